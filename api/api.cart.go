@@ -0,0 +1,402 @@
+package api
+
+import (
+	"afiqo-location/helpers"
+	"afiqo-location/maps"
+	"afiqo-location/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gomodule/redigo/redis"
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	"net/http"
+)
+
+const CART = "CART"
+
+type (
+	Cart struct {
+		TTL int
+	}
+
+	CartModule struct {
+		db     *sql.DB
+		cache  *redis.Pool
+		logger *helpers.Logger
+		name   string
+	}
+
+	// CartItemParam checks the item against a saved address, the given location, or else the customer's
+	// default address.
+	CartItemParam struct {
+		CustomerID uuid.UUID       `json:"customer_id"`
+		ProductID  uuid.UUID       `json:"product_id" validate:"required"`
+		Quantity   uint            `json:"quantity" validate:"required"`
+		AddressID  uuid.UUID       `json:"address_id"`
+		Longitude  decimal.Decimal `json:"longitude"`
+		Latitude   decimal.Decimal `json:"latitude"`
+	}
+
+	CartItemRemoveParam struct {
+		CustomerID uuid.UUID `json:"customer_id"`
+		ProductID  uuid.UUID `json:"product_id"`
+	}
+
+	CartCheckoutParam struct {
+		CustomerID      uuid.UUID       `json:"customer_id"`
+		AddressID       uuid.UUID       `json:"address_id"`
+		DeliveryAddress string          `json:"delivery_address" validate:"required_without=AddressID"`
+		Longitude       decimal.Decimal `json:"longitude"`
+		Latitude        decimal.Decimal `json:"latitude"`
+	}
+
+	CartItemResponse struct {
+		Product  models.ProductResponse `json:"product"`
+		Quantity uint                   `json:"quantity"`
+		SubTotal decimal.Decimal        `json:"sub_total"`
+	}
+
+	CartResponse struct {
+		Items    []CartItemResponse `json:"items"`
+		SubTotal decimal.Decimal    `json:"sub_total"`
+	}
+
+	cachedCartItem struct {
+		ProductID uuid.UUID `json:"product_id"`
+		Quantity  uint      `json:"quantity"`
+	}
+)
+
+var cart = Cart{TTL: 604800}
+
+func InitCart(c Cart) {
+	if c.TTL > 0 {
+		cart = c
+	}
+}
+
+func NewCartModule(db *sql.DB, cache *redis.Pool, logger *helpers.Logger) *CartModule {
+	return &CartModule{
+		db:     db,
+		cache:  cache,
+		logger: logger,
+		name:   "module/cart",
+	}
+}
+
+func cartKey(customerID uuid.UUID) string {
+	return fmt.Sprintf(`%s:%s`, CART, customerID)
+}
+
+func (s CartModule) Detail(ctx context.Context, param CustomerDataParam) (interface{}, *helpers.Error) {
+
+	items, err := s.items(ctx, param.ID)
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Detail/items", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	return s.response(ctx, items, "Detail")
+}
+
+// Add puts more of a product in the cart.
+func (s CartModule) Add(ctx context.Context, param CartItemParam) (interface{}, *helpers.Error) {
+
+	items, err := s.items(ctx, param.CustomerID)
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Add/items", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	for _, item := range items {
+		if item.ProductID == param.ProductID {
+			param.Quantity += item.Quantity
+		}
+	}
+
+	return s.save(ctx, param, "Add")
+}
+
+// Update replaces the quantity of a product in the cart.
+func (s CartModule) Update(ctx context.Context, param CartItemParam) (interface{}, *helpers.Error) {
+	return s.save(ctx, param, "Update")
+}
+
+func (s CartModule) Remove(ctx context.Context, param CartItemRemoveParam) (interface{}, *helpers.Error) {
+
+	cartItem := models.CartItemModel{
+		CustomerID: param.CustomerID,
+		ProductID:  param.ProductID,
+	}
+
+	err := cartItem.Delete(ctx, s.db)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, helpers.ErrorWrap(err, s.name, "Remove/Delete", "Product Not In Cart",
+				http.StatusNotFound)
+		}
+		return nil, helpers.ErrorWrap(err, s.name, "Remove/Delete", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	return s.refresh(ctx, param.CustomerID, "Remove")
+}
+
+// Checkout places the cart as an order through the usual order flow and takes the ordered items out of the cart.
+func (s CartModule) Checkout(ctx context.Context, param CartCheckoutParam) (interface{}, *helpers.Error) {
+
+	items, err := s.items(ctx, param.CustomerID)
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Checkout/items", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	if len(items) == 0 {
+		return nil, helpers.ErrorWrap(errors.New("Cart Is Empty"), s.name, "Checkout/items",
+			"Cart Is Empty", http.StatusBadRequest)
+	}
+
+	var products []Product
+	var ordered []models.CartItemModel
+	for _, item := range items {
+		products = append(products, Product{
+			ID:       item.ProductID,
+			Quantity: item.Quantity,
+		})
+		ordered = append(ordered, models.CartItemModel{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	order, errs := NewOrderModule(s.db, s.cache, s.logger).Order(ctx, OrderParam{
+		AddressID:       param.AddressID,
+		DeliveryAddress: param.DeliveryAddress,
+		Longitude:       param.Longitude,
+		Latitude:        param.Latitude,
+		Product:         products,
+	})
+
+	if errs != nil {
+		return nil, errs
+	}
+
+	// The order is placed, so a cart that fails to clear is only logged. Only what was ordered is removed, items
+	// added while checking out stay in the cart.
+	err = models.DeleteOrderedCartItem(ctx, s.db, param.CustomerID, ordered)
+	if err == nil {
+		err = helpers.DeleteCache(ctx, cartKey(param.CustomerID))
+	}
+
+	if err != nil {
+		s.logger.Err.Printf(`api.cart.go/Checkout/DeleteOrderedCartItem/%v`, err)
+	}
+
+	return order, nil
+}
+
+func (s CartModule) save(ctx context.Context, param CartItemParam, caller string) (interface{}, *helpers.Error) {
+
+	errs := s.checkAvailability(ctx, param, caller)
+	if errs != nil {
+		return nil, errs
+	}
+
+	cartItem := models.CartItemModel{
+		CustomerID: param.CustomerID,
+		ProductID:  param.ProductID,
+		Quantity:   param.Quantity,
+	}
+
+	err := cartItem.Save(ctx, s.db)
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, caller+"/Save", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	return s.refresh(ctx, param.CustomerID, caller)
+}
+
+// checkAvailability accepts the item when the warehouses serving the address hold enough of it.
+func (s CartModule) checkAvailability(ctx context.Context, param CartItemParam, caller string) *helpers.Error {
+
+	location, errs := s.location(ctx, param, caller)
+	if errs != nil {
+		return errs
+	}
+
+	warehouses, err := reachableWarehouses(ctx, s.db, location.Latitude, location.Longitude)
+
+	if err != nil {
+		return reachableError(err, s.name, caller)
+	}
+
+	filter := helpers.Filter{
+		FilterOption: helpers.FilterOption{
+			Limit: 1,
+		},
+		ProductID: param.ProductID,
+	}
+
+	products, err := models.GetAllProductForCustomer(ctx, s.db, filter, warehouseIDs(warehouses))
+
+	if err != nil {
+		return helpers.ErrorWrap(err, s.name, caller+"/GetAllProductForCustomer", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	if len(products) == 0 {
+		return helpers.ErrorWrap(errors.New("Product Not Available"), s.name, caller+"/GetAllProductForCustomer",
+			"Product Not Available", http.StatusConflict)
+	}
+
+	if products[0].Stock < param.Quantity {
+		return helpers.ErrorWrap(errors.New("Insufficient Stock"), s.name, caller+"/GetAllProductForCustomer",
+			fmt.Sprintf(`%s : %s`, helpers.InsufficientStockMessage, products[0].Name), http.StatusConflict)
+	}
+
+	return nil
+}
+
+// location is where the cart would be delivered. A zero location counts as not given, it is what a client
+// without a fix sends and no warehouse serves it.
+func (s CartModule) location(ctx context.Context, param CartItemParam, caller string) (maps.Location,
+	*helpers.Error) {
+
+	if param.AddressID != uuid.Nil {
+		address, errs := savedAddress(ctx, s.db, s.name, caller, param.AddressID)
+		if errs != nil {
+			return maps.Location{}, errs
+		}
+
+		return maps.Location{Latitude: address.Latitude, Longitude: address.Longitude}, nil
+	}
+
+	if !param.Latitude.IsZero() || !param.Longitude.IsZero() {
+		if param.Latitude.LessThan(minLatitude) || param.Latitude.GreaterThan(maxLatitude) ||
+			param.Longitude.LessThan(minLongitude) || param.Longitude.GreaterThan(maxLongitude) {
+			return maps.Location{}, helpers.ErrorWrap(errors.New("Invalid Location"), s.name, caller+"/location",
+				helpers.BadRequestMessage, http.StatusBadRequest)
+		}
+
+		return maps.Location{Latitude: param.Latitude, Longitude: param.Longitude}, nil
+	}
+
+	addresses, err := models.GetAllCustomerAddressByCustomerID(ctx, s.db, param.CustomerID)
+
+	if err != nil {
+		return maps.Location{}, helpers.ErrorWrap(err, s.name, caller+"/GetAllCustomerAddressByCustomerID",
+			helpers.InternalServerError, http.StatusInternalServerError)
+	}
+
+	// Addresses come default first.
+	if len(addresses) == 0 || !addresses[0].IsDefault {
+		return maps.Location{}, helpers.ErrorWrap(errors.New("Delivery Location Required"), s.name,
+			caller+"/location", "Delivery Location Required", http.StatusBadRequest)
+	}
+
+	return maps.Location{Latitude: addresses[0].Latitude, Longitude: addresses[0].Longitude}, nil
+}
+
+// items reads the cart from the cache, loading it from postgres when the cached copy has expired.
+func (s CartModule) items(ctx context.Context, customerID uuid.UUID) ([]cachedCartItem, error) {
+
+	var items []cachedCartItem
+
+	data, err := helpers.GetDataFromCache(ctx, cartKey(customerID))
+	if err == nil && json.Unmarshal([]byte(data), &items) == nil {
+		return items, nil
+	}
+
+	if err != nil && err != redis.ErrNil {
+		s.logger.Err.Printf(`api.cart.go/items/GetDataFromCache/%v`, err)
+	}
+
+	return s.load(ctx, customerID)
+}
+
+// load reads the cart from postgres and caches it again.
+func (s CartModule) load(ctx context.Context, customerID uuid.UUID) ([]cachedCartItem, error) {
+
+	cartItems, err := models.GetAllCartItemByCustomerID(ctx, s.db, customerID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	items := []cachedCartItem{}
+	for _, cartItem := range cartItems {
+		items = append(items, cachedCartItem{
+			ProductID: cartItem.ProductID,
+			Quantity:  cartItem.Quantity,
+		})
+	}
+
+	data, err := json.Marshal(items)
+	if err == nil {
+		err = helpers.SetDataToCacheWithExpiry(ctx, cartKey(customerID), string(data), cart.TTL)
+	}
+
+	if err != nil {
+		s.logger.Err.Printf(`api.cart.go/load/SetDataToCacheWithExpiry/%v`, err)
+	}
+
+	return items, nil
+}
+
+func (s CartModule) refresh(ctx context.Context, customerID uuid.UUID, caller string) (interface{}, *helpers.Error) {
+
+	items, err := s.load(ctx, customerID)
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, caller+"/load", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	return s.response(ctx, items, caller)
+}
+
+func (s CartModule) response(ctx context.Context, items []cachedCartItem, caller string) (
+	interface{}, *helpers.Error) {
+
+	response := CartResponse{
+		Items: []CartItemResponse{},
+	}
+
+	for _, item := range items {
+
+		product, err := models.GetOneProduct(ctx, s.db, item.ProductID)
+
+		if err != nil {
+			return nil, helpers.ErrorWrap(err, s.name, caller+"/GetOneProduct", helpers.InternalServerError,
+				http.StatusInternalServerError)
+		}
+
+		productResponse, err := product.Response(ctx, s.db, s.logger)
+
+		if err != nil {
+			return nil, helpers.ErrorWrap(err, s.name, caller+"/Response", helpers.InternalServerError,
+				http.StatusInternalServerError)
+		}
+
+		subTotal := product.Price.Mul(decimal.NewFromInt(int64(item.Quantity)))
+
+		response.Items = append(response.Items, CartItemResponse{
+			Product:  productResponse,
+			Quantity: item.Quantity,
+			SubTotal: subTotal,
+		})
+
+		response.SubTotal = response.SubTotal.Add(subTotal)
+	}
+
+	return response, nil
+}
@@ -5,6 +5,7 @@ import (
 	"afiqo-location/models"
 	"context"
 	"database/sql"
+	"errors"
 	"github.com/gomodule/redigo/redis"
 	uuid "github.com/satori/go.uuid"
 	"github.com/shopspring/decimal"
@@ -19,8 +20,12 @@ type (
 		name   string
 	}
 
+	// ConfigurationUpdateParam leaves the stored per km fee and free delivery threshold as they are when they
+	// are not given.
 	ConfigurationUpdateParam struct {
-		DeliveryFee decimal.Decimal `json:"delivery_fee" validate:"required"`
+		DeliveryFee           decimal.Decimal     `json:"delivery_fee" validate:"required"`
+		DeliveryFeePerKm      decimal.NullDecimal `json:"delivery_fee_per_km"`
+		FreeDeliveryThreshold decimal.NullDecimal `json:"free_delivery_threshold"`
 	}
 )
 
@@ -47,15 +52,31 @@ func (s ConfigurationModule) GetFee(ctx context.Context) (decimal.Decimal, *help
 
 func (s ConfigurationModule) Update(ctx context.Context, param ConfigurationUpdateParam) (interface{}, *helpers.Error) {
 
-	configuration := models.ConfigurationModel{
-		DeliveryFee: param.DeliveryFee,
-		UpdatedBy: uuid.NullUUID{
-			UUID:  uuid.FromStringOrNil(ctx.Value("user_id").(string)),
-			Valid: true,
-		},
+	if param.DeliveryFee.IsNegative() || param.DeliveryFeePerKm.Decimal.IsNegative() ||
+		param.FreeDeliveryThreshold.Decimal.IsNegative() {
+		return nil, helpers.ErrorWrap(errors.New("Negative Delivery Fee"), s.name, "Update/Validate",
+			helpers.BadRequestMessage, http.StatusBadRequest)
 	}
 
-	err := configuration.Update(ctx, s.db)
+	configuration, err := models.GetConfiguration(ctx, s.db)
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Update/GetConfiguration", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	configuration.DeliveryFee = param.DeliveryFee
+	if param.DeliveryFeePerKm.Valid {
+		configuration.DeliveryFeePerKm = param.DeliveryFeePerKm.Decimal
+	}
+	if param.FreeDeliveryThreshold.Valid {
+		configuration.FreeDeliveryThreshold = param.FreeDeliveryThreshold.Decimal
+	}
+	configuration.UpdatedBy = uuid.NullUUID{
+		UUID:  uuid.FromStringOrNil(ctx.Value("user_id").(string)),
+		Valid: true,
+	}
+
+	err = configuration.Update(ctx, s.db)
 	if err != nil {
 		return nil, helpers.ErrorWrap(err, s.name, "Update/Update", helpers.InternalServerError,
 			http.StatusInternalServerError)
@@ -39,17 +39,19 @@ type (
 	}
 
 	CourierAddParam struct {
-		Name    string `json:"name" validate:"max=20,min=4,required"`
-		Address string `json:"address" validate:"required"`
-		PhoneNo string `json:"phone_no" validate:"required"`
-		Email   string `json:"email" validate:"email,required"`
+		Name        string    `json:"name" validate:"max=20,min=4,required"`
+		Address     string    `json:"address" validate:"required"`
+		PhoneNo     string    `json:"phone_no" validate:"required"`
+		Email       string    `json:"email" validate:"email,required"`
+		WarehouseID uuid.UUID `json:"warehouse_id"`
 	}
 
 	CourierUpdateParam struct {
-		ID      uuid.UUID `json:"id"`
-		Name    string    `json:"name" validate:"max=20,min=4,required"`
-		Address string    `json:"address" validate:"required"`
-		PhoneNo string    `json:"phone_no" validate:"required"`
+		ID          uuid.UUID `json:"id"`
+		Name        string    `json:"name" validate:"max=20,min=4,required"`
+		Address     string    `json:"address" validate:"required"`
+		PhoneNo     string    `json:"phone_no" validate:"required"`
+		WarehouseID uuid.UUID `json:"warehouse_id"`
 	}
 
 	CourierDeleteParam struct {
@@ -200,15 +202,27 @@ func (s CourierModule) Add(ctx context.Context, param CourierAddParam) (interfac
 	password := util.RandomString(10)
 
 	courier := models.CourierModel{
-		Name:      param.Name,
-		PhoneNo:   param.PhoneNo,
-		Email:     param.Email,
-		Address:   param.Address,
-		Password:  password,
+		Name:     param.Name,
+		PhoneNo:  param.PhoneNo,
+		Email:    param.Email,
+		Address:  param.Address,
+		Password: password,
+		WarehouseID: uuid.NullUUID{
+			UUID:  param.WarehouseID,
+			Valid: param.WarehouseID != uuid.Nil,
+		},
 		CreatedBy: uuid.FromStringOrNil(ctx.Value("user_id").(string)),
 	}
 
-	err := courier.Insert(ctx, s.db)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Add/BeginTx", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	defer tx.Rollback()
+
+	err = courier.Insert(ctx, tx)
 	if err != nil {
 		return nil, helpers.ErrorWrap(err, s.name, "Add/Insert", helpers.InternalServerError,
 			http.StatusInternalServerError)
@@ -238,9 +252,17 @@ func (s CourierModule) Add(ctx context.Context, param CourierAddParam) (interfac
 		To:      courier.Email,
 	}
 
-	go func() {
-		mail.SendEmail()
-	}()
+	err = queueMail(ctx, tx, mail)
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Add/queueMail", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Add/Commit", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
 
 	return courier.Response(), nil
 }
@@ -252,6 +274,10 @@ func (s CourierModule) Update(ctx context.Context, param CourierUpdateParam) (in
 		Name:    param.Name,
 		Address: param.Address,
 		PhoneNo: param.PhoneNo,
+		WarehouseID: uuid.NullUUID{
+			UUID:  param.WarehouseID,
+			Valid: param.WarehouseID != uuid.Nil,
+		},
 		UpdatedBy: uuid.NullUUID{
 			UUID:  uuid.FromStringOrNil(ctx.Value("user_id").(string)),
 			Valid: true,
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"afiqo-location/helpers"
+	"afiqo-location/models"
+	"afiqo-location/util"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gomodule/redigo/redis"
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	"net/http"
+	"sort"
+	"time"
+)
+
+const COURIER_LOCATION = "COURIER_LOCATION"
+
+type (
+	CourierLocationModule struct {
+		db     *sql.DB
+		cache  *redis.Pool
+		logger *helpers.Logger
+		name   string
+	}
+
+	CourierLocationFix struct {
+		Latitude  decimal.Decimal `json:"latitude" validate:"required"`
+		Longitude decimal.Decimal `json:"longitude" validate:"required"`
+		Accuracy  decimal.Decimal `json:"accuracy"`
+		Timestamp time.Time       `json:"timestamp" validate:"required"`
+	}
+
+	CourierLocationAddParam struct {
+		CourierID uuid.UUID            `json:"courier_id"`
+		Locations []CourierLocationFix `json:"locations" validate:"required,min=1,max=500,dive"`
+	}
+
+	CourierLocationShipmentParam struct {
+		ShipmentID uuid.UUID `json:"shipment_id"`
+	}
+)
+
+var (
+	minLatitude  = decimal.NewFromInt(-90)
+	maxLatitude  = decimal.NewFromInt(90)
+	minLongitude = decimal.NewFromInt(-180)
+	maxLongitude = decimal.NewFromInt(180)
+)
+
+// fixClockSkew is how far ahead of the server a courier's device clock may run before its fixes are refused.
+const fixClockSkew = time.Minute
+
+func NewCourierLocationModule(db *sql.DB, cache *redis.Pool, logger *helpers.Logger) *CourierLocationModule {
+	return &CourierLocationModule{
+		db:     db,
+		cache:  cache,
+		logger: logger,
+		name:   "module/courier_location",
+	}
+}
+
+func courierLocationKey(courierID uuid.UUID) string {
+	return fmt.Sprintf(`%s:%s`, COURIER_LOCATION, courierID)
+}
+
+func (s CourierLocationModule) Add(ctx context.Context, param CourierLocationAddParam) (interface{}, *helpers.Error) {
+
+	// A fix stamped in the future would keep newer fixes out of the cached position.
+	latestAllowed := time.Now().Add(fixClockSkew)
+
+	for _, fix := range param.Locations {
+		if fix.Timestamp.After(latestAllowed) {
+			return nil, helpers.ErrorWrap(errors.New("Location In Future"), s.name, "Add/Timestamp",
+				helpers.BadRequestMessage, http.StatusBadRequest)
+		}
+
+		if fix.Latitude.LessThan(minLatitude) || fix.Latitude.GreaterThan(maxLatitude) ||
+			fix.Longitude.LessThan(minLongitude) || fix.Longitude.GreaterThan(maxLongitude) ||
+			fix.Accuracy.IsNegative() {
+			return nil, helpers.ErrorWrap(errors.New("Invalid Location"), s.name, "Add/Validate",
+				helpers.BadRequestMessage, http.StatusBadRequest)
+		}
+	}
+
+	fixes := append([]CourierLocationFix(nil), param.Locations...)
+	sort.SliceStable(fixes, func(i, j int) bool {
+		return fixes[i].Timestamp.Before(fixes[j].Timestamp)
+	})
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Add/BeginTx", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	defer tx.Rollback()
+
+	var courierLocation models.CourierLocationModel
+	for _, fix := range fixes {
+		courierLocation = models.CourierLocationModel{
+			CourierID:  param.CourierID,
+			Latitude:   fix.Latitude,
+			Longitude:  fix.Longitude,
+			Accuracy:   fix.Accuracy,
+			RecordedAt: fix.Timestamp.UTC(),
+		}
+
+		err = courierLocation.Insert(ctx, tx)
+
+		if err != nil {
+			return nil, helpers.ErrorWrap(err, s.name, "Add/Insert", helpers.InternalServerError,
+				http.StatusInternalServerError)
+		}
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Add/Commit", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	latest := courierLocation.Response()
+
+	// Batches can arrive out of order, so a delayed batch must not overwrite a newer position.
+	cached, err := s.cachedLocation(ctx, param.CourierID)
+	if err == nil && cached.RecordedAt.After(latest.RecordedAt) {
+		return cached, nil
+	}
+
+	data, err := json.Marshal(latest)
+	if err == nil {
+		err = helpers.SetDataToCache(ctx, courierLocationKey(param.CourierID), string(data))
+	}
+
+	if err != nil {
+		s.logger.Err.Printf(`api.courier.location.go/Add/SetDataToCache/%v`, err)
+	}
+
+	publish(ctx, s.logger, courierLocationKey(param.CourierID), StreamEventLocation, latest)
+
+	return latest, nil
+}
+
+// ShipmentCourier returns the last known position of the courier carrying a customer's shipment
+// while it is still on its way.
+func (s CourierLocationModule) ShipmentCourier(ctx context.Context, param CourierLocationShipmentParam) (
+	interface{}, *helpers.Error) {
+
+	shipment, errs := NewShipmentModule(s.db, s.cache, s.logger).visibleShipment(ctx, param.ShipmentID,
+		"ShipmentCourier")
+	if errs != nil {
+		return nil, errs
+	}
+
+	if shipment.IsDelete || shipment.Status == util.ShipmentStatusDelivered {
+		return nil, helpers.ErrorWrap(errors.New("Shipment Not Active"), s.name, "ShipmentCourier/Status",
+			"Shipment Not Active", http.StatusConflict)
+	}
+
+	location, err := s.cachedLocation(ctx, shipment.CourierID)
+	if err == nil {
+		return location, nil
+	}
+
+	if err != redis.ErrNil {
+		s.logger.Err.Printf(`api.courier.location.go/ShipmentCourier/GetDataFromCache/%v`, err)
+	}
+
+	courierLocation, err := models.GetLatestCourierLocation(ctx, s.db, shipment.CourierID)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, helpers.ErrorWrap(err, s.name, "ShipmentCourier/GetLatestCourierLocation",
+				"Courier Location Unknown", http.StatusNotFound)
+		}
+		return nil, helpers.ErrorWrap(err, s.name, "ShipmentCourier/GetLatestCourierLocation",
+			helpers.InternalServerError, http.StatusInternalServerError)
+	}
+
+	return courierLocation.Response(), nil
+}
+
+func (s CourierLocationModule) cachedLocation(ctx context.Context, courierID uuid.UUID) (
+	models.CourierLocationResponse, error) {
+
+	var location models.CourierLocationResponse
+
+	data, err := helpers.GetDataFromCache(ctx, courierLocationKey(courierID))
+	if err != nil {
+		return location, err
+	}
+
+	err = json.Unmarshal([]byte(data), &location)
+
+	return location, err
+}
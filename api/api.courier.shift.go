@@ -0,0 +1,275 @@
+package api
+
+import (
+	"afiqo-location/helpers"
+	"afiqo-location/models"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/gomodule/redigo/redis"
+	uuid "github.com/satori/go.uuid"
+	"net/http"
+	"time"
+)
+
+const shiftClock = "15:04"
+
+type (
+	CourierShiftModule struct {
+		db     *sql.DB
+		cache  *redis.Pool
+		logger *helpers.Logger
+		name   string
+	}
+
+	CourierShiftDetailParam struct {
+		ID uuid.UUID `json:"id"`
+	}
+
+	CourierShiftParam struct {
+		ID          uuid.UUID `json:"id"`
+		CourierID   uuid.UUID `json:"courier_id"`
+		WarehouseID uuid.UUID `json:"warehouse_id"`
+		Weekday     int       `json:"weekday" validate:"min=0,max=6"`
+		StartTime   string    `json:"start_time" validate:"required"`
+		EndTime     string    `json:"end_time" validate:"required"`
+		MaxParcels  int       `json:"max_parcels" validate:"min=0"`
+	}
+)
+
+func NewCourierShiftModule(db *sql.DB, cache *redis.Pool, logger *helpers.Logger) *CourierShiftModule {
+	return &CourierShiftModule{
+		db:     db,
+		cache:  cache,
+		logger: logger,
+		name:   "module/courier_shift",
+	}
+}
+
+// activeShift finds the shift the courier is working at the given time.
+func activeShift(shifts []models.CourierShiftModel, now time.Time) (models.CourierShiftModel, bool) {
+
+	clock := now.Format(shiftClock)
+	for _, shift := range shifts {
+		if shift.Weekday == int(now.Weekday()) && shift.StartTime <= clock && clock < shift.EndTime {
+			return shift, true
+		}
+	}
+
+	return models.CourierShiftModel{}, false
+}
+
+// shiftWindow is when the shift runs on the day of now.
+func shiftWindow(shift models.CourierShiftModel, now time.Time) (time.Time, time.Time) {
+
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	at := func(clock string) time.Time {
+		parsed, _ := time.Parse(shiftClock, clock)
+		return day.Add(time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute)
+	}
+
+	return at(shift.StartTime), at(shift.EndTime)
+}
+
+// shiftLoad counts the parcels handed to the courier since their current shift started, when that shift caps
+// them. Without a capping shift the courier's load is what they still carry, and ok is false.
+func shiftLoad(ctx context.Context, db helpers.DBExecutor, courierID uuid.UUID,
+	shifts []models.CourierShiftModel, now time.Time) (int, bool, error) {
+
+	shift, ok := activeShift(shifts, now)
+	if !ok || shift.MaxParcels <= 0 {
+		return 0, false, nil
+	}
+
+	start, end := shiftWindow(shift, now)
+
+	load, err := models.CountShipmentAssignedToCourier(ctx, db, courierID, start, end)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return load, true, nil
+}
+
+// courierAvailability works out where a courier is based and how many parcels they may take right now. Couriers
+// without any shift are not scheduled and stay available with their own home warehouse and the dispatch load
+// limit. Couriers with shifts are only available during one, which can override both.
+func courierAvailability(courier models.CourierModel, shifts []models.CourierShiftModel, now time.Time) (
+	uuid.NullUUID, int, bool) {
+
+	home, capacity := courier.WarehouseID, dispatch.MaxLoad
+
+	if len(shifts) == 0 {
+		return home, capacity, true
+	}
+
+	shift, ok := activeShift(shifts, now)
+	if !ok {
+		return home, capacity, false
+	}
+
+	if shift.WarehouseID.Valid {
+		home = shift.WarehouseID
+	}
+
+	if shift.MaxParcels > 0 {
+		capacity = shift.MaxParcels
+	}
+
+	return home, capacity, true
+}
+
+func (s CourierShiftModule) Detail(ctx context.Context, param CourierShiftDetailParam) (interface{}, *helpers.Error) {
+
+	shift, err := models.GetOneCourierShift(ctx, s.db, param.ID)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, helpers.ErrorWrap(err, s.name, "Detail/GetOneCourierShift", helpers.BadRequestMessage,
+				http.StatusNotFound)
+		}
+		return nil, helpers.ErrorWrap(err, s.name, "Detail/GetOneCourierShift", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	return shift.Response(), nil
+}
+
+func (s CourierShiftModule) List(ctx context.Context, filter helpers.Filter) (interface{}, *helpers.Error) {
+
+	shifts, err := models.GetAllCourierShift(ctx, s.db, filter)
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "List/GetAllCourierShift", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	shiftResponse := []models.CourierShiftResponse{}
+	for _, shift := range shifts {
+		shiftResponse = append(shiftResponse, shift.Response())
+	}
+
+	return shiftResponse, nil
+}
+
+func (s CourierShiftModule) Add(ctx context.Context, param CourierShiftParam) (interface{}, *helpers.Error) {
+
+	errs := s.validate(param, "Add")
+	if errs != nil {
+		return nil, errs
+	}
+
+	_, err := models.GetOneCourier(ctx, s.db, param.CourierID)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, helpers.ErrorWrap(err, s.name, "Add/GetOneCourier", helpers.BadRequestMessage,
+				http.StatusBadRequest)
+		}
+		return nil, helpers.ErrorWrap(err, s.name, "Add/GetOneCourier", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	shift := models.CourierShiftModel{
+		CourierID: param.CourierID,
+		WarehouseID: uuid.NullUUID{
+			UUID:  param.WarehouseID,
+			Valid: param.WarehouseID != uuid.Nil,
+		},
+		Weekday:    param.Weekday,
+		StartTime:  param.StartTime,
+		EndTime:    param.EndTime,
+		MaxParcels: param.MaxParcels,
+		CreatedBy:  uuid.FromStringOrNil(ctx.Value("user_id").(string)),
+	}
+
+	err = shift.Insert(ctx, s.db)
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Add/Insert", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	return shift.Response(), nil
+}
+
+func (s CourierShiftModule) Update(ctx context.Context, param CourierShiftParam) (interface{}, *helpers.Error) {
+
+	errs := s.validate(param, "Update")
+	if errs != nil {
+		return nil, errs
+	}
+
+	shift := models.CourierShiftModel{
+		ID: param.ID,
+		WarehouseID: uuid.NullUUID{
+			UUID:  param.WarehouseID,
+			Valid: param.WarehouseID != uuid.Nil,
+		},
+		Weekday:    param.Weekday,
+		StartTime:  param.StartTime,
+		EndTime:    param.EndTime,
+		MaxParcels: param.MaxParcels,
+		UpdatedBy: uuid.NullUUID{
+			UUID:  uuid.FromStringOrNil(ctx.Value("user_id").(string)),
+			Valid: true,
+		},
+	}
+
+	err := shift.Update(ctx, s.db)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, helpers.ErrorWrap(err, s.name, "Update/Update", helpers.BadRequestMessage,
+				http.StatusNotFound)
+		}
+		return nil, helpers.ErrorWrap(err, s.name, "Update/Update", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	return shift.Response(), nil
+}
+
+func (s CourierShiftModule) Delete(ctx context.Context, param CourierShiftDetailParam) (interface{}, *helpers.Error) {
+
+	shift := models.CourierShiftModel{
+		ID: param.ID,
+		UpdatedBy: uuid.NullUUID{
+			UUID:  uuid.FromStringOrNil(ctx.Value("user_id").(string)),
+			Valid: true,
+		},
+	}
+
+	err := shift.Delete(ctx, s.db)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, helpers.ErrorWrap(err, s.name, "Delete/Delete", helpers.BadRequestMessage,
+				http.StatusNotFound)
+		}
+		return nil, helpers.ErrorWrap(err, s.name, "Delete/Delete", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	return nil, nil
+}
+
+// validate accepts HH:MM times with the shift ending later on the same day.
+func (s CourierShiftModule) validate(param CourierShiftParam, caller string) *helpers.Error {
+
+	start, err := time.Parse(shiftClock, param.StartTime)
+	if err == nil {
+		var end time.Time
+		end, err = time.Parse(shiftClock, param.EndTime)
+		if err == nil && !end.After(start) {
+			err = errors.New("Shift Ends Before It Starts")
+		}
+	}
+
+	if err != nil {
+		return helpers.ErrorWrap(err, s.name, caller+"/validate", "Invalid Shift Time", http.StatusBadRequest)
+	}
+
+	return nil
+}
@@ -0,0 +1,250 @@
+package api
+
+import (
+	"afiqo-location/helpers"
+	"afiqo-location/maps"
+	"afiqo-location/models"
+	"context"
+	"database/sql"
+	"github.com/gomodule/redigo/redis"
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	"net/http"
+)
+
+type (
+	CustomerAddressModule struct {
+		db     *sql.DB
+		cache  *redis.Pool
+		logger *helpers.Logger
+		name   string
+	}
+
+	CustomerAddressDetailParam struct {
+		ID         uuid.UUID `json:"id"`
+		CustomerID uuid.UUID `json:"customer_id"`
+	}
+
+	CustomerAddressParam struct {
+		ID         uuid.UUID       `json:"id"`
+		CustomerID uuid.UUID       `json:"customer_id"`
+		Label      string          `json:"label" validate:"required"`
+		Address    string          `json:"address" validate:"required"`
+		Latitude   decimal.Decimal `json:"latitude"`
+		Longitude  decimal.Decimal `json:"longitude"`
+		IsDefault  bool            `json:"is_default"`
+	}
+)
+
+func NewCustomerAddressModule(db *sql.DB, cache *redis.Pool, logger *helpers.Logger) *CustomerAddressModule {
+	return &CustomerAddressModule{
+		db:     db,
+		cache:  cache,
+		logger: logger,
+		name:   "module/customer_address",
+	}
+}
+
+// savedAddress looks up one of the calling customer's addresses for an order or product search.
+func savedAddress(ctx context.Context, db *sql.DB, name, caller string, addressID uuid.UUID) (
+	models.CustomerAddressModel, *helpers.Error) {
+
+	customerID := uuid.FromStringOrNil(ctx.Value("user_id").(string))
+
+	address, err := models.GetOneCustomerAddress(ctx, db, addressID, customerID)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return address, helpers.ErrorWrap(err, name, caller+"/GetOneCustomerAddress",
+				helpers.AddressNotFoundMessage, http.StatusBadRequest)
+		}
+		return address, helpers.ErrorWrap(err, name, caller+"/GetOneCustomerAddress", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	return address, nil
+}
+
+func (s CustomerAddressModule) List(ctx context.Context, param CustomerDataParam) (interface{}, *helpers.Error) {
+
+	addresses, err := models.GetAllCustomerAddressByCustomerID(ctx, s.db, param.ID)
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "List/GetAllCustomerAddressByCustomerID",
+			helpers.InternalServerError, http.StatusInternalServerError)
+	}
+
+	addressResponse := []models.CustomerAddressResponse{}
+	for _, address := range addresses {
+		addressResponse = append(addressResponse, address.Response())
+	}
+
+	return addressResponse, nil
+}
+
+func (s CustomerAddressModule) Detail(ctx context.Context, param CustomerAddressDetailParam) (
+	interface{}, *helpers.Error) {
+
+	address, errs := s.get(ctx, param.ID, param.CustomerID, "Detail")
+	if errs != nil {
+		return nil, errs
+	}
+
+	return address.Response(), nil
+}
+
+// Add saves a new address. The first address a customer saves becomes their default.
+func (s CustomerAddressModule) Add(ctx context.Context, param CustomerAddressParam) (interface{}, *helpers.Error) {
+
+	location, mismatch, err := locate(ctx, maps.AddressGeocoder(), param.Address, param.Latitude, param.Longitude)
+
+	if err != nil {
+		return nil, locateError(err, s.name, "Add")
+	}
+
+	addresses, err := models.GetAllCustomerAddressByCustomerID(ctx, s.db, param.CustomerID)
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Add/GetAllCustomerAddressByCustomerID",
+			helpers.InternalServerError, http.StatusInternalServerError)
+	}
+
+	address := models.CustomerAddressModel{
+		CustomerID:      param.CustomerID,
+		Label:           param.Label,
+		Address:         param.Address,
+		Latitude:        location.Latitude,
+		Longitude:       location.Longitude,
+		AddressMismatch: mismatch,
+		IsDefault:       param.IsDefault || len(addresses) == 0,
+	}
+
+	errs := s.save(ctx, &address, (*models.CustomerAddressModel).Insert, "Add")
+	if errs != nil {
+		return nil, errs
+	}
+
+	return address.Response(), nil
+}
+
+func (s CustomerAddressModule) Update(ctx context.Context, param CustomerAddressParam) (
+	interface{}, *helpers.Error) {
+
+	current, errs := s.get(ctx, param.ID, param.CustomerID, "Update")
+	if errs != nil {
+		return nil, errs
+	}
+
+	// Coordinates that are not sent stay as they are unless the address moved, then they are geocoded again.
+	latitude, longitude := param.Latitude, param.Longitude
+	if latitude.IsZero() && longitude.IsZero() && param.Address == current.Address {
+		latitude, longitude = current.Latitude, current.Longitude
+	}
+
+	location, mismatch, err := locate(ctx, maps.AddressGeocoder(), param.Address, latitude, longitude)
+
+	if err != nil {
+		return nil, locateError(err, s.name, "Update")
+	}
+
+	address := models.CustomerAddressModel{
+		ID:              param.ID,
+		CustomerID:      param.CustomerID,
+		Label:           param.Label,
+		Address:         param.Address,
+		Latitude:        location.Latitude,
+		Longitude:       location.Longitude,
+		AddressMismatch: mismatch,
+		IsDefault:       param.IsDefault || current.IsDefault,
+	}
+
+	errs = s.save(ctx, &address, (*models.CustomerAddressModel).Update, "Update")
+	if errs != nil {
+		return nil, errs
+	}
+
+	return address.Response(), nil
+}
+
+func (s CustomerAddressModule) Delete(ctx context.Context, param CustomerAddressDetailParam) (
+	interface{}, *helpers.Error) {
+
+	address := models.CustomerAddressModel{
+		ID:         param.ID,
+		CustomerID: param.CustomerID,
+	}
+
+	err := address.Delete(ctx, s.db)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, helpers.ErrorWrap(err, s.name, "Delete/Delete", helpers.AddressNotFoundMessage,
+				http.StatusNotFound)
+		}
+		return nil, helpers.ErrorWrap(err, s.name, "Delete/Delete", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	return nil, nil
+}
+
+func (s CustomerAddressModule) get(ctx context.Context, addressID, customerID uuid.UUID, caller string) (
+	models.CustomerAddressModel, *helpers.Error) {
+
+	address, err := models.GetOneCustomerAddress(ctx, s.db, addressID, customerID)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return address, helpers.ErrorWrap(err, s.name, caller+"/GetOneCustomerAddress",
+				helpers.AddressNotFoundMessage, http.StatusNotFound)
+		}
+		return address, helpers.ErrorWrap(err, s.name, caller+"/GetOneCustomerAddress",
+			helpers.InternalServerError, http.StatusInternalServerError)
+	}
+
+	return address, nil
+}
+
+// save writes the address, first taking the default flag off the customer's other address when this one
+// becomes the default.
+func (s CustomerAddressModule) save(ctx context.Context, address *models.CustomerAddressModel,
+	write func(*models.CustomerAddressModel, context.Context, helpers.DBExecutor) error, caller string) *helpers.Error {
+
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return helpers.ErrorWrap(err, s.name, caller+"/BeginTx", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	defer tx.Rollback()
+
+	if address.IsDefault {
+		err = models.ClearDefaultCustomerAddress(ctx, tx, address.CustomerID)
+
+		if err != nil {
+			return helpers.ErrorWrap(err, s.name, caller+"/ClearDefaultCustomerAddress", helpers.InternalServerError,
+				http.StatusInternalServerError)
+		}
+	}
+
+	err = write(address, ctx, tx)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return helpers.ErrorWrap(err, s.name, caller+"/write", helpers.AddressNotFoundMessage,
+				http.StatusNotFound)
+		}
+		return helpers.ErrorWrap(err, s.name, caller+"/write", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		return helpers.ErrorWrap(err, s.name, caller+"/Commit", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	return nil
+}
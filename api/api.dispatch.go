@@ -0,0 +1,378 @@
+package api
+
+import (
+	"afiqo-location/helpers"
+	"afiqo-location/maps"
+	"afiqo-location/models"
+	"afiqo-location/util"
+	"context"
+	"database/sql"
+	"errors"
+	uuid "github.com/satori/go.uuid"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// DispatchActor is the actor recorded on shipment events created by automatic dispatch.
+const DispatchActor = "DISPATCH"
+
+type (
+	Dispatch struct {
+		// MaxLoad is how many undelivered shipments a courier may hold before they are passed over, unless their
+		// shift caps the parcels handed to them during it. Zero is unlimited.
+		MaxLoad int
+	}
+
+	ShipmentCourierParam struct {
+		ID        uuid.UUID `json:"id"`
+		CourierID uuid.UUID `json:"courier_id" validate:"required"`
+	}
+
+	courierCandidate struct {
+		courier  models.CourierModel
+		home     bool
+		load     int
+		capacity int
+		distance float64
+	}
+)
+
+var dispatch Dispatch
+
+// maxPickAttempts bounds how often dispatch picks again after its courier filled up before the shipment was
+// created.
+const maxPickAttempts = 3
+
+var (
+	errNoCourierAvailable = errors.New("no courier available")
+	errCourierNotActive   = errors.New("courier not active")
+	errCourierOffShift    = errors.New("courier off shift")
+	errCourierAtCapacity  = errors.New("courier at capacity")
+)
+
+func InitDispatch(d Dispatch) {
+	dispatch = d
+}
+
+// chooseCourier prefers couriers based at the shipment's warehouse, then the least loaded, then the closest.
+// Couriers at their capacity are never chosen, a zero capacity is unlimited.
+func chooseCourier(candidates []courierCandidate) (models.CourierModel, bool) {
+
+	var available []courierCandidate
+	for _, candidate := range candidates {
+		if candidate.capacity <= 0 || candidate.load < candidate.capacity {
+			available = append(available, candidate)
+		}
+	}
+
+	if len(available) == 0 {
+		return models.CourierModel{}, false
+	}
+
+	sort.SliceStable(available, func(i, j int) bool {
+		a, b := available[i], available[j]
+		if a.home != b.home {
+			return a.home
+		}
+		if a.load != b.load {
+			return a.load < b.load
+		}
+		return a.distance < b.distance
+	})
+
+	return available[0].courier, true
+}
+
+// Dispatch ships a confirmed order from each of its warehouses with an automatically picked courier. Warehouses
+// that already ship the order are skipped. It is best effort: anything left undispatched is logged and can
+// still be shipped by an admin.
+func (s ShipmentModule) Dispatch(ctx context.Context, orderID uuid.UUID) {
+
+	warehouses, err := models.GetAllWarehouseByOrderID(ctx, s.db, orderID)
+
+	if err != nil {
+		s.logger.Err.Printf(`api.dispatch.go/Dispatch/GetAllWarehouseByOrderID/%s/%v`, orderID, err)
+		return
+	}
+
+	for _, warehouse := range warehouses {
+
+		_, err = models.GetOneShipmentByOrderAndWarehouse(ctx, s.db, orderID, warehouse.ID)
+		if err != sql.ErrNoRows {
+			if err != nil {
+				s.logger.Err.Printf(`api.dispatch.go/Dispatch/GetOneShipmentByOrderAndWarehouse/%s/%v`, orderID, err)
+			}
+			continue
+		}
+
+		shipment := models.ShipmentModel{
+			OrderID:     orderID,
+			WarehouseID: warehouse.ID,
+			Status:      util.ShipmentStatusProcessing,
+			CreatedBy:   uuid.FromStringOrNil(ctx.Value("user_id").(string)),
+		}
+
+		errs := s.createWithPickedCourier(ctx, &shipment, DispatchActor, "Dispatch")
+		if errs != nil {
+			s.logger.Err.Printf(`api.dispatch.go/Dispatch/createWithPickedCourier/%s/%s/%v`, orderID, warehouse.ID,
+				errs.Err)
+		}
+	}
+}
+
+// AssignCourier lets an admin hand a shipment that is not delivered yet to another courier. The courier is checked
+// in the same transaction as the handover, so they cannot fill up in between.
+func (s ShipmentModule) AssignCourier(ctx context.Context, param ShipmentCourierParam) (interface{}, *helpers.Error) {
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "AssignCourier/BeginTx", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	defer tx.Rollback()
+
+	err = checkCourier(ctx, tx, param.CourierID)
+	if err != nil {
+		return nil, courierError(err, s.name, "AssignCourier")
+	}
+
+	shipment := models.ShipmentModel{
+		ID:        param.ID,
+		CourierID: param.CourierID,
+		UpdatedBy: uuid.NullUUID{
+			UUID:  uuid.FromStringOrNil(ctx.Value("user_id").(string)),
+			Valid: true,
+		},
+	}
+
+	err = shipment.UpdateCourier(ctx, tx)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, helpers.ErrorWrap(err, s.name, "AssignCourier/UpdateCourier", helpers.ShipmentStatusMessage,
+				http.StatusConflict)
+		}
+		return nil, helpers.ErrorWrap(err, s.name, "AssignCourier/UpdateCourier", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "AssignCourier/Commit", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	response, err := shipment.Response(ctx, s.db, s.logger)
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "AssignCourier/Response", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	// Live tracking follows the shipment to its new courier.
+	publish(ctx, s.logger, shipmentEventChannel(shipment.ID), StreamEventCourier, response.Courier)
+
+	return response, nil
+}
+
+// createWithPickedCourier creates the shipment with the best courier for its warehouse. A courier who filled up
+// between being picked and the shipment being created is passed over by picking again.
+func (s ShipmentModule) createWithPickedCourier(ctx context.Context, shipment *models.ShipmentModel,
+	actor, caller string) *helpers.Error {
+
+	var errs *helpers.Error
+	for attempt := 0; attempt < maxPickAttempts; attempt++ {
+
+		var courier models.CourierModel
+		courier, errs = s.pickCourier(ctx, shipment.WarehouseID, caller)
+		if errs != nil {
+			return errs
+		}
+
+		shipment.CourierID = courier.ID
+
+		errs = s.create(ctx, shipment, actor, caller)
+		if errs == nil || errs.StatusCode != http.StatusConflict {
+			return errs
+		}
+	}
+
+	return errs
+}
+
+func (s ShipmentModule) pickCourier(ctx context.Context, warehouseID uuid.UUID, caller string) (
+	models.CourierModel, *helpers.Error) {
+
+	warehouse, err := models.GetOneWarehouse(ctx, s.db, warehouseID)
+
+	if err != nil {
+		return models.CourierModel{}, helpers.ErrorWrap(err, s.name, caller+"/GetOneWarehouse",
+			helpers.InternalServerError, http.StatusInternalServerError)
+	}
+
+	couriers, err := models.GetAllActiveCourier(ctx, s.db)
+
+	if err != nil {
+		return models.CourierModel{}, helpers.ErrorWrap(err, s.name, caller+"/GetAllActiveCourier",
+			helpers.InternalServerError, http.StatusInternalServerError)
+	}
+
+	loads, err := models.CountUndeliveredShipmentByCourier(ctx, s.db)
+
+	if err != nil {
+		return models.CourierModel{}, helpers.ErrorWrap(err, s.name, caller+"/CountUndeliveredShipmentByCourier",
+			helpers.InternalServerError, http.StatusInternalServerError)
+	}
+
+	shifts, err := models.GetAllCourierShift(ctx, s.db, helpers.Filter{})
+
+	if err != nil {
+		return models.CourierModel{}, helpers.ErrorWrap(err, s.name, caller+"/GetAllCourierShift",
+			helpers.InternalServerError, http.StatusInternalServerError)
+	}
+
+	courierShifts := make(map[uuid.UUID][]models.CourierShiftModel)
+	for _, shift := range shifts {
+		courierShifts[shift.CourierID] = append(courierShifts[shift.CourierID], shift)
+	}
+
+	now := time.Now()
+	origin := maps.Location{Latitude: warehouse.Latitude, Longitude: warehouse.Longitude}
+
+	var candidates []courierCandidate
+	for _, courier := range couriers {
+
+		home, capacity, ok := courierAvailability(courier, courierShifts[courier.ID], now)
+		if !ok {
+			continue
+		}
+
+		load, capped, err := shiftLoad(ctx, s.db, courier.ID, courierShifts[courier.ID], now)
+
+		if err != nil {
+			return models.CourierModel{}, helpers.ErrorWrap(err, s.name, caller+"/shiftLoad",
+				helpers.InternalServerError, http.StatusInternalServerError)
+		}
+
+		if !capped {
+			load = loads[courier.ID]
+		}
+
+		candidate := courierCandidate{
+			courier:  courier,
+			home:     home.Valid && home.UUID == warehouse.ID,
+			load:     load,
+			capacity: capacity,
+			distance: math.Inf(1),
+		}
+
+		position, ok := s.courierPosition(ctx, courier.ID)
+		if ok {
+			candidate.distance = maps.Haversine(origin, position)
+		}
+
+		candidates = append(candidates, candidate)
+	}
+
+	courier, ok := chooseCourier(candidates)
+
+	if !ok {
+		return models.CourierModel{}, helpers.ErrorWrap(errNoCourierAvailable, s.name, caller+"/chooseCourier",
+			"No Courier Available", http.StatusConflict)
+	}
+
+	return courier, nil
+}
+
+// checkCourier refuses a courier who is inactive, off shift or already at capacity: handed all the parcels their
+// shift allows, or carrying the dispatch limit. Run it in the transaction that hands the courier a shipment: the
+// courier row stays locked until then, so concurrent dispatches to the same courier are counted one after another.
+func checkCourier(ctx context.Context, tx helpers.DBExecutor, courierID uuid.UUID) error {
+
+	courier, err := models.GetOneCourierForUpdate(ctx, tx, courierID)
+
+	if err != nil {
+		return err
+	}
+
+	if !courier.IsActive {
+		return errCourierNotActive
+	}
+
+	shifts, err := models.GetAllCourierShift(ctx, tx, helpers.Filter{CourierID: courier.ID})
+
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	_, capacity, ok := courierAvailability(courier, shifts, now)
+
+	if !ok {
+		return errCourierOffShift
+	}
+
+	if capacity <= 0 {
+		return nil
+	}
+
+	load, capped, err := shiftLoad(ctx, tx, courier.ID, shifts, now)
+
+	if err != nil {
+		return err
+	}
+
+	if !capped {
+		load, err = models.CountUndeliveredShipmentByCourierID(ctx, tx, courier.ID)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if load >= capacity {
+		return errCourierAtCapacity
+	}
+
+	return nil
+}
+
+func courierError(err error, name, caller string) *helpers.Error {
+	switch err {
+	case sql.ErrNoRows:
+		return helpers.ErrorWrap(err, name, caller+"/checkCourier", helpers.BadRequestMessage,
+			http.StatusBadRequest)
+	case errCourierNotActive:
+		return helpers.ErrorWrap(err, name, caller+"/checkCourier", "Courier Not Active", http.StatusBadRequest)
+	case errCourierOffShift:
+		return helpers.ErrorWrap(err, name, caller+"/checkCourier", "Courier Off Shift", http.StatusConflict)
+	case errCourierAtCapacity:
+		return helpers.ErrorWrap(err, name, caller+"/checkCourier", "Courier At Capacity", http.StatusConflict)
+	}
+	return helpers.ErrorWrap(err, name, caller+"/checkCourier", helpers.InternalServerError,
+		http.StatusInternalServerError)
+}
+
+// courierPosition is the courier's last reported position, from the cache when it is there.
+func (s ShipmentModule) courierPosition(ctx context.Context, courierID uuid.UUID) (maps.Location, bool) {
+
+	cached, err := NewCourierLocationModule(s.db, s.cache, s.logger).cachedLocation(ctx, courierID)
+	if err == nil {
+		return maps.Location{Latitude: cached.Latitude, Longitude: cached.Longitude}, true
+	}
+
+	courierLocation, err := models.GetLatestCourierLocation(ctx, s.db, courierID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			s.logger.Err.Printf(`api.dispatch.go/courierPosition/GetLatestCourierLocation/%v`, err)
+		}
+		return maps.Location{}, false
+	}
+
+	return maps.Location{Latitude: courierLocation.Latitude, Longitude: courierLocation.Longitude}, true
+}
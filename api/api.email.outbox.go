@@ -0,0 +1,166 @@
+package api
+
+import (
+	"afiqo-location/email"
+	"afiqo-location/helpers"
+	"afiqo-location/models"
+	"afiqo-location/util"
+	"context"
+	"database/sql"
+	"github.com/gomodule/redigo/redis"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+const maxEmailBackoff = time.Hour
+
+type (
+	EmailOutbox struct {
+		// IntervalSeconds is how often the worker looks for mail that is due.
+		IntervalSeconds int
+		// MaxAttempts is how many sends are tried before a mail is marked failed.
+		MaxAttempts int
+		// BackoffSeconds is the wait after the first failed send, doubled after every further failure.
+		BackoffSeconds int
+		// BatchSize caps how many mails one sweep sends.
+		BatchSize int
+	}
+
+	EmailModule struct {
+		db     *sql.DB
+		cache  *redis.Pool
+		logger *helpers.Logger
+		name   string
+	}
+)
+
+var emailOutbox = EmailOutbox{IntervalSeconds: 10, MaxAttempts: 8, BackoffSeconds: 30, BatchSize: 50}
+
+// sendMail is how the worker hands mail to SMTP.
+var sendMail = func(mail email.Mail) error {
+	return mail.SendEmail()
+}
+
+func InitEmailOutbox(e EmailOutbox) {
+	if e.IntervalSeconds > 0 {
+		emailOutbox.IntervalSeconds = e.IntervalSeconds
+	}
+	if e.MaxAttempts > 0 {
+		emailOutbox.MaxAttempts = e.MaxAttempts
+	}
+	if e.BackoffSeconds > 0 {
+		emailOutbox.BackoffSeconds = e.BackoffSeconds
+	}
+	if e.BatchSize > 0 {
+		emailOutbox.BatchSize = e.BatchSize
+	}
+}
+
+func NewEmailModule(db *sql.DB, cache *redis.Pool, logger *helpers.Logger) *EmailModule {
+	return &EmailModule{
+		db:     db,
+		cache:  cache,
+		logger: logger,
+		name:   "module/email",
+	}
+}
+
+// queueMail puts mail in the outbox. Pass the caller's transaction so the mail is only sent if the change it
+// announces is committed.
+func queueMail(ctx context.Context, db helpers.DBExecutor, mail email.Mail) error {
+
+	outbox := models.EmailOutboxModel{
+		Recipient: mail.To,
+		Subject:   mail.Subject,
+		Body:      mail.Body,
+		CreatedBy: uuid.FromStringOrNil(ctx.Value("user_id").(string)),
+	}
+
+	return outbox.Insert(ctx, db)
+}
+
+// DeliverOutbox sends queued mail until ctx is done.
+func (s EmailModule) DeliverOutbox(ctx context.Context) {
+
+	ticker := time.NewTicker(time.Duration(emailOutbox.IntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		s.deliver(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s EmailModule) deliver(ctx context.Context) {
+
+	// Long enough for a whole batch of slow SMTP sends before another worker may take the mail over.
+	lease := time.Duration(emailOutbox.BatchSize) * time.Minute
+
+	mails, err := models.ClaimDueEmailOutbox(ctx, s.db, lease, emailOutbox.BatchSize)
+
+	if err != nil {
+		if ctx.Err() == nil {
+			s.logger.Err.Printf(`api.email.outbox.go/deliver/ClaimDueEmailOutbox/%v`, err)
+		}
+		return
+	}
+
+	for _, mail := range mails {
+		// Claimed mail left unsent is due again once its lease runs out.
+		if ctx.Err() != nil {
+			return
+		}
+
+		err = sendMail(email.Mail{
+			Subject: mail.Subject,
+			Body:    mail.Body,
+			To:      mail.Recipient,
+		})
+
+		mail.Attempts++
+		mail.Status, mail.NextAttemptAt = nextEmailAttempt(mail.Attempts, err, time.Now())
+		mail.LastError = sql.NullString{}
+
+		if err != nil {
+			mail.LastError = sql.NullString{String: err.Error(), Valid: true}
+			s.logger.Err.Printf(`api.email.outbox.go/deliver/SendEmail/%s/attempt %d/%v`, mail.ID, mail.Attempts,
+				err)
+		}
+
+		// Not tied to ctx, the mail has gone out and that must be recorded even while shutting down.
+		err = mail.UpdateAttempt(context.Background(), s.db)
+
+		if err != nil {
+			s.logger.Err.Printf(`api.email.outbox.go/deliver/UpdateAttempt/%s/%v`, mail.ID, err)
+		}
+	}
+}
+
+// nextEmailAttempt decides what happens to a mail after its latest send: sent, retried after a backoff that
+// doubles with every attempt, or failed for good after the last attempt.
+func nextEmailAttempt(attempts int, err error, now time.Time) (int, time.Time) {
+
+	if err == nil {
+		return util.EmailStatusSent, now
+	}
+
+	if attempts >= emailOutbox.MaxAttempts {
+		return util.EmailStatusFailed, now
+	}
+
+	backoff := time.Duration(emailOutbox.BackoffSeconds) * time.Second
+	for i := 1; i < attempts && backoff < maxEmailBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > maxEmailBackoff {
+		backoff = maxEmailBackoff
+	}
+
+	return util.EmailStatusPending, now.Add(backoff)
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"afiqo-location/util"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNextEmailAttempt(t *testing.T) {
+
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	refused := errors.New("connection refused")
+
+	status, _ := nextEmailAttempt(1, nil, now)
+	if status != util.EmailStatusSent {
+		t.Fatalf("status = %s, want Sent", util.GetEmailStatus(status))
+	}
+
+	status, next := nextEmailAttempt(1, refused, now)
+	if status != util.EmailStatusPending || next.Sub(now) != 30*time.Second {
+		t.Fatalf("first retry = %s in %v, want Pending in 30s", util.GetEmailStatus(status), next.Sub(now))
+	}
+
+	_, next = nextEmailAttempt(3, refused, now)
+	if next.Sub(now) != 2*time.Minute {
+		t.Fatalf("third retry in %v, want the backoff doubled twice", next.Sub(now))
+	}
+
+	status, _ = nextEmailAttempt(8, refused, now)
+	if status != util.EmailStatusFailed {
+		t.Fatalf("status after the last attempt = %s, want Failed", util.GetEmailStatus(status))
+	}
+
+	emailOutbox.MaxAttempts = 20
+	defer func() { emailOutbox.MaxAttempts = 8 }()
+
+	_, next = nextEmailAttempt(9, refused, now)
+	if next.Sub(now) != maxEmailBackoff {
+		t.Fatalf("ninth retry in %v, want the backoff capped", next.Sub(now))
+	}
+}
@@ -0,0 +1,339 @@
+package api
+
+import (
+	"afiqo-location/helpers"
+	"afiqo-location/maps"
+	"afiqo-location/models"
+	"afiqo-location/util"
+	"bytes"
+	"context"
+	"errors"
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	"net/http"
+	"sort"
+)
+
+const (
+	RankByDistance = "distance"
+	RankByDuration = "duration"
+
+	defaultShortlist = 10
+	// maxShortlist keeps warehouse ranking to one Distance Matrix request.
+	maxShortlist = maps.MaxDestinations
+)
+
+type (
+	Fulfilment struct {
+		MaxWarehouses int
+		// Shortlist is how many of the straight-line nearest warehouses are ranked by the distance provider.
+		Shortlist       int
+		RankBy          string
+		HandlingMinutes int
+		// ServiceRadius in km applies to warehouses without a service area of their own. Zero is unlimited.
+		ServiceRadius float64
+		// StopMinutes is how long a courier spends handing over a delivery.
+		StopMinutes int
+	}
+
+	Allocation struct {
+		WarehouseID uuid.UUID
+		ProductID   uuid.UUID
+		Quantity    uint
+	}
+)
+
+var fulfilment = Fulfilment{Shortlist: defaultShortlist}
+
+var (
+	errNoDistanceProvider  = errors.New("no distance provider")
+	errOutsideDeliveryArea = errors.New("outside delivery area")
+)
+
+func InitFulfilment(f Fulfilment) {
+	if f.Shortlist <= 0 {
+		f.Shortlist = defaultShortlist
+	}
+	if f.Shortlist > maxShortlist {
+		f.Shortlist = maxShortlist
+	}
+	fulfilment = f
+}
+
+// reachableWarehouses returns the warehouses whose service area covers an address, nearest first. It fails with
+// errOutsideDeliveryArea when no warehouse delivers there.
+func reachableWarehouses(ctx context.Context, db helpers.DBExecutor, latitude, longitude decimal.Decimal) (
+	[]models.WarehouseModel, error) {
+
+	// Service areas are checked here rather than in SQL, so every warehouse is read before the shortlist is cut.
+	warehouses, err := models.GetAllWarehouseWithDistance(ctx, db, helpers.Filter{
+		Longitude: longitude,
+		Latitude:  latitude,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	address := maps.Location{Latitude: latitude, Longitude: longitude}
+
+	var reachable []models.WarehouseModel
+	for _, warehouse := range warehouses {
+		if fulfilment.MaxWarehouses > 0 && len(reachable) == fulfilment.MaxWarehouses {
+			break
+		}
+		if serves(warehouse, address) {
+			reachable = append(reachable, warehouse)
+		}
+	}
+
+	if len(reachable) == 0 {
+		return nil, errOutsideDeliveryArea
+	}
+
+	return reachable, nil
+}
+
+// serves checks an address against the warehouse's polygon, else its radius, else the default radius.
+func serves(warehouse models.WarehouseModel, address maps.Location) bool {
+
+	if warehouse.ServiceArea.Valid {
+		area, err := maps.ParseArea([]byte(warehouse.ServiceArea.String))
+		if err == nil {
+			return area.Contains(address)
+		}
+
+		if logger != nil {
+			logger.Err.Printf(`api.fulfilment.go/serves/ParseArea/%s/%v`, warehouse.ID, err)
+		}
+	}
+
+	radius, _ := warehouse.ServiceRadius.Float64()
+	if radius == 0 {
+		radius = fulfilment.ServiceRadius
+	}
+
+	if radius == 0 {
+		return true
+	}
+
+	location := maps.Location{Latitude: warehouse.Latitude, Longitude: warehouse.Longitude}
+
+	return maps.Haversine(address, location) <= radius*1000
+}
+
+func reachableError(err error, name, caller string) *helpers.Error {
+	if err == errOutsideDeliveryArea {
+		return helpers.ErrorWrap(err, name, caller+"/reachableWarehouses", helpers.OutsideDeliveryAreaMessage,
+			http.StatusBadRequest)
+	}
+	return helpers.ErrorWrap(err, name, caller+"/reachableWarehouses", helpers.InternalServerError,
+		http.StatusInternalServerError)
+}
+
+// rankWarehouses orders the straight-line shortlist of warehouses, nearest first, by road distance or duration
+// to the address. Warehouses past the shortlist follow in straight-line order. When the distance provider fails
+// the straight-line distance is kept. Distances line up with the returned warehouses.
+func rankWarehouses(ctx context.Context, provider maps.DistanceProvider, warehouses []models.WarehouseModel,
+	latitude, longitude decimal.Decimal) ([]models.WarehouseModel, []util.Distance) {
+
+	address := maps.Location{Latitude: latitude, Longitude: longitude}
+
+	var locations []maps.Location
+	for _, warehouse := range warehouses {
+		locations = append(locations, maps.Location{Latitude: warehouse.Latitude, Longitude: warehouse.Longitude})
+	}
+
+	shortlist := len(locations)
+	if shortlist > fulfilment.Shortlist {
+		shortlist = fulfilment.Shortlist
+	}
+
+	routes, _ := maps.HaversineProvider{}.Distances(ctx, address, locations)
+
+	err := errNoDistanceProvider
+	if provider != nil && shortlist > 0 {
+		var roads []maps.Route
+		roads, err = provider.Distances(ctx, address, locations[:shortlist])
+		if err == nil {
+			copy(routes, roads)
+		}
+	}
+
+	if err != nil && logger != nil && err != errNoDistanceProvider {
+		logger.Err.Printf(`api.fulfilment.go/rankWarehouses/Distances/%v`, err)
+	}
+
+	distances := make([]util.Distance, len(warehouses))
+	for i, warehouse := range warehouses {
+		distances[i] = util.Distance{
+			WarehouseID:   warehouse.ID,
+			DistanceValue: routes[i].Distance,
+			DurationValue: routes[i].Duration,
+		}
+	}
+
+	ranked := append([]models.WarehouseModel(nil), warehouses...)
+	order := make([]int, len(warehouses))
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order[:shortlist], func(i, j int) bool {
+		return rankValue(distances[order[i]]) < rankValue(distances[order[j]])
+	})
+
+	rankedDistances := make([]util.Distance, len(warehouses))
+	for i, index := range order {
+		ranked[i] = warehouses[index]
+		rankedDistances[i] = distances[index]
+	}
+
+	return ranked, rankedDistances
+}
+
+func rankValue(distance util.Distance) int {
+	if fulfilment.RankBy == RankByDuration {
+		return distance.DurationValue
+	}
+	return distance.DistanceValue
+}
+
+// nearestAllocated picks the closest warehouse that takes part in the allocations.
+func nearestAllocated(distances []util.Distance, allocations []Allocation) util.Distance {
+
+	allocated := make(map[uuid.UUID]bool)
+	for _, allocation := range allocations {
+		allocated[allocation.WarehouseID] = true
+	}
+
+	var candidates []util.Distance
+	for _, distance := range distances {
+		if allocated[distance.WarehouseID] {
+			candidates = append(candidates, distance)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return util.Distance{}
+	}
+
+	var id uuid.UUID
+	if fulfilment.RankBy == RankByDuration {
+		_, id = util.GetMinDuration(candidates)
+	} else {
+		_, id = util.GetMinDistance(candidates)
+	}
+
+	for _, candidate := range candidates {
+		if candidate.WarehouseID == id {
+			return candidate
+		}
+	}
+
+	return candidates[0]
+}
+
+func warehouseIDs(warehouses []models.WarehouseModel) []uuid.UUID {
+	var ids []uuid.UUID
+	for _, warehouse := range warehouses {
+		ids = append(ids, warehouse.ID)
+	}
+	return ids
+}
+
+// mergeProducts folds repeated lines of the same product together and sorts them by product ID.
+func mergeProducts(products []Product) []Product {
+
+	quantities := make(map[uuid.UUID]uint)
+	var merged []Product
+	for _, product := range products {
+		if _, ok := quantities[product.ID]; !ok {
+			merged = append(merged, Product{ID: product.ID})
+		}
+		quantities[product.ID] += product.Quantity
+	}
+
+	for i := range merged {
+		merged[i].Quantity = quantities[merged[i].ID]
+	}
+
+	sort.Slice(merged, func(i, j int) bool {
+		return bytes.Compare(merged[i].ID.Bytes(), merged[j].ID.Bytes()) < 0
+	})
+
+	return merged
+}
+
+// PlanFulfilment allocates each product to the nearest warehouse able to supply the whole quantity.
+// When no single warehouse can, the quantity is split across warehouses by distance. Warehouses must
+// be ordered nearest first. Products that cannot be covered at all are returned as short.
+func PlanFulfilment(products []Product, warehouses []models.WarehouseModel, stocks []models.StockModel) (
+	[]Allocation, []uuid.UUID) {
+
+	available := make(map[uuid.UUID]map[uuid.UUID]uint)
+	for _, stock := range stocks {
+		if available[stock.WarehouseID] == nil {
+			available[stock.WarehouseID] = make(map[uuid.UUID]uint)
+		}
+		available[stock.WarehouseID][stock.ProductID] += stock.Stock
+	}
+
+	var allocations []Allocation
+	var short []uuid.UUID
+	for _, product := range mergeProducts(products) {
+
+		if product.Quantity == 0 {
+			continue
+		}
+
+		var total uint
+		allocated := false
+		for _, warehouse := range warehouses {
+			stock := available[warehouse.ID][product.ID]
+			if stock >= product.Quantity {
+				allocations = append(allocations, Allocation{
+					WarehouseID: warehouse.ID,
+					ProductID:   product.ID,
+					Quantity:    product.Quantity,
+				})
+				available[warehouse.ID][product.ID] -= product.Quantity
+				allocated = true
+				break
+			}
+			total += stock
+		}
+
+		if allocated {
+			continue
+		}
+
+		if total < product.Quantity {
+			short = append(short, product.ID)
+			continue
+		}
+
+		remaining := product.Quantity
+		for _, warehouse := range warehouses {
+			stock := available[warehouse.ID][product.ID]
+			if stock == 0 {
+				continue
+			}
+			if stock > remaining {
+				stock = remaining
+			}
+			allocations = append(allocations, Allocation{
+				WarehouseID: warehouse.ID,
+				ProductID:   product.ID,
+				Quantity:    stock,
+			})
+			available[warehouse.ID][product.ID] -= stock
+			remaining -= stock
+			if remaining == 0 {
+				break
+			}
+		}
+	}
+
+	return allocations, short
+}
@@ -0,0 +1,250 @@
+package api
+
+import (
+	"afiqo-location/maps"
+	"afiqo-location/models"
+	"context"
+	"errors"
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	"testing"
+	"time"
+)
+
+type fakeDistanceProvider struct {
+	routes []maps.Route
+	err    error
+}
+
+func (p fakeDistanceProvider) Distances(ctx context.Context, origin maps.Location, destinations []maps.Location) (
+	[]maps.Route, error) {
+	if len(p.routes) > len(destinations) {
+		return p.routes[:len(destinations)], p.err
+	}
+	return p.routes, p.err
+}
+
+func TestPlanFulfilment(t *testing.T) {
+
+	near := models.WarehouseModel{ID: uuid.NewV4()}
+	far := models.WarehouseModel{ID: uuid.NewV4()}
+	warehouses := []models.WarehouseModel{near, far}
+
+	apple := uuid.NewV4()
+	pear := uuid.NewV4()
+	plum := uuid.NewV4()
+	kiwi := uuid.NewV4()
+
+	stocks := []models.StockModel{
+		{WarehouseID: near.ID, ProductID: apple, Stock: 5},
+		{WarehouseID: far.ID, ProductID: apple, Stock: 5},
+		{WarehouseID: near.ID, ProductID: pear, Stock: 1},
+		{WarehouseID: far.ID, ProductID: pear, Stock: 4},
+		{WarehouseID: near.ID, ProductID: plum, Stock: 2},
+		{WarehouseID: far.ID, ProductID: plum, Stock: 2},
+		{WarehouseID: far.ID, ProductID: kiwi, Stock: 1},
+	}
+
+	products := []Product{
+		{ID: apple, Quantity: 2},
+		{ID: apple, Quantity: 1},
+		{ID: pear, Quantity: 3},
+		{ID: plum, Quantity: 3},
+		{ID: kiwi, Quantity: 2},
+	}
+
+	allocations, short := PlanFulfilment(products, warehouses, stocks)
+
+	if len(short) != 1 || short[0] != kiwi {
+		t.Fatalf("short = %v, want only kiwi", short)
+	}
+
+	got := make(map[uuid.UUID]map[uuid.UUID]uint)
+	for _, allocation := range allocations {
+		if got[allocation.ProductID] == nil {
+			got[allocation.ProductID] = make(map[uuid.UUID]uint)
+		}
+		got[allocation.ProductID][allocation.WarehouseID] += allocation.Quantity
+	}
+
+	want := map[uuid.UUID]map[uuid.UUID]uint{
+		// merged lines come from the nearest warehouse
+		apple: {near.ID: 3},
+		// the nearest warehouse is short so the farther one takes the whole line
+		pear: {far.ID: 3},
+		// no warehouse has enough alone so the line is split nearest first
+		plum: {near.ID: 2, far.ID: 1},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("allocations = %v, want %v", got, want)
+	}
+
+	for product, warehouses := range want {
+		for warehouse, quantity := range warehouses {
+			if got[product][warehouse] != quantity {
+				t.Fatalf("allocations = %v, want %v", got, want)
+			}
+		}
+		if len(got[product]) != len(warehouses) {
+			t.Fatalf("allocations = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestRankWarehouses(t *testing.T) {
+
+	ctx := context.Background()
+
+	// Straight-line order from the address: across the river first, then the one down the road.
+	acrossRiver := models.WarehouseModel{ID: uuid.NewV4(),
+		Latitude: decimal.NewFromFloat(3.1080), Longitude: decimal.NewFromFloat(101.6070)}
+	downTheRoad := models.WarehouseModel{ID: uuid.NewV4(),
+		Latitude: decimal.NewFromFloat(3.1200), Longitude: decimal.NewFromFloat(101.6070)}
+	shortlist := []models.WarehouseModel{acrossRiver, downTheRoad}
+
+	latitude := decimal.NewFromFloat(3.1073)
+	longitude := decimal.NewFromFloat(101.6067)
+
+	road := fakeDistanceProvider{routes: []maps.Route{
+		{Distance: 9000, Duration: 900},
+		{Distance: 1500, Duration: 1200},
+	}}
+
+	ranked, distances := rankWarehouses(ctx, road, shortlist, latitude, longitude)
+
+	if ranked[0].ID != downTheRoad.ID || distances[0].DistanceValue != 1500 {
+		t.Fatalf("ranked %v by distance, want the warehouse down the road first", distances)
+	}
+
+	fulfilment.RankBy = RankByDuration
+	defer func() { fulfilment.RankBy = "" }()
+
+	ranked, _ = rankWarehouses(ctx, road, shortlist, latitude, longitude)
+
+	if ranked[0].ID != acrossRiver.ID {
+		t.Fatalf("ranked %v by duration, want the warehouse across the river first", ranked)
+	}
+
+	fulfilment.RankBy = ""
+
+	// Past the shortlist the straight-line order stands even though the road is shorter.
+	fulfilment.Shortlist = 1
+	ranked, distances = rankWarehouses(ctx, road, shortlist, latitude, longitude)
+	fulfilment.Shortlist = defaultShortlist
+
+	if ranked[0].ID != acrossRiver.ID || distances[0].DistanceValue != 9000 || distances[1].DistanceValue == 1500 {
+		t.Fatalf("ranked %v with a shortlist of one, want only the nearest ranked by road", distances)
+	}
+
+	broken := fakeDistanceProvider{err: errors.New("quota exceeded")}
+
+	ranked, distances = rankWarehouses(ctx, broken, shortlist, latitude, longitude)
+
+	if ranked[0].ID != acrossRiver.ID || distances[0].DistanceValue == 0 {
+		t.Fatalf("fallback ranked %v, want straight-line order", distances)
+	}
+
+	allocations := []Allocation{{WarehouseID: downTheRoad.ID}}
+
+	nearest := nearestAllocated(distances, allocations)
+	if nearest.WarehouseID != downTheRoad.ID {
+		t.Fatalf("nearest allocated = %v, want the only allocated warehouse", nearest.WarehouseID)
+	}
+}
+
+func TestServes(t *testing.T) {
+
+	petalingJaya := maps.NewLocation(3.1073, 101.6067)
+	kualaLumpur := maps.NewLocation(3.1579, 101.7116)
+
+	// About 12.9km from Petaling Jaya and 2.8km from Kuala Lumpur.
+	warehouse := models.WarehouseModel{
+		Latitude: decimal.NewFromFloat(3.1390), Longitude: decimal.NewFromFloat(101.6869)}
+
+	if !serves(warehouse, petalingJaya) {
+		t.Fatal("a warehouse without any service area should serve everywhere")
+	}
+
+	fulfilment.ServiceRadius = 5
+	defer func() { fulfilment.ServiceRadius = 0 }()
+
+	if serves(warehouse, petalingJaya) || !serves(warehouse, kualaLumpur) {
+		t.Fatal("the default radius should only cover Kuala Lumpur")
+	}
+
+	warehouse.ServiceRadius = decimal.NewFromInt(15)
+
+	if !serves(warehouse, petalingJaya) {
+		t.Fatal("the warehouse radius should win over the default radius")
+	}
+
+	// A polygon around Petaling Jaya only wins over any radius.
+	warehouse.ServiceArea.String = `{"type":"Polygon","coordinates":[
+		[[101.58,3.08],[101.63,3.08],[101.63,3.13],[101.58,3.13],[101.58,3.08]]]}`
+	warehouse.ServiceArea.Valid = true
+
+	if !serves(warehouse, petalingJaya) || serves(warehouse, kualaLumpur) {
+		t.Fatal("the polygon should only cover Petaling Jaya")
+	}
+}
+
+func TestChooseCourier(t *testing.T) {
+
+	based := courierCandidate{courier: models.CourierModel{ID: uuid.NewV4()}, home: true, load: 3, distance: 9000}
+	idle := courierCandidate{courier: models.CourierModel{ID: uuid.NewV4()}, load: 0, distance: 5000}
+	nearby := courierCandidate{courier: models.CourierModel{ID: uuid.NewV4()}, load: 0, distance: 800}
+
+	courier, ok := chooseCourier([]courierCandidate{idle, nearby, based})
+	if !ok || courier.ID != based.courier.ID {
+		t.Fatal("a courier based at the warehouse should be chosen first")
+	}
+
+	based.capacity = 3
+
+	courier, ok = chooseCourier([]courierCandidate{idle, nearby, based})
+	if !ok || courier.ID != nearby.courier.ID {
+		t.Fatal("past the load limit the closest idle courier should be chosen")
+	}
+
+	_, ok = chooseCourier([]courierCandidate{based})
+	if ok {
+		t.Fatal("no courier should be chosen when everyone is at the load limit")
+	}
+}
+
+func TestCourierAvailability(t *testing.T) {
+
+	warehouseID := uuid.NewV4()
+	courier := models.CourierModel{ID: uuid.NewV4()}
+
+	// Wednesday 14 October 2026, 10:30.
+	now := time.Date(2026, 10, 14, 10, 30, 0, 0, time.Local)
+
+	_, _, ok := courierAvailability(courier, nil, now)
+	if !ok {
+		t.Fatal("a courier without shifts should stay available")
+	}
+
+	shifts := []models.CourierShiftModel{
+		{Weekday: int(time.Wednesday), StartTime: "08:00", EndTime: "10:30"},
+		{Weekday: int(time.Wednesday), StartTime: "10:30", EndTime: "18:00", MaxParcels: 12,
+			WarehouseID: uuid.NullUUID{UUID: warehouseID, Valid: true}},
+	}
+
+	home, capacity, ok := courierAvailability(courier, shifts, now)
+	if !ok || home.UUID != warehouseID || capacity != 12 {
+		t.Fatalf("availability = %v, %d, %v, want the afternoon shift's warehouse and capacity", home, capacity, ok)
+	}
+
+	_, _, ok = courierAvailability(courier, shifts, now.AddDate(0, 0, 1))
+	if ok {
+		t.Fatal("a courier should be off shift on a day without shifts")
+	}
+
+	// The afternoon shift's parcels are counted from 10:30 to 18:00 that day.
+	start, end := shiftWindow(shifts[1], now.Add(4*time.Hour))
+	if !start.Equal(now) || !end.Equal(time.Date(2026, 10, 14, 18, 0, 0, 0, time.Local)) {
+		t.Fatalf("shift window = %v to %v, want 10:30 to 18:00", start, end)
+	}
+}
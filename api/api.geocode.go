@@ -0,0 +1,60 @@
+package api
+
+import (
+	"afiqo-location/helpers"
+	"afiqo-location/maps"
+	"context"
+	"errors"
+	"github.com/shopspring/decimal"
+	"net/http"
+)
+
+type Geocoding struct {
+	MismatchMetres float64
+}
+
+var geocoding = Geocoding{MismatchMetres: 500}
+
+func InitGeocoding(g Geocoding) {
+	if g.MismatchMetres > 0 {
+		geocoding = g
+	}
+}
+
+// locate fills in coordinates from the address when none were sent. Coordinates that were sent are kept,
+// but reported as a mismatch when they sit too far from where the address geocodes to.
+func locate(ctx context.Context, geocoder maps.Geocoder, address string, latitude, longitude decimal.Decimal) (
+	maps.Location, bool, error) {
+
+	location := maps.Location{Latitude: latitude, Longitude: longitude}
+
+	if geocoder == nil {
+		if location.IsZero() {
+			return location, false, maps.ErrAddressNotFound
+		}
+		return location, false, nil
+	}
+
+	geocoded, err := geocoder.Geocode(ctx, address)
+
+	if location.IsZero() {
+		return geocoded, false, err
+	}
+
+	if err != nil {
+		if err != maps.ErrAddressNotFound && logger != nil {
+			logger.Err.Printf(`api.geocode.go/locate/Geocode/%v`, err)
+		}
+		return location, err == maps.ErrAddressNotFound, nil
+	}
+
+	return location, maps.Haversine(location, geocoded) > geocoding.MismatchMetres, nil
+}
+
+func locateError(err error, name, caller string) *helpers.Error {
+	if errors.Is(err, maps.ErrAddressNotFound) {
+		return helpers.ErrorWrap(err, name, caller+"/locate", helpers.AddressNotFoundMessage, http.StatusBadRequest)
+	}
+	return helpers.ErrorWrap(err, name, caller+"/locate", helpers.InternalServerError,
+		http.StatusInternalServerError)
+}
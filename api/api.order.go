@@ -2,13 +2,21 @@ package api
 
 import (
 	"afiqo-location/helpers"
+	"afiqo-location/maps"
 	"afiqo-location/models"
+	"afiqo-location/session"
+	"afiqo-location/util"
+	"bytes"
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"github.com/gomodule/redigo/redis"
 	uuid "github.com/satori/go.uuid"
 	"github.com/shopspring/decimal"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -22,11 +30,6 @@ type (
 		ID uuid.UUID `json:"id"`
 	}
 
-	Distance struct {
-		WarehouseID   uuid.UUID
-		DistanceValue int
-	}
-
 	OrderModule struct {
 		db     *sql.DB
 		cache  *redis.Pool
@@ -39,15 +42,20 @@ type (
 	}
 
 	OrderParam struct {
-		DeliveryAddress string          `json:"delivery_address" validate:"required"`
-		Longitude       decimal.Decimal `json:"longitude" validate:"required"`
-		Latitude        decimal.Decimal `json:"latitude" validate:"required"`
+		AddressID       uuid.UUID       `json:"address_id"`
+		DeliveryAddress string          `json:"delivery_address" validate:"required_without=AddressID"`
+		Longitude       decimal.Decimal `json:"longitude"`
+		Latitude        decimal.Decimal `json:"latitude"`
 		Product         []Product       `json:"product" validate:"required"`
 	}
 
 	OrderDeleteParam struct {
 		ID uuid.UUID `json:"id"`
 	}
+
+	OrderCancelParam struct {
+		ID uuid.UUID `json:"id"`
+	}
 )
 
 func NewOrderModule(db *sql.DB, cache *redis.Pool, logger *helpers.Logger) *OrderModule {
@@ -126,133 +134,119 @@ func (s OrderModule) ListByCustomerID(ctx context.Context, filter helpers.Filter
 
 func (s OrderModule) Order(ctx context.Context, param OrderParam) (interface{}, *helpers.Error) {
 
-	now := time.Now()
+	plan, errs := s.plan(ctx, param, "Order")
+	if errs != nil {
+		return nil, errs
+	}
+
+	if len(plan.short) > 0 {
+		return nil, s.insufficientStock(ctx, plan.short)
+	}
 
-	deliveryDateTime := now.AddDate(0, 0, 3)
+	if len(plan.allocations) == 0 {
+		return nil, helpers.ErrorWrap(errors.New("Empty Order"), s.name, "Order/PlanFulfilment",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
 
-	warehouses, err := models.GetAllWarehouseWithDistance(ctx, s.db, helpers.Filter{
-		FilterOption: helpers.FilterOption{
-			Limit:  1,
-			Offset: 0,
-		},
-		Longitude: param.Longitude,
-		Latitude:  param.Latitude,
-	})
+	allocations := plan.allocations
 
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return nil, helpers.ErrorWrap(err, s.name, "Order/GetAllWarehouseWithDistance", helpers.InternalServerError,
+		return nil, helpers.ErrorWrap(err, s.name, "Order/BeginTx", helpers.InternalServerError,
 			http.StatusInternalServerError)
 	}
 
-	var warehouseID uuid.UUID
-	for _, warehouse := range warehouses {
-		warehouseID = warehouse.ID
-	}
+	defer tx.Rollback()
 
 	order := models.OrderModel{
 		CustomerID:       uuid.FromStringOrNil(ctx.Value("user_id").(string)),
-		WarehouseID:      warehouseID,
-		DeliveryDatetime: deliveryDateTime,
-		DeliveryAddress:  param.DeliveryAddress,
-		Longitude:        param.Longitude,
-		Latitude:         param.Latitude,
-		Status:           0,
+		WarehouseID:      plan.nearest.WarehouseID,
+		DeliveryDatetime: EstimateDelivery(time.Now(), plan.distances, allocations),
+		DeliveryAddress:  plan.address,
+		Longitude:        plan.location.Longitude,
+		Latitude:         plan.location.Latitude,
+		Status:           util.OrderStatusOpen,
+		Distance:         DeliveryDistance(plan.distances, allocations),
+		AddressMismatch:  plan.addressMismatch,
 		CreatedBy:        uuid.FromStringOrNil(ctx.Value("user_id").(string)),
 	}
 
-	err = order.Insert(ctx, s.db)
+	err = order.Insert(ctx, tx)
 	if err != nil {
 		return nil, helpers.ErrorWrap(err, s.name, "Order/order.Insert",
 			helpers.InternalServerError,
 			http.StatusInternalServerError)
 	}
 
-	for _, orderProduct := range param.Product {
-
-		stock, err := models.GetOneStockByProductAndWarehouse(ctx, s.db, warehouseID, orderProduct.ID)
-		if err != nil {
-			return nil, helpers.ErrorWrap(err, s.name, "Order/GetOneStockByProductAndWarehouse",
-				helpers.InternalServerError,
-				http.StatusInternalServerError)
+	// Reserve in a fixed order so concurrent orders lock stock rows in the same sequence.
+	sort.Slice(allocations, func(i, j int) bool {
+		compare := bytes.Compare(allocations[i].ProductID.Bytes(), allocations[j].ProductID.Bytes())
+		if compare == 0 {
+			return bytes.Compare(allocations[i].WarehouseID.Bytes(), allocations[j].WarehouseID.Bytes()) < 0
 		}
+		return compare < 0
+	})
 
-		subStock := stock.Stock - orderProduct.Quantity
+	for _, allocation := range allocations {
 
-		stockModel := models.StockModel{
-			ID:    stock.ID,
-			Stock: subStock,
+		stock := models.StockModel{
+			WarehouseID: allocation.WarehouseID,
+			ProductID:   allocation.ProductID,
 			UpdatedBy: uuid.NullUUID{
 				UUID:  uuid.FromStringOrNil(ctx.Value("user_id").(string)),
 				Valid: true,
 			},
 		}
 
-		err = stockModel.Update(ctx, s.db)
+		err = stock.Reserve(ctx, tx, allocation.Quantity)
 
 		if err != nil {
-			return nil, helpers.ErrorWrap(err, s.name, "Order/stockModel.Update",
+			if err == sql.ErrNoRows {
+				return nil, s.insufficientStock(ctx, []uuid.UUID{allocation.ProductID})
+			}
+			return nil, helpers.ErrorWrap(err, s.name, "Order/stock.Reserve",
 				helpers.InternalServerError,
 				http.StatusInternalServerError)
 		}
 
-		if subStock < 10 {
+		product, err := models.GetOneProduct(ctx, tx, allocation.ProductID)
 
-		}
-
-		stocks, err := models.GetAllStockByProductID(ctx, s.db, stock.ProductID)
 		if err != nil {
-			return nil, helpers.ErrorWrap(err, s.name, "Add/GetAllStockByProductID", helpers.InternalServerError,
+			return nil, helpers.ErrorWrap(err, s.name, "Order/GetOneProduct",
+				helpers.InternalServerError,
 				http.StatusInternalServerError)
 		}
 
-		var totalStock uint
-		for _, stock = range stocks {
-			totalStock = totalStock + stock.Stock
-		}
-
-		productStock := models.ProductModel{
-			ID:    stock.ProductID,
-			Stock: totalStock,
-			UpdatedBy: uuid.NullUUID{
-				UUID:  uuid.FromStringOrNil(ctx.Value("user_id").(string)),
-				Valid: true,
-			},
-		}
-
-		err = productStock.StockUpdate(ctx, s.db)
+		subTotal := product.Price.Mul(decimal.NewFromInt(int64(allocation.Quantity)))
 
-		if err != nil {
-			return nil, helpers.ErrorWrap(err, s.name, "Add/StockUpdate", helpers.InternalServerError,
-				http.StatusInternalServerError)
+		orderProduct := models.OrderProductModel{
+			OrderID:     order.ID,
+			ProductID:   allocation.ProductID,
+			WarehouseID: allocation.WarehouseID,
+			Quantity:    allocation.Quantity,
+			SubTotal:    subTotal,
+			CreatedBy:   uuid.FromStringOrNil(ctx.Value("user_id").(string)),
 		}
 
-		product, err := models.GetOneProduct(ctx, s.db, orderProduct.ID)
-
+		err = orderProduct.Insert(ctx, tx)
 		if err != nil {
-			return nil, helpers.ErrorWrap(err, s.name, "Order/GetOneProduct",
+			return nil, helpers.ErrorWrap(err, s.name, "Order/orderProduct.Insert",
 				helpers.InternalServerError,
 				http.StatusInternalServerError)
 		}
+	}
 
-		subTotal := product.Price.Mul(decimal.NewFromInt(int64(orderProduct.Quantity)))
+	for _, product := range plan.products {
 
-		orderProduct := models.OrderProductModel{
-			OrderID:   order.ID,
-			ProductID: orderProduct.ID,
-			Quantity:  orderProduct.Quantity,
-			SubTotal:  subTotal,
-			CreatedBy: uuid.FromStringOrNil(ctx.Value("user_id").(string)),
-		}
+		err = syncProductStock(ctx, tx, product.ID, uuid.FromStringOrNil(ctx.Value("user_id").(string)))
 
-		err = orderProduct.Insert(ctx, s.db)
 		if err != nil {
-			return nil, helpers.ErrorWrap(err, s.name, "Order/orderProduct.Insert",
-				helpers.InternalServerError,
+			return nil, helpers.ErrorWrap(err, s.name, "Order/syncProductStock", helpers.InternalServerError,
 				http.StatusInternalServerError)
 		}
 	}
 
-	orderProducts, err := models.GetAllOrderProductByOrderID(ctx, s.db, order.ID)
+	orderProducts, err := models.GetAllOrderProductByOrderID(ctx, tx, order.ID)
 
 	if err != nil {
 		return nil, helpers.ErrorWrap(err, s.name, "Order/GetAllOrderProduct", helpers.InternalServerError,
@@ -264,22 +258,25 @@ func (s OrderModule) Order(ctx context.Context, param OrderParam) (interface{},
 		totalPrice = decimal.Sum(totalPrice, orderProduct.SubTotal)
 	}
 
-	configuration, err := models.GetConfiguration(ctx, s.db)
+	configuration, err := models.GetConfiguration(ctx, tx)
 	if err != nil {
 		return nil, helpers.ErrorWrap(err, s.name, "Order/GetConfiguration", helpers.InternalServerError,
 			http.StatusInternalServerError)
 	}
 
+	deliveryFee := DeliveryFee(configuration, totalPrice, order.Distance)
+
 	orderUpdate := models.OrderModel{
-		ID:         order.ID,
-		TotalPrice: totalPrice.Add(configuration.DeliveryFee),
+		ID:          order.ID,
+		TotalPrice:  totalPrice.Add(deliveryFee),
+		DeliveryFee: deliveryFee,
 		UpdatedBy: uuid.NullUUID{
 			UUID:  uuid.FromStringOrNil(ctx.Value("user_id").(string)),
 			Valid: true,
 		},
 	}
 
-	err = orderUpdate.UpdatePrice(ctx, s.db)
+	err = orderUpdate.UpdatePrice(ctx, tx)
 
 	if err != nil {
 		return nil, helpers.ErrorWrap(err, s.name, "Order/UpdatePrice", helpers.InternalServerError,
@@ -288,17 +285,25 @@ func (s OrderModule) Order(ctx context.Context, param OrderParam) (interface{},
 
 	payment := models.PaymentModel{
 		OrderID:   order.ID,
-		Status:    0,
+		Status:    util.PaymentStatusUnpaid,
+		Amount:    orderUpdate.TotalPrice,
 		CreatedBy: uuid.FromStringOrNil(ctx.Value("user_id").(string)),
 	}
 
-	err = payment.Insert(ctx, s.db)
+	err = payment.Insert(ctx, tx)
 
 	if err != nil {
 		return nil, helpers.ErrorWrap(err, s.name, "Order/payment.Insert", helpers.InternalServerError,
 			http.StatusInternalServerError)
 	}
 
+	err = tx.Commit()
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Order/Commit", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
 	order, err = models.GetOneOrder(ctx, s.db, order.ID)
 
 	if err != nil {
@@ -317,6 +322,96 @@ func (s OrderModule) Order(ctx context.Context, param OrderParam) (interface{},
 
 }
 
+type orderPlan struct {
+	address         string
+	location        maps.Location
+	addressMismatch bool
+	products        []Product
+	warehouses      []models.WarehouseModel
+	distances       []util.Distance
+	stocks          []models.StockModel
+	allocations     []Allocation
+	short           []uuid.UUID
+	nearest         util.Distance
+}
+
+// plan works out which warehouses would serve an order without touching any stock.
+func (s OrderModule) plan(ctx context.Context, param OrderParam, caller string) (orderPlan, *helpers.Error) {
+
+	var plan orderPlan
+
+	if param.AddressID != uuid.Nil {
+		address, errs := savedAddress(ctx, s.db, s.name, caller, param.AddressID)
+		if errs != nil {
+			return plan, errs
+		}
+
+		plan.address = address.Address
+		plan.location = maps.Location{Latitude: address.Latitude, Longitude: address.Longitude}
+		plan.addressMismatch = address.AddressMismatch
+	} else {
+		location, mismatch, err := locate(ctx, maps.AddressGeocoder(), param.DeliveryAddress, param.Latitude,
+			param.Longitude)
+
+		if err != nil {
+			return plan, locateError(err, s.name, caller)
+		}
+
+		plan.address = param.DeliveryAddress
+		plan.location = location
+		plan.addressMismatch = mismatch
+	}
+
+	warehouses, err := reachableWarehouses(ctx, s.db, plan.location.Latitude, plan.location.Longitude)
+
+	if err != nil {
+		return plan, reachableError(err, s.name, caller)
+	}
+
+	plan.warehouses, plan.distances = rankWarehouses(ctx, maps.Provider(), warehouses, plan.location.Latitude,
+		plan.location.Longitude)
+
+	plan.products = mergeProducts(param.Product)
+
+	var productIDs []uuid.UUID
+	for _, product := range plan.products {
+		productIDs = append(productIDs, product.ID)
+	}
+
+	plan.stocks, err = models.GetAllStockByWarehousesAndProducts(ctx, s.db, warehouseIDs(plan.warehouses),
+		productIDs)
+
+	if err != nil {
+		return plan, helpers.ErrorWrap(err, s.name, caller+"/GetAllStockByWarehousesAndProducts",
+			helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	plan.allocations, plan.short = PlanFulfilment(plan.products, plan.warehouses, plan.stocks)
+
+	// The order is attached to the nearest warehouse taking part in it.
+	plan.nearest = nearestAllocated(plan.distances, plan.allocations)
+
+	return plan, nil
+}
+
+func (s OrderModule) insufficientStock(ctx context.Context, productIDs []uuid.UUID) *helpers.Error {
+
+	var names []string
+	for _, productID := range productIDs {
+		product, err := models.GetOneProduct(ctx, s.db, productID)
+		if err != nil {
+			names = append(names, productID.String())
+			continue
+		}
+		names = append(names, product.Name)
+	}
+
+	return helpers.ErrorWrap(errors.New("Insufficient Stock"), s.name, "Order/insufficientStock",
+		fmt.Sprintf(`%s : %s`, helpers.InsufficientStockMessage, strings.Join(names, ", ")),
+		http.StatusConflict)
+}
+
 func (s OrderModule) Delete(ctx context.Context, param OrderDeleteParam) (interface{}, *helpers.Error) {
 
 	order := models.OrderModel{
@@ -336,3 +431,235 @@ func (s OrderModule) Delete(ctx context.Context, param OrderDeleteParam) (interf
 	return nil, nil
 
 }
+
+// UpdateStatus moves an order to status when the lifecycle allows it. The write is conditional on the
+// status read, so a concurrent change makes it fail instead of being overwritten.
+func (s OrderModule) UpdateStatus(ctx context.Context, db helpers.DBExecutor, order models.OrderModel,
+	status int) *helpers.Error {
+
+	if !util.CanTransitOrderStatus(order.Status, status) {
+		return helpers.ErrorWrap(errors.New("Invalid Order Status Transition"), s.name, "UpdateStatus/CanTransitOrderStatus",
+			fmt.Sprintf(`%s : %s To %s`, helpers.OrderStatusMessage, util.GetOrderStatus(order.Status),
+				util.GetOrderStatus(status)),
+			http.StatusConflict)
+	}
+
+	orderUpdate := models.OrderModel{
+		ID:     order.ID,
+		Status: status,
+		UpdatedBy: uuid.NullUUID{
+			UUID:  uuid.FromStringOrNil(ctx.Value("user_id").(string)),
+			Valid: true,
+		},
+	}
+
+	err := orderUpdate.TransitStatus(ctx, db, order.Status)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return helpers.ErrorWrap(err, s.name, "UpdateStatus/TransitStatus", helpers.OrderStatusMessage,
+				http.StatusConflict)
+		}
+		return helpers.ErrorWrap(err, s.name, "UpdateStatus/TransitStatus", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+func (s OrderModule) Cancel(ctx context.Context, param OrderCancelParam) (interface{}, *helpers.Error) {
+
+	userID := uuid.FromStringOrNil(ctx.Value("user_id").(string))
+
+	order, err := models.GetOneOrder(ctx, s.db, param.ID)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, helpers.ErrorWrap(err, s.name, "Cancel/GetOneOrder", helpers.BadRequestMessage,
+				http.StatusNotFound)
+		}
+		return nil, helpers.ErrorWrap(err, s.name, "Cancel/GetOneOrder", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	isCustomer := ctx.Value("role") == session.CUSTOMER_ROLE
+
+	if isCustomer && order.CustomerID != userID {
+		return nil, helpers.ErrorWrap(errors.New("Not Your Order"), s.name, "Cancel/CustomerID",
+			helpers.OrderErrorMessage, http.StatusForbidden)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Cancel/BeginTx", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	defer tx.Rollback()
+
+	errs := s.cancel(ctx, tx, order, userID, isCustomer, "Cancel")
+	if errs != nil {
+		return nil, errs
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Cancel/Commit", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	// The cancellation stands even if the gateway refuses, an admin can retry the refund on the payment.
+	errs = NewPaymentModule(s.db, s.cache, s.logger).RefundOrder(ctx, order.ID)
+	if errs != nil {
+		s.logger.Err.Printf(`api.order.go/Cancel/RefundOrder/%s/%v`, order.ID, errs.Err)
+	}
+
+	order, err = models.GetOneOrder(ctx, s.db, order.ID)
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Cancel/GetOneOrder", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	response, err := order.Response(ctx, s.db, s.logger)
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Cancel/Response", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	return response, nil
+
+}
+
+// cancel drops the order's shipments, marks it Cancelled, closes a payment still waiting for money and puts
+// its items back in stock inside tx.
+func (s OrderModule) cancel(ctx context.Context, tx *sql.Tx, order models.OrderModel, userID uuid.UUID,
+	isCustomer bool, caller string) *helpers.Error {
+
+	shipments, err := models.GetAllShipmentByOrderID(ctx, tx, order.ID)
+
+	if err != nil {
+		return helpers.ErrorWrap(err, s.name, caller+"/GetAllShipmentByOrderID", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	for _, shipment := range shipments {
+
+		// Customers can only cancel before anything has left a warehouse.
+		if isCustomer && shipment.Status >= util.ShipmentStatusShipped {
+			return helpers.ErrorWrap(errors.New("Order Already Shipped"), s.name, caller+"/Shipped",
+				"Order Already Shipped", http.StatusConflict)
+		}
+
+		if shipment.Status == util.ShipmentStatusDelivered {
+			return helpers.ErrorWrap(errors.New("Order Already Delivered"), s.name, caller+"/Delivered",
+				"Order Already Delivered", http.StatusConflict)
+		}
+
+		shipmentDelete := models.ShipmentModel{
+			ID: shipment.ID,
+			UpdatedBy: uuid.NullUUID{
+				UUID:  userID,
+				Valid: true,
+			},
+		}
+
+		err = shipmentDelete.Delete(ctx, tx)
+
+		if err != nil {
+			return helpers.ErrorWrap(err, s.name, caller+"/shipment.Delete", helpers.InternalServerError,
+				http.StatusInternalServerError)
+		}
+	}
+
+	errs := s.UpdateStatus(ctx, tx, order, util.OrderStatusCancelled)
+	if errs != nil {
+		return errs
+	}
+
+	payment, err := models.GetOnePaymentByOrderID(ctx, tx, order.ID)
+
+	if err != nil && err != sql.ErrNoRows {
+		return helpers.ErrorWrap(err, s.name, caller+"/GetOnePaymentByOrderID", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	// Close a payment still waiting for money, whatever the gateway collects later is refunded on arrival.
+	if err == nil && util.IsPaymentPayable(payment.Status) {
+
+		paymentUpdate := models.PaymentModel{
+			ID:     payment.ID,
+			Status: util.PaymentStatusCancelled,
+			UpdatedBy: uuid.NullUUID{
+				UUID:  userID,
+				Valid: true,
+			},
+		}
+
+		err = paymentUpdate.TransitStatus(ctx, tx, payment.Status)
+
+		if err != nil {
+			// Settled since it was read, the customer has paid and the order is no longer Open.
+			if err == sql.ErrNoRows {
+				return helpers.ErrorWrap(err, s.name, caller+"/payment.TransitStatus", helpers.PaymentStatusMessage,
+					http.StatusConflict)
+			}
+			return helpers.ErrorWrap(err, s.name, caller+"/payment.TransitStatus", helpers.InternalServerError,
+				http.StatusInternalServerError)
+		}
+	}
+
+	orderProducts, err := models.GetAllOrderProductByOrderID(ctx, tx, order.ID)
+
+	if err != nil {
+		return helpers.ErrorWrap(err, s.name, caller+"/GetAllOrderProductByOrderID", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	// Restock in the same product order used when reserving to keep lock order consistent.
+	sort.Slice(orderProducts, func(i, j int) bool {
+		compare := bytes.Compare(orderProducts[i].ProductID.Bytes(), orderProducts[j].ProductID.Bytes())
+		if compare == 0 {
+			return bytes.Compare(orderProducts[i].WarehouseID.Bytes(), orderProducts[j].WarehouseID.Bytes()) < 0
+		}
+		return compare < 0
+	})
+
+	var productIDs []uuid.UUID
+	for _, orderProduct := range orderProducts {
+
+		stock := models.StockModel{
+			WarehouseID: orderProduct.WarehouseID,
+			ProductID:   orderProduct.ProductID,
+			UpdatedBy: uuid.NullUUID{
+				UUID:  userID,
+				Valid: true,
+			},
+		}
+
+		err = stock.Restock(ctx, tx, orderProduct.Quantity)
+
+		if err != nil {
+			return helpers.ErrorWrap(err, s.name, caller+"/stock.Restock", helpers.InternalServerError,
+				http.StatusInternalServerError)
+		}
+
+		if len(productIDs) == 0 || productIDs[len(productIDs)-1] != orderProduct.ProductID {
+			productIDs = append(productIDs, orderProduct.ProductID)
+		}
+	}
+
+	for _, productID := range productIDs {
+
+		err = syncProductStock(ctx, tx, productID, userID)
+
+		if err != nil {
+			return helpers.ErrorWrap(err, s.name, caller+"/syncProductStock", helpers.InternalServerError,
+				http.StatusInternalServerError)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"afiqo-location/helpers"
+	"afiqo-location/invoice"
+	"afiqo-location/models"
+	"afiqo-location/session"
+	"afiqo-location/util"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	"net/http"
+)
+
+type OrderInvoiceParam struct {
+	ID uuid.UUID `json:"id"`
+}
+
+// Invoice gathers the invoice of a paid order, giving the order its invoice number the first time it is asked
+// for. Customers only get invoices of their own orders.
+func (s OrderModule) Invoice(ctx context.Context, param OrderInvoiceParam) (invoice.Invoice, *helpers.Error) {
+
+	userID := uuid.FromStringOrNil(ctx.Value("user_id").(string))
+
+	order, err := models.GetOneOrder(ctx, s.db, param.ID)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return invoice.Invoice{}, helpers.ErrorWrap(err, s.name, "Invoice/GetOneOrder", helpers.BadRequestMessage,
+				http.StatusNotFound)
+		}
+		return invoice.Invoice{}, helpers.ErrorWrap(err, s.name, "Invoice/GetOneOrder", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	if ctx.Value("role") == session.CUSTOMER_ROLE && order.CustomerID != userID {
+		return invoice.Invoice{}, helpers.ErrorWrap(errors.New("Not Your Order"), s.name, "Invoice/CustomerID",
+			helpers.OrderErrorMessage, http.StatusForbidden)
+	}
+
+	payment, err := models.GetOnePaymentByOrderID(ctx, s.db, order.ID)
+
+	if err != nil {
+		return invoice.Invoice{}, helpers.ErrorWrap(err, s.name, "Invoice/GetOnePaymentByOrderID",
+			helpers.InternalServerError, http.StatusInternalServerError)
+	}
+
+	if util.IsPaymentPayable(payment.Status) || util.IsPaymentClosed(payment.Status) {
+		return invoice.Invoice{}, helpers.ErrorWrap(errors.New("Order Not Paid"), s.name, "Invoice/Status",
+			fmt.Sprintf(`%s : %s`, helpers.PaymentStatusMessage, util.GetPaymentStatus(payment.Status)),
+			http.StatusConflict)
+	}
+
+	number, errs := s.invoiceNumber(ctx, order.ID, userID)
+	if errs != nil {
+		return invoice.Invoice{}, errs
+	}
+
+	customer, err := models.GetOneCustomer(ctx, s.db, order.CustomerID)
+
+	if err != nil {
+		return invoice.Invoice{}, helpers.ErrorWrap(err, s.name, "Invoice/GetOneCustomer", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	orderProducts, err := models.GetAllOrderProductByOrderID(ctx, s.db, order.ID)
+
+	if err != nil {
+		return invoice.Invoice{}, helpers.ErrorWrap(err, s.name, "Invoice/GetAllOrderProductByOrderID",
+			helpers.InternalServerError, http.StatusInternalServerError)
+	}
+
+	refunds, err := models.GetAllPaymentRefundByPaymentID(ctx, s.db, payment.ID)
+
+	if err != nil {
+		return invoice.Invoice{}, helpers.ErrorWrap(err, s.name, "Invoice/GetAllPaymentRefundByPaymentID",
+			helpers.InternalServerError, http.StatusInternalServerError)
+	}
+
+	refunded, _ := models.RefundTotals(refunds)
+
+	document := invoice.Invoice{
+		Number:          number.Number,
+		IssuedAt:        number.CreatedAt,
+		OrderID:         order.ID.String(),
+		CustomerName:    customer.Name,
+		CustomerEmail:   customer.Email,
+		DeliveryAddress: order.DeliveryAddress,
+		DeliveryFee:     order.DeliveryFee,
+		Refunded:        refunded,
+	}
+
+	for _, orderProduct := range orderProducts {
+		product, err := models.GetOneProduct(ctx, s.db, orderProduct.ProductID)
+
+		if err != nil {
+			return invoice.Invoice{}, helpers.ErrorWrap(err, s.name, "Invoice/GetOneProduct",
+				helpers.InternalServerError, http.StatusInternalServerError)
+		}
+
+		// The price is taken from what was charged, the product may have been repriced since.
+		unitPrice := orderProduct.SubTotal
+		if orderProduct.Quantity > 0 {
+			unitPrice = orderProduct.SubTotal.DivRound(decimal.NewFromInt(int64(orderProduct.Quantity)), 2)
+		}
+
+		document.Lines = append(document.Lines, invoice.Line{
+			Description: product.Name,
+			Quantity:    orderProduct.Quantity,
+			UnitPrice:   unitPrice,
+			Amount:      orderProduct.SubTotal,
+		})
+	}
+
+	return document, nil
+}
+
+func (s OrderModule) invoiceNumber(ctx context.Context, orderID, userID uuid.UUID) (models.InvoiceModel,
+	*helpers.Error) {
+
+	number, err := models.GetOneInvoiceByOrderID(ctx, s.db, orderID)
+
+	if err == nil {
+		return number, nil
+	}
+
+	if err != sql.ErrNoRows {
+		return number, helpers.ErrorWrap(err, s.name, "invoiceNumber/GetOneInvoiceByOrderID",
+			helpers.InternalServerError, http.StatusInternalServerError)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return number, helpers.ErrorWrap(err, s.name, "invoiceNumber/BeginTx", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	defer tx.Rollback()
+
+	number = models.InvoiceModel{
+		OrderID:   orderID,
+		CreatedBy: userID,
+	}
+
+	err = number.Insert(ctx, tx)
+
+	// Someone else issued it in the meantime.
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		number, err = models.GetOneInvoiceByOrderID(ctx, s.db, orderID)
+	} else if err == nil {
+		err = tx.Commit()
+	}
+
+	if err != nil {
+		return number, helpers.ErrorWrap(err, s.name, "invoiceNumber/Insert", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	return number, nil
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"afiqo-location/helpers"
+	"afiqo-location/models"
+	"context"
+	"database/sql"
+	"errors"
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	"net/http"
+	"time"
+)
+
+type (
+	QuoteItem struct {
+		ProductID uuid.UUID       `json:"product_id"`
+		Name      string          `json:"name"`
+		Price     decimal.Decimal `json:"price"`
+		Quantity  uint            `json:"quantity"`
+		SubTotal  decimal.Decimal `json:"sub_total"`
+		Stock     uint            `json:"stock"`
+		Available bool            `json:"available"`
+	}
+
+	OrderQuoteResponse struct {
+		Items            []QuoteItem                `json:"items"`
+		Available        bool                       `json:"available"`
+		Warehouse        *models.WarehouseResponse  `json:"warehouse"`
+		Warehouses       []models.WarehouseResponse `json:"warehouses"`
+		Distance         decimal.Decimal            `json:"distance"`
+		SubTotal         decimal.Decimal            `json:"sub_total"`
+		DeliveryFee      decimal.Decimal            `json:"delivery_fee"`
+		TotalPrice       decimal.Decimal            `json:"total_price"`
+		DeliveryDatetime time.Time                  `json:"delivery_datetime"`
+	}
+)
+
+// Quote prices an order the way Order would place it right now. Items that cannot be supplied are listed as
+// unavailable and left out of the totals. Nothing is reserved or written.
+func (s OrderModule) Quote(ctx context.Context, param OrderParam) (interface{}, *helpers.Error) {
+
+	plan, errs := s.plan(ctx, param, "Quote")
+	if errs != nil {
+		return nil, errs
+	}
+
+	short := make(map[uuid.UUID]bool)
+	for _, productID := range plan.short {
+		short[productID] = true
+	}
+
+	stock := make(map[uuid.UUID]uint)
+	for _, warehouseStock := range plan.stocks {
+		stock[warehouseStock.ProductID] += warehouseStock.Stock
+	}
+
+	response := OrderQuoteResponse{
+		Available: len(plan.short) == 0 && len(plan.allocations) > 0,
+	}
+
+	for _, product := range plan.products {
+
+		if product.Quantity == 0 {
+			continue
+		}
+
+		productModel, err := models.GetOneProduct(ctx, s.db, product.ID)
+
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, helpers.ErrorWrap(errors.New("Product Not Found"), s.name, "Quote/GetOneProduct",
+					helpers.BadRequestMessage, http.StatusBadRequest)
+			}
+			return nil, helpers.ErrorWrap(err, s.name, "Quote/GetOneProduct", helpers.InternalServerError,
+				http.StatusInternalServerError)
+		}
+
+		item := QuoteItem{
+			ProductID: product.ID,
+			Name:      productModel.Name,
+			Price:     productModel.Price,
+			Quantity:  product.Quantity,
+			SubTotal:  productModel.Price.Mul(decimal.NewFromInt(int64(product.Quantity))),
+			Stock:     stock[product.ID],
+			Available: !short[product.ID],
+		}
+
+		if item.Available {
+			response.SubTotal = response.SubTotal.Add(item.SubTotal)
+		}
+
+		response.Items = append(response.Items, item)
+	}
+
+	if len(plan.allocations) == 0 {
+		response.TotalPrice = response.SubTotal
+		return response, nil
+	}
+
+	allocated := make(map[uuid.UUID]bool)
+	for _, allocation := range plan.allocations {
+		allocated[allocation.WarehouseID] = true
+	}
+
+	for _, warehouse := range plan.warehouses {
+		if !allocated[warehouse.ID] {
+			continue
+		}
+
+		warehouseResponse := warehouse.Response()
+		response.Warehouses = append(response.Warehouses, warehouseResponse)
+
+		if warehouse.ID == plan.nearest.WarehouseID {
+			response.Warehouse = &warehouseResponse
+		}
+	}
+
+	configuration, err := models.GetConfiguration(ctx, s.db)
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Quote/GetConfiguration", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	response.Distance = DeliveryDistance(plan.distances, plan.allocations)
+	response.DeliveryFee = DeliveryFee(configuration, response.SubTotal, response.Distance)
+	response.TotalPrice = response.SubTotal.Add(response.DeliveryFee)
+	response.DeliveryDatetime = EstimateDelivery(time.Now(), plan.distances, plan.allocations)
+
+	return response, nil
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"afiqo-location/models"
+	"afiqo-location/util"
+	"context"
+	"database/sql"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+// ExpiryActor is the role recorded for orders cancelled because their payment window ran out.
+const ExpiryActor = "EXPIRY"
+
+type PaymentExpiry struct {
+	// WindowMinutes is how long an order waits for payment before it is cancelled. Zero keeps orders forever.
+	WindowMinutes int
+	// IntervalSeconds is how often the worker looks for expired payments.
+	IntervalSeconds int
+	// BatchSize caps how many orders one sweep cancels.
+	BatchSize int
+}
+
+var paymentExpiry = PaymentExpiry{IntervalSeconds: 60, BatchSize: 100}
+
+func InitPaymentExpiry(e PaymentExpiry) {
+	paymentExpiry.WindowMinutes = e.WindowMinutes
+	if e.IntervalSeconds > 0 {
+		paymentExpiry.IntervalSeconds = e.IntervalSeconds
+	}
+	if e.BatchSize > 0 {
+		paymentExpiry.BatchSize = e.BatchSize
+	}
+}
+
+// ExpirePayments sweeps for orders left unpaid past the payment window until ctx is done. An order being
+// cancelled when ctx ends is rolled back whole and picked up again on the next start.
+func (s PaymentModule) ExpirePayments(ctx context.Context) {
+
+	if paymentExpiry.WindowMinutes <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(paymentExpiry.IntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		s.expire(ctx, time.Now().Add(-time.Duration(paymentExpiry.WindowMinutes)*time.Minute))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s PaymentModule) expire(ctx context.Context, deadline time.Time) {
+
+	payments, err := models.GetAllExpiredPayment(ctx, s.db, deadline, paymentExpiry.BatchSize)
+
+	if err != nil {
+		if ctx.Err() == nil {
+			s.logger.Err.Printf(`api.payment.expiry.go/expire/GetAllExpiredPayment/%v`, err)
+		}
+		return
+	}
+
+	for _, payment := range payments {
+		if ctx.Err() != nil {
+			return
+		}
+
+		err = s.expireOne(ctx, payment)
+		if err != nil && ctx.Err() == nil {
+			s.logger.Err.Printf(`api.payment.expiry.go/expire/%s/%v`, payment.OrderID, err)
+		}
+	}
+
+	if len(payments) > 0 {
+		s.logger.Out.Printf(`api.payment.expiry.go/expire/cancelled %d unpaid orders`, len(payments))
+	}
+}
+
+// expireOne marks the payment Expired and cancels its order in one transaction. A payment the gateway settles
+// in the meantime no longer matches its old status and is left alone.
+func (s PaymentModule) expireOne(ctx context.Context, payment models.PaymentModel) error {
+
+	order, err := models.GetOneOrder(ctx, s.db, payment.OrderID)
+	if err != nil {
+		return err
+	}
+
+	ctx = context.WithValue(ctx, "user_id", order.CustomerID.String())
+	ctx = context.WithValue(ctx, "role", ExpiryActor)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	paymentUpdate := models.PaymentModel{
+		ID:     payment.ID,
+		Status: util.PaymentStatusExpired,
+		UpdatedBy: uuid.NullUUID{
+			UUID:  order.CustomerID,
+			Valid: true,
+		},
+	}
+
+	err = paymentUpdate.TransitStatus(ctx, tx, payment.Status)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	errs := NewOrderModule(s.db, s.cache, s.logger).cancel(ctx, tx, order, order.CustomerID, false, "expireOne")
+	if errs != nil {
+		return errs.Err
+	}
+
+	return tx.Commit()
+}
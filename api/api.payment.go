@@ -2,13 +2,17 @@ package api
 
 import (
 	"afiqo-location/email"
+	"afiqo-location/gateway"
 	"afiqo-location/helpers"
 	"afiqo-location/models"
+	"afiqo-location/util"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/gomodule/redigo/redis"
 	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
 	"net/http"
 )
 
@@ -27,8 +31,21 @@ type (
 	PaymentDetailParam struct {
 		ID uuid.UUID `json:"id"`
 	}
+
+	PaymentWebhookParam struct {
+		Payload   []byte
+		Signature string
+	}
+
+	PaymentIntentResponse struct {
+		Payment      models.PaymentResponse `json:"payment"`
+		ClientSecret string                 `json:"client_secret"`
+	}
 )
 
+// GatewayActor is the role recorded for changes made on behalf of the payment gateway.
+const GatewayActor = "GATEWAY"
+
 func NewPaymentModule(db *sql.DB, cache *redis.Pool, logger *helpers.Logger) *PaymentModule {
 	return &PaymentModule{
 		db:     db,
@@ -78,11 +95,17 @@ func (s PaymentModule) List(ctx context.Context, filter helpers.Filter) (interfa
 	return paymentResponse, nil
 }
 
+// Update starts paying for an order by opening an intent at the payment gateway. The payment stays Unpaid
+// until the gateway confirms it through Webhook.
 func (s PaymentModule) Update(ctx context.Context, param PaymentUpdateParam) (interface{}, *helpers.Error) {
 
 	payment, err := models.GetOnePayment(ctx, s.db, param.ID)
 
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, helpers.ErrorWrap(err, s.name, "Update/GetOnePayment", helpers.BadRequestMessage,
+				http.StatusNotFound)
+		}
 		return nil, helpers.ErrorWrap(err, s.name, "Update/GetOnePayment", helpers.InternalServerError,
 			http.StatusInternalServerError)
 	}
@@ -99,57 +122,254 @@ func (s PaymentModule) Update(ctx context.Context, param PaymentUpdateParam) (in
 			http.StatusInternalServerError)
 	}
 
-	payment = models.PaymentModel{
-		ID:     payment.ID,
-		Status: 1,
-		UpdatedBy: uuid.NullUUID{
-			UUID:  uuid.FromStringOrNil(ctx.Value("user_id").(string)),
-			Valid: true,
-		},
+	if !util.IsPaymentPayable(payment.Status) || order.Status != util.OrderStatusOpen {
+		return nil, helpers.ErrorWrap(errors.New("Payment Not Payable"), s.name, "Update/Status",
+			fmt.Sprintf(`%s : %s`, helpers.PaymentStatusMessage, util.GetPaymentStatus(payment.Status)),
+			http.StatusConflict)
+	}
+
+	intent, err := gateway.Provider().CreateIntent(ctx, gateway.Intent{
+		PaymentID: payment.ID.String(),
+		Amount:    payment.Amount,
+		Currency:  gateway.Currency(),
+	})
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Update/CreateIntent", helpers.PaymentGatewayMessage,
+			http.StatusBadGateway)
+	}
+
+	payment.Reference = sql.NullString{String: intent.Reference, Valid: true}
+	payment.UpdatedBy = uuid.NullUUID{
+		UUID:  uuid.FromStringOrNil(ctx.Value("user_id").(string)),
+		Valid: true,
+	}
+
+	err = payment.UpdateReference(ctx, s.db)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, helpers.ErrorWrap(err, s.name, "Update/UpdateReference", helpers.PaymentStatusMessage,
+				http.StatusConflict)
+		}
+		return nil, helpers.ErrorWrap(err, s.name, "Update/UpdateReference", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	payment, err = models.GetOnePayment(ctx, s.db, payment.ID)
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Update/GetOnePayment", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	response, err := payment.Response(ctx, s.db, s.logger)
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Update/Response", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	return PaymentIntentResponse{
+		Payment:      response,
+		ClientSecret: intent.ClientSecret,
+	}, nil
+}
+
+// Webhook applies a signed notification from the payment gateway. Notifications are acknowledged again
+// without effect when the gateway repeats them.
+func (s PaymentModule) Webhook(ctx context.Context, param PaymentWebhookParam) (interface{}, *helpers.Error) {
+
+	event, err := gateway.Provider().VerifyWebhook(param.Payload, param.Signature)
+
+	if err != nil {
+		if err == gateway.ErrInvalidSignature {
+			return nil, helpers.ErrorWrap(err, s.name, "Webhook/VerifyWebhook", helpers.UnauthorizedMessage,
+				http.StatusUnauthorized)
+		}
+		return nil, helpers.ErrorWrap(err, s.name, "Webhook/VerifyWebhook", helpers.BadRequestMessage,
+			http.StatusBadRequest)
+	}
+
+	payment, err := models.GetOnePaymentByReference(ctx, s.db, event.Reference)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, helpers.ErrorWrap(err, s.name, "Webhook/GetOnePaymentByReference", helpers.BadRequestMessage,
+				http.StatusNotFound)
+		}
+		return nil, helpers.ErrorWrap(err, s.name, "Webhook/GetOnePaymentByReference", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	order, err := models.GetOneOrder(ctx, s.db, payment.OrderID)
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Webhook/GetOneOrder", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	// Nobody is signed in on a webhook, changes are recorded against the paying customer.
+	ctx = context.WithValue(ctx, "user_id", order.CustomerID.String())
+	ctx = context.WithValue(ctx, "role", GatewayActor)
+
+	switch event.Type {
+	case gateway.EventPaymentSucceeded:
+		if !event.Amount.Equal(payment.Amount) {
+			return nil, helpers.ErrorWrap(fmt.Errorf(`paid %s for %s`, event.Amount, payment.Amount), s.name,
+				"Webhook/Amount", helpers.PaymentAmountMessage, http.StatusConflict)
+		}
+
+		errs := s.settle(ctx, payment, order, util.PaymentStatusPaid)
+		if errs != nil {
+			return nil, errs
+		}
+	case gateway.EventPaymentFailed:
+		errs := s.settle(ctx, payment, order, util.PaymentStatusFailed)
+		if errs != nil {
+			return nil, errs
+		}
+	default:
+		s.logger.Out.Printf(`api.payment.go/Webhook/ignored %s for %s`, event.Type, event.Reference)
+	}
+
+	payment, err = models.GetOnePayment(ctx, s.db, payment.ID)
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Webhook/GetOnePayment", helpers.InternalServerError,
+			http.StatusInternalServerError)
 	}
 
-	err = payment.Update(ctx, s.db)
+	response, err := payment.Response(ctx, s.db, s.logger)
+
 	if err != nil {
-		return nil, helpers.ErrorWrap(err, s.name, "Update/Update", helpers.InternalServerError,
+		return nil, helpers.ErrorWrap(err, s.name, "Webhook/Response", helpers.InternalServerError,
 			http.StatusInternalServerError)
 	}
 
-	orderUpdate := models.OrderModel{
-		ID:     order.ID,
-		Status: 1,
+	return response, nil
+}
+
+// settle records the gateway's outcome. A paid payment confirms the order, queues the receipt and dispatches
+// the order, a payment already settled is left as it is.
+func (s PaymentModule) settle(ctx context.Context, payment models.PaymentModel, order models.OrderModel,
+	status int) *helpers.Error {
+
+	if util.IsPaymentClosed(payment.Status) && status == util.PaymentStatusPaid {
+		return s.settleClosed(ctx, payment)
+	}
+
+	if !util.IsPaymentPayable(payment.Status) {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return helpers.ErrorWrap(err, s.name, "settle/BeginTx", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	defer tx.Rollback()
+
+	paymentUpdate := models.PaymentModel{
+		ID:     payment.ID,
+		Status: status,
 		UpdatedBy: uuid.NullUUID{
-			UUID:  uuid.FromStringOrNil(ctx.Value("user_id").(string)),
+			UUID:  order.CustomerID,
 			Valid: true,
 		},
 	}
 
-	err = orderUpdate.UpdateStatus(ctx, s.db)
+	err = paymentUpdate.TransitStatus(ctx, tx, payment.Status)
+
 	if err != nil {
-		return nil, helpers.ErrorWrap(err, s.name, "Update/UpdateStatus", helpers.InternalServerError,
+		// Changed since it was read, most likely expired. The gateway retries and meets the new status.
+		if err == sql.ErrNoRows {
+			return helpers.ErrorWrap(err, s.name, "settle/TransitStatus", helpers.PaymentStatusMessage,
+				http.StatusConflict)
+		}
+		return helpers.ErrorWrap(err, s.name, "settle/TransitStatus", helpers.InternalServerError,
 			http.StatusInternalServerError)
 	}
 
-	customer, err := models.GetOneCustomer(ctx, s.db, order.CustomerID)
+	if status == util.PaymentStatusPaid {
+		errs := NewOrderModule(s.db, s.cache, s.logger).UpdateStatus(ctx, tx, order, util.OrderStatusConfirmed)
+		if errs != nil {
+			return errs
+		}
+
+		err = s.queueReceipt(ctx, tx, order)
+		if err != nil {
+			return helpers.ErrorWrap(err, s.name, "settle/queueReceipt", helpers.InternalServerError,
+				http.StatusInternalServerError)
+		}
+	}
 
+	err = tx.Commit()
 	if err != nil {
-		return nil, helpers.ErrorWrap(err, s.name, "Update/GetOneCustomer", helpers.InternalServerError,
+		return helpers.ErrorWrap(err, s.name, "settle/Commit", helpers.InternalServerError,
 			http.StatusInternalServerError)
 	}
 
-	orderProducts, err := models.GetAllOrderProductByOrderID(ctx, s.db, order.ID)
+	if status != util.PaymentStatusPaid {
+		return nil
+	}
+
+	NewShipmentModule(s.db, s.cache, s.logger).Dispatch(ctx, order.ID)
+
+	return nil
+}
+
+// settleClosed handles money that arrives after the payment was closed, by the payment window running out or
+// by the order being cancelled. The order is already cancelled and restocked, so the payment is recorded as
+// Paid and refunded in full.
+func (s PaymentModule) settleClosed(ctx context.Context, payment models.PaymentModel) *helpers.Error {
+
+	reason := RefundReasonExpired
+	if payment.Status == util.PaymentStatusCancelled {
+		reason = RefundReasonCancelled
+	}
+
+	paymentUpdate := models.PaymentModel{
+		ID:        payment.ID,
+		Status:    util.PaymentStatusPaid,
+		UpdatedBy: payment.UpdatedBy,
+	}
+
+	err := paymentUpdate.TransitStatus(ctx, s.db, payment.Status)
 
 	if err != nil {
-		return nil, helpers.ErrorWrap(err, s.name, "Update/GetAllOrderProductByOrderID", helpers.InternalServerError,
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return helpers.ErrorWrap(err, s.name, "settleClosed/TransitStatus", helpers.InternalServerError,
 			http.StatusInternalServerError)
 	}
 
+	return s.refund(ctx, payment.ID, decimal.NullDecimal{}, reason, "settleClosed")
+}
+
+// queueReceipt puts the receipt for the customer's order in the outbox.
+func (s PaymentModule) queueReceipt(ctx context.Context, db helpers.DBExecutor, order models.OrderModel) error {
+
+	customer, err := models.GetOneCustomer(ctx, db, order.CustomerID)
+
+	if err != nil {
+		return err
+	}
+
+	orderProducts, err := models.GetAllOrderProductByOrderID(ctx, db, order.ID)
+
+	if err != nil {
+		return err
+	}
+
 	var entries [][]email.Entry
 	for _, orderProduct := range orderProducts {
 		var columns []email.Entry
-		product, err := models.GetOneProduct(ctx, s.db, orderProduct.ProductID)
+		product, err := models.GetOneProduct(ctx, db, orderProduct.ProductID)
 		if err != nil {
-			return nil, helpers.ErrorWrap(err, s.name, "Update/GetOneProduct", helpers.InternalServerError,
-				http.StatusInternalServerError)
+			return err
 		}
 		column := email.Entry{
 			Key:   "Item",
@@ -185,34 +405,14 @@ func (s PaymentModule) Update(ctx context.Context, param PaymentUpdateParam) (in
 	body, err := data.GenerateForReceipt()
 
 	if err != nil {
-		return nil, helpers.ErrorWrap(err, s.name, "Update/GenerateForReceipt", helpers.InternalServerError,
-			http.StatusInternalServerError)
+		return err
 	}
 
 	mail := email.Mail{
 		Subject: "Order Processing",
 		Body:    body,
-		To:      "mohdjamilafiq@gmail.com",
+		To:      customer.Email,
 	}
 
-	go func() {
-		mail.SendEmail()
-	}()
-
-	payment, err = models.GetOnePayment(ctx, s.db, payment.ID)
-
-	if err != nil {
-		return nil, helpers.ErrorWrap(err, s.name, "Update/GetOnePayment", helpers.InternalServerError,
-			http.StatusInternalServerError)
-	}
-
-	response, err := payment.Response(ctx, s.db, s.logger)
-
-	if err != nil {
-		return nil, helpers.ErrorWrap(err, s.name, "Update/Response", helpers.InternalServerError,
-			http.StatusInternalServerError)
-	}
-
-	return response, nil
-
+	return queueMail(ctx, db, mail)
 }
@@ -0,0 +1,259 @@
+package api
+
+import (
+	"afiqo-location/gateway"
+	"afiqo-location/helpers"
+	"afiqo-location/models"
+	"afiqo-location/util"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	"net/http"
+)
+
+// Reasons recorded on refunds the system makes by itself.
+const (
+	RefundReasonCancelled = "Order Cancelled"
+	RefundReasonExpired   = "Paid After Payment Window"
+)
+
+type PaymentRefundParam struct {
+	ID uuid.UUID `json:"id"`
+	// Amount left empty refunds whatever has not been refunded yet.
+	Amount decimal.NullDecimal `json:"amount"`
+	Reason string              `json:"reason" validate:"required,max=255"`
+}
+
+var errNothingToRefund = errors.New("nothing left to refund")
+
+// Refund gives back all or part of a paid payment through the payment gateway.
+func (s PaymentModule) Refund(ctx context.Context, param PaymentRefundParam) (interface{}, *helpers.Error) {
+
+	errs := s.refund(ctx, param.ID, param.Amount, param.Reason, "Refund")
+	if errs != nil {
+		return nil, errs
+	}
+
+	payment, err := models.GetOnePayment(ctx, s.db, param.ID)
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Refund/GetOnePayment", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	response, err := payment.Response(ctx, s.db, s.logger)
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Refund/Response", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	return response, nil
+}
+
+// RefundOrder refunds whatever is left on the payment of a cancelled order. Orders that were never paid have
+// nothing to give back.
+func (s PaymentModule) RefundOrder(ctx context.Context, orderID uuid.UUID) *helpers.Error {
+
+	payment, err := models.GetOnePaymentByOrderID(ctx, s.db, orderID)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return helpers.ErrorWrap(err, s.name, "RefundOrder/GetOnePaymentByOrderID", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	if payment.Status != util.PaymentStatusPaid && payment.Status != util.PaymentStatusPartiallyRefunded {
+		return nil
+	}
+
+	return s.refund(ctx, payment.ID, decimal.NullDecimal{}, RefundReasonCancelled, "RefundOrder")
+}
+
+// refund holds the amount back as a pending refund before asking the gateway, so two refunds running at once
+// cannot give back more than was paid. The gateway's answer settles the refund and the payment status.
+func (s PaymentModule) refund(ctx context.Context, paymentID uuid.UUID, amount decimal.NullDecimal,
+	reason, caller string) *helpers.Error {
+
+	userID := uuid.FromStringOrNil(ctx.Value("user_id").(string))
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return helpers.ErrorWrap(err, s.name, caller+"/BeginTx", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	defer tx.Rollback()
+
+	payment, err := models.GetOnePaymentForUpdate(ctx, tx, paymentID)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return helpers.ErrorWrap(err, s.name, caller+"/GetOnePaymentForUpdate", helpers.BadRequestMessage,
+				http.StatusNotFound)
+		}
+		return helpers.ErrorWrap(err, s.name, caller+"/GetOnePaymentForUpdate", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	if payment.Status != util.PaymentStatusPaid && payment.Status != util.PaymentStatusPartiallyRefunded {
+		return helpers.ErrorWrap(errors.New("Payment Not Refundable"), s.name, caller+"/Status",
+			fmt.Sprintf(`%s : %s`, helpers.PaymentStatusMessage, util.GetPaymentStatus(payment.Status)),
+			http.StatusConflict)
+	}
+
+	refunds, err := models.GetAllPaymentRefundByPaymentID(ctx, tx, payment.ID)
+
+	if err != nil {
+		return helpers.ErrorWrap(err, s.name, caller+"/GetAllPaymentRefundByPaymentID", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	refundAmount, err := refundableAmount(payment.Amount, refunds, amount)
+
+	if err != nil {
+		return helpers.ErrorWrap(err, s.name, caller+"/refundableAmount", helpers.RefundAmountMessage,
+			http.StatusBadRequest)
+	}
+
+	refund := models.PaymentRefundModel{
+		PaymentID: payment.ID,
+		Amount:    refundAmount,
+		Reason:    reason,
+		Status:    util.RefundStatusPending,
+		CreatedBy: userID,
+	}
+
+	err = refund.Insert(ctx, tx)
+
+	if err != nil {
+		return helpers.ErrorWrap(err, s.name, caller+"/PaymentRefundInsert", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		return helpers.ErrorWrap(err, s.name, caller+"/Commit", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	result, gatewayErr := gateway.Provider().Refund(ctx, gateway.Refund{
+		Reference: payment.Reference.String,
+		Amount:    refund.Amount,
+		Reason:    refund.Reason,
+	})
+
+	refund.Status = util.RefundStatusSucceeded
+	refund.Reference = sql.NullString{String: result.Reference, Valid: gatewayErr == nil}
+	refund.UpdatedBy = uuid.NullUUID{UUID: userID, Valid: true}
+	if gatewayErr != nil {
+		refund.Status = util.RefundStatusFailed
+	}
+
+	errs := s.settleRefund(ctx, refund, caller)
+	if errs != nil {
+		return errs
+	}
+
+	if gatewayErr != nil {
+		return helpers.ErrorWrap(gatewayErr, s.name, caller+"/gateway.Refund", helpers.PaymentGatewayMessage,
+			http.StatusBadGateway)
+	}
+
+	return nil
+}
+
+// settleRefund records the outcome of a pending refund and moves the payment to Partially Refunded or Refunded.
+func (s PaymentModule) settleRefund(ctx context.Context, refund models.PaymentRefundModel,
+	caller string) *helpers.Error {
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return helpers.ErrorWrap(err, s.name, caller+"/settleRefund/BeginTx", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	defer tx.Rollback()
+
+	payment, err := models.GetOnePaymentForUpdate(ctx, tx, refund.PaymentID)
+
+	if err != nil {
+		return helpers.ErrorWrap(err, s.name, caller+"/settleRefund/GetOnePaymentForUpdate",
+			helpers.InternalServerError, http.StatusInternalServerError)
+	}
+
+	err = refund.UpdateStatus(ctx, tx)
+
+	if err != nil {
+		return helpers.ErrorWrap(err, s.name, caller+"/settleRefund/UpdateStatus", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	refunds, err := models.GetAllPaymentRefundByPaymentID(ctx, tx, payment.ID)
+
+	if err != nil {
+		return helpers.ErrorWrap(err, s.name, caller+"/settleRefund/GetAllPaymentRefundByPaymentID",
+			helpers.InternalServerError, http.StatusInternalServerError)
+	}
+
+	refunded, _ := models.RefundTotals(refunds)
+
+	payment.Status = refundedStatus(payment.Amount, refunded)
+	payment.UpdatedBy = refund.UpdatedBy
+
+	err = payment.Update(ctx, tx)
+
+	if err != nil {
+		return helpers.ErrorWrap(err, s.name, caller+"/settleRefund/Update", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		return helpers.ErrorWrap(err, s.name, caller+"/settleRefund/Commit", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// refundableAmount checks a requested refund against what is left of the payment. No amount asks for all
+// that is left.
+func refundableAmount(paid decimal.Decimal, refunds []models.PaymentRefundModel,
+	amount decimal.NullDecimal) (decimal.Decimal, error) {
+
+	_, held := models.RefundTotals(refunds)
+	left := paid.Sub(held)
+
+	if !left.IsPositive() {
+		return decimal.Zero, errNothingToRefund
+	}
+
+	if !amount.Valid {
+		return left, nil
+	}
+
+	if !amount.Decimal.IsPositive() || amount.Decimal.GreaterThan(left) {
+		return decimal.Zero, fmt.Errorf(`refund of %s with %s left`, amount.Decimal, left)
+	}
+
+	return amount.Decimal, nil
+}
+
+func refundedStatus(paid, refunded decimal.Decimal) int {
+	switch {
+	case !refunded.IsPositive():
+		return util.PaymentStatusPaid
+	case refunded.LessThan(paid):
+		return util.PaymentStatusPartiallyRefunded
+	default:
+		return util.PaymentStatusRefunded
+	}
+}
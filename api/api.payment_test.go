@@ -0,0 +1,201 @@
+package api
+
+import (
+	"afiqo-location/gateway"
+	"afiqo-location/helpers"
+	"afiqo-location/models"
+	"afiqo-location/session"
+	"afiqo-location/util"
+	"context"
+	"database/sql"
+	"fmt"
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	"os"
+	"testing"
+	"time"
+)
+
+//set AFIQO_TEST_DATABASE_URL to a local postgres with the afiqo schema to run
+
+func testDB(t *testing.T) *sql.DB {
+	dsn := os.Getenv("AFIQO_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("AFIQO_TEST_DATABASE_URL not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Ping()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func TestRefundableAmount(t *testing.T) {
+
+	paid := decimal.NewFromInt(100)
+	refunds := []models.PaymentRefundModel{
+		{Amount: decimal.NewFromInt(30), Status: util.RefundStatusSucceeded},
+		{Amount: decimal.NewFromInt(20), Status: util.RefundStatusPending},
+		{Amount: decimal.NewFromInt(50), Status: util.RefundStatusFailed},
+	}
+
+	amount, err := refundableAmount(paid, refunds, decimal.NullDecimal{})
+	if err != nil || !amount.Equal(decimal.NewFromInt(50)) {
+		t.Fatalf("full refund = %s, %v, want the 50 neither refunded nor pending", amount, err)
+	}
+
+	_, err = refundableAmount(paid, refunds, decimal.NullDecimal{Decimal: decimal.NewFromInt(51), Valid: true})
+	if err == nil {
+		t.Fatal("refunding more than is left should fail")
+	}
+
+	_, err = refundableAmount(paid, refunds, decimal.NullDecimal{Decimal: decimal.Zero, Valid: true})
+	if err == nil {
+		t.Fatal("a zero refund should fail")
+	}
+
+	refunds = append(refunds, models.PaymentRefundModel{Amount: decimal.NewFromInt(50),
+		Status: util.RefundStatusSucceeded})
+
+	_, err = refundableAmount(paid, refunds, decimal.NullDecimal{})
+	if err != errNothingToRefund {
+		t.Fatalf("refund of a fully refunded payment err = %v, want errNothingToRefund", err)
+	}
+
+	if refundedStatus(paid, decimal.NewFromInt(30)) != util.PaymentStatusPartiallyRefunded ||
+		refundedStatus(paid, paid) != util.PaymentStatusRefunded {
+		t.Fatal("payment status should follow the refunded total")
+	}
+}
+
+func TestCancelThenWebhookRefunds(t *testing.T) {
+
+	db := testDB(t)
+	defer db.Close()
+
+	err := gateway.Init(gateway.Gateway{Provider: gateway.ProviderFake, Secret: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	customer := models.CustomerModel{
+		Name:        "Test Customer",
+		DateOfBirth: time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC),
+		Address:     "Test Address",
+		PhoneNo:     "0123456789",
+		Email:       fmt.Sprintf(`%s@test.afiqo`, uuid.NewV4()),
+		Password:    "password",
+		CreatedBy:   uuid.NewV4(),
+	}
+	err = customer.Insert(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.WithValue(context.Background(), "user_id", customer.ID.String())
+	ctx = context.WithValue(ctx, "role", session.CUSTOMER_ROLE)
+
+	warehouse := models.WarehouseModel{
+		Name:      "Test Warehouse",
+		Address:   "Test Address",
+		Latitude:  decimal.NewFromFloat(3.0738),
+		Longitude: decimal.NewFromFloat(101.5183),
+		PhoneNo:   "0123456789",
+		CreatedBy: customer.ID,
+	}
+	err = warehouse.Insert(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	order := models.OrderModel{
+		CustomerID:       customer.ID,
+		WarehouseID:      warehouse.ID,
+		DeliveryDatetime: time.Now(),
+		DeliveryAddress:  "Test Address",
+		Latitude:         decimal.NewFromFloat(3.0738),
+		Longitude:        decimal.NewFromFloat(101.5183),
+		Status:           util.OrderStatusOpen,
+		TotalPrice:       decimal.NewFromInt(100),
+		CreatedBy:        customer.ID,
+	}
+	err = order.Insert(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payment := models.PaymentModel{
+		OrderID:   order.ID,
+		Status:    util.PaymentStatusUnpaid,
+		Amount:    decimal.NewFromInt(100),
+		CreatedBy: customer.ID,
+	}
+	err = payment.Insert(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM payment_refund WHERE payment_id = $1`, payment.ID)
+		db.Exec(`DELETE FROM payment WHERE id = $1`, payment.ID)
+		db.Exec(`DELETE FROM "order" WHERE id = $1`, order.ID)
+		db.Exec(`DELETE FROM warehouse WHERE id = $1`, warehouse.ID)
+		db.Exec(`DELETE FROM customer WHERE id = $1`, customer.ID)
+	})
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	errs := NewOrderModule(db, nil, helpers.NewLogger()).cancel(ctx, tx, order, customer.ID, true, "test")
+	if errs != nil {
+		t.Fatal(errs.Err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payment, err = models.GetOnePayment(ctx, db, payment.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if payment.Status != util.PaymentStatusCancelled {
+		t.Fatalf("payment after cancel is %s, want Cancelled", util.GetPaymentStatus(payment.Status))
+	}
+
+	// The customer paid on the intent they already had before the webhook for it arrived.
+	errs = NewPaymentModule(db, nil, helpers.NewLogger()).settle(ctx, payment, order, util.PaymentStatusPaid)
+	if errs != nil {
+		t.Fatal(errs.Err)
+	}
+
+	payment, err = models.GetOnePayment(ctx, db, payment.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if payment.Status != util.PaymentStatusRefunded {
+		t.Fatalf("payment after late webhook is %s, want Refunded", util.GetPaymentStatus(payment.Status))
+	}
+
+	refunds, err := models.GetAllPaymentRefundByPaymentID(ctx, db, payment.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(refunds) != 1 || !refunds[0].Amount.Equal(payment.Amount) || refunds[0].Reason != RefundReasonCancelled {
+		t.Fatalf("refunds = %+v, want one full refund for the cancelled order", refunds)
+	}
+}
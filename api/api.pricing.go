@@ -0,0 +1,61 @@
+package api
+
+import (
+	"afiqo-location/models"
+	"afiqo-location/util"
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// DeliveryFee charges the base fee plus the per-km rate on the delivery distance. Orders whose subtotal
+// reaches the free delivery threshold are delivered for free; a zero threshold turns that off.
+func DeliveryFee(configuration models.ConfigurationModel, subTotal, distance decimal.Decimal) decimal.Decimal {
+
+	if configuration.FreeDeliveryThreshold.IsPositive() &&
+		subTotal.GreaterThanOrEqual(configuration.FreeDeliveryThreshold) {
+		return decimal.Zero
+	}
+
+	fee := configuration.DeliveryFee.Add(configuration.DeliveryFeePerKm.Mul(distance))
+
+	return fee.Round(2)
+}
+
+// DeliveryDistance is the distance the order travels in km: each warehouse taking part sends its own shipment,
+// so the road distance from every one of them counts.
+func DeliveryDistance(distances []util.Distance, allocations []Allocation) decimal.Decimal {
+
+	allocated := make(map[uuid.UUID]bool)
+	for _, allocation := range allocations {
+		allocated[allocation.WarehouseID] = true
+	}
+
+	var meters int
+	for _, distance := range distances {
+		if allocated[distance.WarehouseID] {
+			meters += distance.DistanceValue
+		}
+	}
+
+	return decimal.NewFromFloat(util.MeterToKilometer(meters))
+}
+
+// EstimateDelivery is when the last shipment of the order should arrive: the handling time plus the longest
+// travel time among the warehouses taking part.
+func EstimateDelivery(now time.Time, distances []util.Distance, allocations []Allocation) time.Time {
+
+	allocated := make(map[uuid.UUID]bool)
+	for _, allocation := range allocations {
+		allocated[allocation.WarehouseID] = true
+	}
+
+	var duration int
+	for _, distance := range distances {
+		if allocated[distance.WarehouseID] && distance.DurationValue > duration {
+			duration = distance.DurationValue
+		}
+	}
+
+	return now.Add(time.Duration(fulfilment.HandlingMinutes)*time.Minute + time.Duration(duration)*time.Second)
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"afiqo-location/models"
+	"afiqo-location/util"
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	"testing"
+	"time"
+)
+
+func TestDeliveryFee(t *testing.T) {
+
+	configuration := models.ConfigurationModel{
+		DeliveryFee:           decimal.NewFromInt(5),
+		DeliveryFeePerKm:      decimal.NewFromFloat(0.5),
+		FreeDeliveryThreshold: decimal.NewFromInt(150),
+	}
+
+	tests := []struct {
+		subTotal string
+		distance string
+		want     string
+	}{
+		{"20", "0", "5"},
+		{"20", "12.35", "11.18"},
+		{"149.99", "40", "25"},
+		{"150", "40", "0"},
+	}
+
+	for _, test := range tests {
+		fee := DeliveryFee(configuration, decimal.RequireFromString(test.subTotal),
+			decimal.RequireFromString(test.distance))
+		if !fee.Equal(decimal.RequireFromString(test.want)) {
+			t.Errorf("fee for %s over %skm = %s, want %s", test.subTotal, test.distance, fee, test.want)
+		}
+	}
+
+	configuration.FreeDeliveryThreshold = decimal.Zero
+
+	fee := DeliveryFee(configuration, decimal.NewFromInt(1000), decimal.Zero)
+	if !fee.Equal(decimal.NewFromInt(5)) {
+		t.Fatalf("fee without threshold = %s, want the base fee", fee)
+	}
+}
+
+func TestDeliveryFeeSplitOrder(t *testing.T) {
+
+	configuration := models.ConfigurationModel{
+		DeliveryFee:      decimal.NewFromInt(5),
+		DeliveryFeePerKm: decimal.NewFromFloat(0.5),
+	}
+
+	near := uuid.NewV4()
+	far := uuid.NewV4()
+	unused := uuid.NewV4()
+
+	distances := []util.Distance{
+		{WarehouseID: near, DistanceValue: 4000},
+		{WarehouseID: far, DistanceValue: 16000},
+		{WarehouseID: unused, DistanceValue: 1000},
+	}
+
+	// Two of the order's products ship from the nearer warehouse, one from the farther.
+	allocations := []Allocation{{WarehouseID: near}, {WarehouseID: near}, {WarehouseID: far}}
+
+	distance := DeliveryDistance(distances, allocations)
+	if !distance.Equal(decimal.NewFromInt(20)) {
+		t.Fatalf("distance = %skm, want 20km from both shipping warehouses", distance)
+	}
+
+	fee := DeliveryFee(configuration, decimal.NewFromInt(50), distance)
+	if !fee.Equal(decimal.NewFromInt(15)) {
+		t.Fatalf("fee = %s, want 15", fee)
+	}
+}
+
+func TestEstimateDelivery(t *testing.T) {
+
+	fulfilment.HandlingMinutes = 30
+	defer func() { fulfilment.HandlingMinutes = 0 }()
+
+	near := uuid.NewV4()
+	far := uuid.NewV4()
+	unused := uuid.NewV4()
+
+	distances := []util.Distance{
+		{WarehouseID: near, DurationValue: 600},
+		{WarehouseID: far, DurationValue: 1800},
+		{WarehouseID: unused, DurationValue: 7200},
+	}
+
+	allocations := []Allocation{{WarehouseID: near}, {WarehouseID: far}}
+
+	now := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	eta := EstimateDelivery(now, distances, allocations)
+
+	// The split order arrives with its slowest shipment, after the handling time.
+	if want := now.Add(time.Hour); !eta.Equal(want) {
+		t.Fatalf("eta = %v, want %v", eta, want)
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"afiqo-location/models"
 	"context"
 	"database/sql"
-	"fmt"
 	"github.com/gomodule/redigo/redis"
 	uuid "github.com/satori/go.uuid"
 	"github.com/shopspring/decimal"
@@ -25,8 +24,9 @@ type (
 	}
 
 	ForCustomerParam struct {
-		Longitude decimal.Decimal `json:"longitude" validate:"required"`
-		Latitude  decimal.Decimal `json:"latitude" validate:"required"`
+		AddressID uuid.UUID       `json:"address_id"`
+		Longitude decimal.Decimal `json:"longitude" validate:"required_without=AddressID"`
+		Latitude  decimal.Decimal `json:"latitude" validate:"required_without=AddressID"`
 	}
 
 	ProductDetailParam struct {
@@ -131,27 +131,22 @@ func (s ProductModule) ListBySupplierID(ctx context.Context, filter helpers.Filt
 func (s ProductModule) ListForCustomer(ctx context.Context, filter helpers.Filter, param ForCustomerParam) (
 	interface{}, *helpers.Error) {
 
-	warehouses, err := models.GetAllWarehouseWithDistance(ctx, s.db, helpers.Filter{
-		FilterOption: helpers.FilterOption{
-			Limit:  1,
-			Offset: 0,
-		},
-		Longitude: param.Longitude,
-		Latitude:  param.Latitude,
-	})
+	if param.AddressID != uuid.Nil {
+		address, errs := savedAddress(ctx, s.db, s.name, "ListForCustomer", param.AddressID)
+		if errs != nil {
+			return nil, errs
+		}
 
-	if err != nil {
-		return nil, helpers.ErrorWrap(err, s.name, "ListForCustomer/GetAllWarehouseWithDistance",
-			helpers.InternalServerError,
-			http.StatusInternalServerError)
+		param.Latitude, param.Longitude = address.Latitude, address.Longitude
 	}
 
-	var warehouseID uuid.UUID
-	for _, warehouse := range warehouses {
-		warehouseID = warehouse.ID
+	warehouses, err := reachableWarehouses(ctx, s.db, param.Latitude, param.Longitude)
+
+	if err != nil {
+		return nil, reachableError(err, s.name, "ListForCustomer")
 	}
-	fmt.Println(warehouseID)
-	products, err := models.GetAllProductForCustomer(ctx, s.db, filter, warehouseID)
+
+	products, err := models.GetAllProductForCustomer(ctx, s.db, filter, warehouseIDs(warehouses))
 
 	if err != nil {
 		return nil, helpers.ErrorWrap(err, s.name, "ListForCustomer/GetAllProductForCustomer",
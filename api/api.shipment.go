@@ -3,10 +3,15 @@ package api
 import (
 	"afiqo-location/helpers"
 	"afiqo-location/models"
+	"afiqo-location/session"
+	"afiqo-location/util"
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"github.com/gomodule/redigo/redis"
 	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
 	"net/http"
 )
 
@@ -27,14 +32,21 @@ type (
 	}
 
 	ShipmentAddParam struct {
-		CourierID uuid.UUID `json:"courier_id" validate:"required"`
-		OrderID   uuid.UUID `json:"order_id" validate:"required"`
-		Status    int       `json:"status" validate:"required"`
+		CourierID   uuid.UUID `json:"courier_id"`
+		OrderID     uuid.UUID `json:"order_id" validate:"required"`
+		WarehouseID uuid.UUID `json:"warehouse_id"`
 	}
 
 	ShipmentUpdateParam struct {
-		ID     uuid.UUID `json:"id"`
-		Status int       `json:"status"`
+		ID        uuid.UUID           `json:"id"`
+		Status    int                 `json:"status" validate:"required"`
+		Latitude  decimal.NullDecimal `json:"latitude"`
+		Longitude decimal.NullDecimal `json:"longitude"`
+	}
+
+	ShipmentTrackingResponse struct {
+		Shipment models.ShipmentResponse        `json:"shipment"`
+		Events   []models.ShipmentEventResponse `json:"events"`
 	}
 )
 
@@ -147,25 +159,68 @@ func (s ShipmentModule) Add(ctx context.Context, param ShipmentAddParam) (interf
 			http.StatusInternalServerError)
 	}
 
-	if order.Status != 1 {
+	if order.Status != util.OrderStatusConfirmed {
 		return nil, helpers.ErrorWrap(err, s.name, "Add/GetOneOrder", "Order Not Confirmed",
 			http.StatusInternalServerError)
 	}
 
-	shipment := models.ShipmentModel{
-		CourierID: param.CourierID,
-		OrderID:   param.OrderID,
-		Status:    param.Status,
-		CreatedBy: uuid.FromStringOrNil(ctx.Value("user_id").(string)),
+	// A split order ships once per warehouse, defaulting to the order's own warehouse.
+	warehouseID := param.WarehouseID
+	if warehouseID == uuid.Nil {
+		warehouseID = order.WarehouseID
 	}
 
-	err = shipment.Insert(ctx, s.db)
+	warehouses, err := models.GetAllWarehouseByOrderID(ctx, s.db, order.ID)
 
 	if err != nil {
-		return nil, helpers.ErrorWrap(err, s.name, "Add/Insert", helpers.InternalServerError,
+		return nil, helpers.ErrorWrap(err, s.name, "Add/GetAllWarehouseByOrderID", helpers.InternalServerError,
 			http.StatusInternalServerError)
 	}
 
+	servesOrder := false
+	for _, warehouse := range warehouses {
+		if warehouse.ID == warehouseID {
+			servesOrder = true
+			break
+		}
+	}
+
+	if !servesOrder {
+		return nil, helpers.ErrorWrap(errors.New("Warehouse Not In Order"), s.name, "Add/GetAllWarehouseByOrderID",
+			"Warehouse Not In Order", http.StatusBadRequest)
+	}
+
+	_, err = models.GetOneShipmentByOrderAndWarehouse(ctx, s.db, order.ID, warehouseID)
+
+	if err == nil {
+		return nil, helpers.ErrorWrap(errors.New("Shipment Already Exists"), s.name,
+			"Add/GetOneShipmentByOrderAndWarehouse", "Shipment Already Exists", http.StatusConflict)
+	}
+
+	if err != sql.ErrNoRows {
+		return nil, helpers.ErrorWrap(err, s.name, "Add/GetOneShipmentByOrderAndWarehouse",
+			helpers.InternalServerError, http.StatusInternalServerError)
+	}
+
+	shipment := models.ShipmentModel{
+		CourierID:   param.CourierID,
+		OrderID:     param.OrderID,
+		WarehouseID: warehouseID,
+		Status:      util.ShipmentStatusProcessing,
+		CreatedBy:   uuid.FromStringOrNil(ctx.Value("user_id").(string)),
+	}
+
+	var errs *helpers.Error
+	if shipment.CourierID == uuid.Nil {
+		errs = s.createWithPickedCourier(ctx, &shipment, ctx.Value("role").(string), "Add")
+	} else {
+		errs = s.create(ctx, &shipment, ctx.Value("role").(string), "Add")
+	}
+
+	if errs != nil {
+		return nil, errs
+	}
+
 	response, err := shipment.Response(ctx, s.db, s.logger)
 
 	if err != nil {
@@ -177,28 +232,324 @@ func (s ShipmentModule) Add(ctx context.Context, param ShipmentAddParam) (interf
 
 }
 
+// create inserts the shipment together with its first event, once the courier is checked to be able to take it.
+func (s ShipmentModule) create(ctx context.Context, shipment *models.ShipmentModel,
+	actor, caller string) *helpers.Error {
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return helpers.ErrorWrap(err, s.name, caller+"/BeginTx", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	defer tx.Rollback()
+
+	err = checkCourier(ctx, tx, shipment.CourierID)
+	if err != nil {
+		return courierError(err, s.name, caller)
+	}
+
+	err = shipment.Insert(ctx, tx)
+
+	if err != nil {
+		return helpers.ErrorWrap(err, s.name, caller+"/Insert", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	shipmentEvent := models.ShipmentEventModel{
+		ShipmentID: shipment.ID,
+		Status:     shipment.Status,
+		ActorRole:  actor,
+		CreatedBy:  shipment.CreatedBy,
+	}
+
+	err = shipmentEvent.Insert(ctx, tx)
+
+	if err != nil {
+		return helpers.ErrorWrap(err, s.name, caller+"/ShipmentEventInsert", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		return helpers.ErrorWrap(err, s.name, caller+"/Commit", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
 func (s ShipmentModule) UpdateStatus(ctx context.Context, param ShipmentUpdateParam) (interface{}, *helpers.Error) {
 
-	shipment := models.ShipmentModel{
-		ID:        param.ID,
-		Status:    param.Status,
-		CreatedBy: uuid.FromStringOrNil(ctx.Value("user_id").(string)),
+	courierID := uuid.FromStringOrNil(ctx.Value("user_id").(string))
+
+	shipment, errs := s.courierShipment(ctx, param.ID, courierID, param.Status, "UpdateStatus")
+	if errs != nil {
+		return nil, errs
+	}
+
+	if param.Status == util.ShipmentStatusDelivered {
+		return nil, helpers.ErrorWrap(errors.New("Proof Of Delivery Required"), s.name, "UpdateStatus/Delivered",
+			helpers.ProofRequiredMessage, http.StatusBadRequest)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "UpdateStatus/BeginTx", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	defer tx.Rollback()
+
+	shipmentEvent, errs := s.transit(ctx, tx, shipment, param, courierID, "UpdateStatus")
+	if errs != nil {
+		return nil, errs
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "UpdateStatus/Commit", helpers.InternalServerError,
+			http.StatusInternalServerError)
 	}
 
-	err := shipment.UpdateStatus(ctx, s.db)
+	publish(ctx, s.logger, shipmentEventChannel(shipment.ID), StreamEventStatus, shipmentEvent.Response())
+
+	shipment, err = models.GetOneShipment(ctx, s.db, shipment.ID)
 
 	if err != nil {
-		return nil, helpers.ErrorWrap(err, s.name, "Add/Insert", helpers.InternalServerError,
+		return nil, helpers.ErrorWrap(err, s.name, "UpdateStatus/GetOneShipment", helpers.InternalServerError,
 			http.StatusInternalServerError)
 	}
 
 	response, err := shipment.Response(ctx, s.db, s.logger)
 
 	if err != nil {
-		return nil, helpers.ErrorWrap(err, s.name, "Add/Response", helpers.InternalServerError,
+		return nil, helpers.ErrorWrap(err, s.name, "UpdateStatus/Response", helpers.InternalServerError,
 			http.StatusInternalServerError)
 	}
 
 	return response, nil
 
 }
+
+// courierShipment loads a shipment of the calling courier and checks it may move to status.
+func (s ShipmentModule) courierShipment(ctx context.Context, id, courierID uuid.UUID, status int, caller string) (
+	models.ShipmentModel, *helpers.Error) {
+
+	shipment, err := models.GetOneShipment(ctx, s.db, id)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return shipment, helpers.ErrorWrap(err, s.name, caller+"/GetOneShipment", helpers.BadRequestMessage,
+				http.StatusNotFound)
+		}
+		return shipment, helpers.ErrorWrap(err, s.name, caller+"/GetOneShipment", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	if shipment.IsDelete || shipment.CourierID != courierID {
+		return shipment, helpers.ErrorWrap(errors.New("Not Your Shipment"), s.name, caller+"/CourierID",
+			helpers.ShipmentErrorMessage, http.StatusForbidden)
+	}
+
+	if !util.CanTransitShipmentStatus(shipment.Status, status) {
+		return shipment, helpers.ErrorWrap(errors.New("Invalid Shipment Status Transition"), s.name,
+			caller+"/CanTransitShipmentStatus",
+			fmt.Sprintf(`%s : %s To %s`, helpers.ShipmentStatusMessage, util.GetShipmentStatus(shipment.Status),
+				util.GetShipmentStatus(status)),
+			http.StatusConflict)
+	}
+
+	return shipment, nil
+}
+
+// transit moves the shipment to param.Status inside tx, records the event and completes the order once the
+// last of its shipments is delivered.
+func (s ShipmentModule) transit(ctx context.Context, tx *sql.Tx, shipment models.ShipmentModel,
+	param ShipmentUpdateParam, courierID uuid.UUID, caller string) (models.ShipmentEventModel, *helpers.Error) {
+
+	shipmentUpdate := models.ShipmentModel{
+		ID:     shipment.ID,
+		Status: param.Status,
+		UpdatedBy: uuid.NullUUID{
+			UUID:  courierID,
+			Valid: true,
+		},
+	}
+
+	err := shipmentUpdate.TransitStatus(ctx, tx, shipment.Status)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.ShipmentEventModel{}, helpers.ErrorWrap(err, s.name, caller+"/TransitStatus",
+				helpers.ShipmentStatusMessage, http.StatusConflict)
+		}
+		return models.ShipmentEventModel{}, helpers.ErrorWrap(err, s.name, caller+"/TransitStatus",
+			helpers.InternalServerError, http.StatusInternalServerError)
+	}
+
+	shipmentEvent := models.ShipmentEventModel{
+		ShipmentID: shipment.ID,
+		Status:     param.Status,
+		Latitude:   param.Latitude,
+		Longitude:  param.Longitude,
+		ActorRole:  ctx.Value("role").(string),
+		CreatedBy:  courierID,
+	}
+
+	err = shipmentEvent.Insert(ctx, tx)
+
+	if err != nil {
+		return shipmentEvent, helpers.ErrorWrap(err, s.name, caller+"/ShipmentEventInsert",
+			helpers.InternalServerError, http.StatusInternalServerError)
+	}
+
+	if param.Status == util.ShipmentStatusDelivered {
+		errs := s.completeOrder(ctx, tx, shipment.OrderID)
+		if errs != nil {
+			return shipmentEvent, errs
+		}
+	}
+
+	return shipmentEvent, nil
+}
+
+func (s ShipmentModule) Events(ctx context.Context, param ShipmentDetailParam) (interface{}, *helpers.Error) {
+
+	shipment, errs := s.visibleShipment(ctx, param.ID, "Events")
+	if errs != nil {
+		return nil, errs
+	}
+
+	events, errs := s.events(ctx, shipment.ID, "Events")
+	if errs != nil {
+		return nil, errs
+	}
+
+	return events, nil
+}
+
+func (s ShipmentModule) Tracking(ctx context.Context, param ShipmentDetailParam) (interface{}, *helpers.Error) {
+
+	shipment, errs := s.visibleShipment(ctx, param.ID, "Tracking")
+	if errs != nil {
+		return nil, errs
+	}
+
+	response, err := shipment.Response(ctx, s.db, s.logger)
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Tracking/Response", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	events, errs := s.events(ctx, shipment.ID, "Tracking")
+	if errs != nil {
+		return nil, errs
+	}
+
+	return ShipmentTrackingResponse{
+		Shipment: response,
+		Events:   events,
+	}, nil
+}
+
+// visibleShipment loads a shipment the caller may look at: admins see every shipment, couriers only
+// their own and customers only those of their own orders.
+func (s ShipmentModule) visibleShipment(ctx context.Context, id uuid.UUID, caller string) (
+	models.ShipmentModel, *helpers.Error) {
+
+	userID := uuid.FromStringOrNil(ctx.Value("user_id").(string))
+
+	shipment, err := models.GetOneShipment(ctx, s.db, id)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return shipment, helpers.ErrorWrap(err, s.name, caller+"/GetOneShipment", helpers.BadRequestMessage,
+				http.StatusNotFound)
+		}
+		return shipment, helpers.ErrorWrap(err, s.name, caller+"/GetOneShipment", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	switch ctx.Value("role") {
+	case session.ADMIN_ROLE:
+		return shipment, nil
+	case session.COURIER_ROLE:
+		if shipment.CourierID == userID {
+			return shipment, nil
+		}
+	case session.CUSTOMER_ROLE:
+		order, err := models.GetOneOrder(ctx, s.db, shipment.OrderID)
+
+		if err != nil {
+			return shipment, helpers.ErrorWrap(err, s.name, caller+"/GetOneOrder", helpers.InternalServerError,
+				http.StatusInternalServerError)
+		}
+
+		if order.CustomerID == userID {
+			return shipment, nil
+		}
+	}
+
+	return shipment, helpers.ErrorWrap(errors.New("Not Your Shipment"), s.name, caller+"/Access",
+		helpers.ShipmentErrorMessage, http.StatusForbidden)
+}
+
+func (s ShipmentModule) events(ctx context.Context, shipmentID uuid.UUID, caller string) (
+	[]models.ShipmentEventResponse, *helpers.Error) {
+
+	shipmentEvents, err := models.GetAllShipmentEventByShipmentID(ctx, s.db, shipmentID)
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, caller+"/GetAllShipmentEventByShipmentID",
+			helpers.InternalServerError, http.StatusInternalServerError)
+	}
+
+	var events []models.ShipmentEventResponse
+	for _, shipmentEvent := range shipmentEvents {
+		events = append(events, shipmentEvent.Response())
+	}
+
+	return events, nil
+}
+
+// completeOrder marks the order Completed once every warehouse's shipment for it has been delivered.
+func (s ShipmentModule) completeOrder(ctx context.Context, db helpers.DBExecutor, orderID uuid.UUID) *helpers.Error {
+
+	order, err := models.GetOneOrder(ctx, db, orderID)
+
+	if err != nil {
+		return helpers.ErrorWrap(err, s.name, "completeOrder/GetOneOrder", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	warehouses, err := models.GetAllWarehouseByOrderID(ctx, db, orderID)
+
+	if err != nil {
+		return helpers.ErrorWrap(err, s.name, "completeOrder/GetAllWarehouseByOrderID", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	shipments, err := models.GetAllShipmentByOrderID(ctx, db, orderID)
+
+	if err != nil {
+		return helpers.ErrorWrap(err, s.name, "completeOrder/GetAllShipmentByOrderID", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	delivered := 0
+	for _, shipment := range shipments {
+		if shipment.Status == util.ShipmentStatusDelivered {
+			delivered++
+		}
+	}
+
+	if delivered < len(warehouses) {
+		return nil
+	}
+
+	return NewOrderModule(s.db, s.cache, s.logger).UpdateStatus(ctx, db, order, util.OrderStatusCompleted)
+}
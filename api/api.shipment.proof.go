@@ -0,0 +1,272 @@
+package api
+
+import (
+	"afiqo-location/helpers"
+	"afiqo-location/models"
+	"afiqo-location/storage"
+	"afiqo-location/util"
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	ProofPhoto     = "photo"
+	ProofSignature = "signature"
+)
+
+type (
+	ShipmentProofParam struct {
+		ID            uuid.UUID
+		RecipientName string
+		Latitude      decimal.Decimal
+		Longitude     decimal.Decimal
+		Photo         io.Reader
+		Signature     io.Reader
+	}
+
+	ShipmentProofFileParam struct {
+		ID   uuid.UUID
+		Kind string
+	}
+
+	ShipmentDeliveryResponse struct {
+		Shipment models.ShipmentResponse      `json:"shipment"`
+		Proof    models.ShipmentProofResponse `json:"proof"`
+	}
+)
+
+var errNotAnImage = errors.New("proof of delivery file is not an image")
+
+// Deliver completes a shipment with its proof of delivery. The photo and signature are written to the blob
+// store first and removed again if the shipment cannot be marked delivered.
+func (s ShipmentModule) Deliver(ctx context.Context, param ShipmentProofParam) (interface{}, *helpers.Error) {
+
+	courierID := uuid.FromStringOrNil(ctx.Value("user_id").(string))
+
+	shipment, errs := s.courierShipment(ctx, param.ID, courierID, util.ShipmentStatusDelivered, "Deliver")
+	if errs != nil {
+		return nil, errs
+	}
+
+	recipientName := strings.TrimSpace(param.RecipientName)
+	if recipientName == "" || param.Photo == nil || param.Signature == nil ||
+		(param.Latitude.IsZero() && param.Longitude.IsZero()) {
+		return nil, helpers.ErrorWrap(errors.New("Incomplete Proof Of Delivery"), s.name, "Deliver/Validate",
+			helpers.ProofRequiredMessage, http.StatusBadRequest)
+	}
+
+	store := storage.Store()
+
+	shipmentProof := models.ShipmentProofModel{
+		ShipmentID:    shipment.ID,
+		RecipientName: recipientName,
+		Latitude:      param.Latitude,
+		Longitude:     param.Longitude,
+		CreatedBy:     courierID,
+	}
+
+	var err error
+	shipmentProof.PhotoKey, shipmentProof.PhotoType, err = putProofFile(ctx, store, shipment.ID, ProofPhoto,
+		param.Photo)
+	if err != nil {
+		return nil, proofFileError(err, s.name, "Deliver/PutPhoto")
+	}
+
+	committed := false
+	defer func() {
+		if committed {
+			return
+		}
+		for _, key := range []string{shipmentProof.PhotoKey, shipmentProof.SignatureKey} {
+			if key == "" {
+				continue
+			}
+			if err := store.Delete(ctx, key); err != nil {
+				s.logger.Err.Printf(`api.shipment.proof.go/Deliver/Delete/%v`, err)
+			}
+		}
+	}()
+
+	shipmentProof.SignatureKey, shipmentProof.SignatureType, err = putProofFile(ctx, store, shipment.ID,
+		ProofSignature, param.Signature)
+	if err != nil {
+		return nil, proofFileError(err, s.name, "Deliver/PutSignature")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Deliver/BeginTx", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	defer tx.Rollback()
+
+	err = shipmentProof.Insert(ctx, tx)
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Deliver/ShipmentProofInsert", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	update := ShipmentUpdateParam{
+		ID:        shipment.ID,
+		Status:    util.ShipmentStatusDelivered,
+		Latitude:  decimal.NullDecimal{Decimal: param.Latitude, Valid: true},
+		Longitude: decimal.NullDecimal{Decimal: param.Longitude, Valid: true},
+	}
+
+	shipmentEvent, errs := s.transit(ctx, tx, shipment, update, courierID, "Deliver")
+	if errs != nil {
+		return nil, errs
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Deliver/Commit", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	committed = true
+
+	publish(ctx, s.logger, shipmentEventChannel(shipment.ID), StreamEventStatus, shipmentEvent.Response())
+
+	shipment, err = models.GetOneShipment(ctx, s.db, shipment.ID)
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Deliver/GetOneShipment", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	response, err := shipment.Response(ctx, s.db, s.logger)
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Deliver/Response", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	return ShipmentDeliveryResponse{
+		Shipment: response,
+		Proof:    shipmentProof.Response(),
+	}, nil
+}
+
+// Proof shows the proof of delivery to admins, the delivering courier and the customer who ordered.
+func (s ShipmentModule) Proof(ctx context.Context, param ShipmentDetailParam) (interface{}, *helpers.Error) {
+
+	shipmentProof, errs := s.proof(ctx, param.ID, "Proof")
+	if errs != nil {
+		return nil, errs
+	}
+
+	return shipmentProof.Response(), nil
+}
+
+// ProofFile opens the photo or signature of a proof of delivery, the caller closes it.
+func (s ShipmentModule) ProofFile(ctx context.Context, param ShipmentProofFileParam) (io.ReadCloser, string,
+	*helpers.Error) {
+
+	shipmentProof, errs := s.proof(ctx, param.ID, "ProofFile")
+	if errs != nil {
+		return nil, "", errs
+	}
+
+	var key, contentType string
+	switch param.Kind {
+	case ProofPhoto:
+		key, contentType = shipmentProof.PhotoKey, shipmentProof.PhotoType
+	case ProofSignature:
+		key, contentType = shipmentProof.SignatureKey, shipmentProof.SignatureType
+	default:
+		return nil, "", helpers.ErrorWrap(fmt.Errorf(`unknown proof file %q`, param.Kind), s.name,
+			"ProofFile/Kind", helpers.BadRequestMessage, http.StatusNotFound)
+	}
+
+	file, err := storage.Store().Open(ctx, key)
+
+	if err != nil {
+		if err == storage.ErrBlobNotFound {
+			return nil, "", helpers.ErrorWrap(err, s.name, "ProofFile/Open", helpers.ProofNotFoundMessage,
+				http.StatusNotFound)
+		}
+		return nil, "", helpers.ErrorWrap(err, s.name, "ProofFile/Open", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	return file, contentType, nil
+}
+
+func (s ShipmentModule) proof(ctx context.Context, shipmentID uuid.UUID, caller string) (
+	models.ShipmentProofModel, *helpers.Error) {
+
+	shipment, errs := s.visibleShipment(ctx, shipmentID, caller)
+	if errs != nil {
+		return models.ShipmentProofModel{}, errs
+	}
+
+	shipmentProof, err := models.GetOneShipmentProofByShipmentID(ctx, s.db, shipment.ID)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return shipmentProof, helpers.ErrorWrap(err, s.name, caller+"/GetOneShipmentProofByShipmentID",
+				helpers.ProofNotFoundMessage, http.StatusNotFound)
+		}
+		return shipmentProof, helpers.ErrorWrap(err, s.name, caller+"/GetOneShipmentProofByShipmentID",
+			helpers.InternalServerError, http.StatusInternalServerError)
+	}
+
+	return shipmentProof, nil
+}
+
+// putProofFile sniffs the content type of an uploaded file, refuses anything that is not an image and stores
+// it under a fresh key of the shipment.
+func putProofFile(ctx context.Context, store storage.BlobStore, shipmentID uuid.UUID, kind string,
+	file io.Reader) (string, string, error) {
+
+	reader := bufio.NewReaderSize(file, 512)
+
+	head, err := reader.Peek(512)
+	if err != nil && err != io.EOF {
+		return "", "", err
+	}
+
+	contentType := proofContentType(head)
+	if contentType == "" {
+		return "", "", errNotAnImage
+	}
+
+	key := fmt.Sprintf(`shipments/%s/%s-%s`, shipmentID, kind, uuid.NewV4())
+
+	err = store.Put(ctx, key, reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	return key, contentType, nil
+}
+
+// proofContentType returns the image type of a file from its first bytes, or "" when it is not an image.
+func proofContentType(head []byte) string {
+
+	contentType := http.DetectContentType(head)
+	if !strings.HasPrefix(contentType, "image/") {
+		return ""
+	}
+
+	return contentType
+}
+
+func proofFileError(err error, name, caller string) *helpers.Error {
+
+	if err == errNotAnImage {
+		return helpers.ErrorWrap(err, name, caller, helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	return helpers.ErrorWrap(err, name, caller, helpers.InternalServerError, http.StatusInternalServerError)
+}
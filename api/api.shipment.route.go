@@ -0,0 +1,176 @@
+package api
+
+import (
+	"afiqo-location/helpers"
+	"afiqo-location/maps"
+	"afiqo-location/models"
+	"afiqo-location/util"
+	"context"
+	"database/sql"
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	"net/http"
+	"time"
+)
+
+type (
+	CourierRouteParam struct {
+		CourierID   uuid.UUID `json:"courier_id"`
+		WarehouseID uuid.UUID `json:"warehouse_id"`
+	}
+
+	RouteStop struct {
+		Sequence        int             `json:"sequence"`
+		ShipmentID      uuid.UUID       `json:"shipment_id"`
+		OrderID         uuid.UUID       `json:"order_id"`
+		Status          string          `json:"status"`
+		DeliveryAddress string          `json:"delivery_address"`
+		Latitude        decimal.Decimal `json:"latitude"`
+		Longitude       decimal.Decimal `json:"longitude"`
+		Distance        decimal.Decimal `json:"distance"`
+		ETA             time.Time       `json:"eta"`
+	}
+
+	CourierRouteResponse struct {
+		Warehouse     *models.WarehouseResponse `json:"warehouse"`
+		Stops         []RouteStop               `json:"stops"`
+		TotalDistance decimal.Decimal           `json:"total_distance"`
+		FinishAt      time.Time                 `json:"finish_at"`
+	}
+)
+
+// Route orders the courier's undelivered shipments into a round from the start warehouse. Without a warehouse
+// the one most of the shipments come from is used. Distances are in km.
+func (s ShipmentModule) Route(ctx context.Context, param CourierRouteParam) (interface{}, *helpers.Error) {
+
+	shipments, err := models.GetAllUndeliveredShipmentByCourierID(ctx, s.db, param.CourierID)
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Route/GetAllUndeliveredShipmentByCourierID",
+			helpers.InternalServerError, http.StatusInternalServerError)
+	}
+
+	now := time.Now()
+	response := CourierRouteResponse{
+		Stops:    []RouteStop{},
+		FinishAt: now,
+	}
+
+	if len(shipments) == 0 {
+		return response, nil
+	}
+
+	warehouseID := param.WarehouseID
+	if warehouseID == uuid.Nil {
+		warehouseID = busiestWarehouse(shipments)
+	}
+
+	warehouse, err := models.GetOneWarehouse(ctx, s.db, warehouseID)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, helpers.ErrorWrap(err, s.name, "Route/GetOneWarehouse", helpers.BadRequestMessage,
+				http.StatusNotFound)
+		}
+		return nil, helpers.ErrorWrap(err, s.name, "Route/GetOneWarehouse", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	warehouseResponse := warehouse.Response()
+	response.Warehouse = &warehouseResponse
+
+	locations := []maps.Location{{Latitude: warehouse.Latitude, Longitude: warehouse.Longitude}}
+	orders := make([]models.OrderModel, len(shipments))
+
+	for i, shipment := range shipments {
+		orders[i], err = models.GetOneOrder(ctx, s.db, shipment.OrderID)
+
+		if err != nil {
+			return nil, helpers.ErrorWrap(err, s.name, "Route/GetOneOrder", helpers.InternalServerError,
+				http.StatusInternalServerError)
+		}
+
+		locations = append(locations, maps.Location{Latitude: orders[i].Latitude, Longitude: orders[i].Longitude})
+	}
+
+	tour, matrix := planRoute(ctx, maps.Provider(), locations)
+
+	at := now
+	for i := 1; i < len(tour); i++ {
+		leg := matrix[tour[i-1]][tour[i]]
+		shipment, order := shipments[tour[i]-1], orders[tour[i]-1]
+
+		distance := decimal.NewFromFloat(util.MeterToKilometer(leg.Distance))
+		at = at.Add(time.Duration(leg.Duration) * time.Second)
+
+		response.Stops = append(response.Stops, RouteStop{
+			Sequence:        i,
+			ShipmentID:      shipment.ID,
+			OrderID:         shipment.OrderID,
+			Status:          util.GetShipmentStatus(shipment.Status),
+			DeliveryAddress: order.DeliveryAddress,
+			Latitude:        order.Latitude,
+			Longitude:       order.Longitude,
+			Distance:        distance,
+			ETA:             at,
+		})
+
+		response.TotalDistance = response.TotalDistance.Add(distance)
+		at = at.Add(time.Duration(fulfilment.StopMinutes) * time.Minute)
+	}
+
+	response.FinishAt = at
+
+	return response, nil
+}
+
+// planRoute returns the visiting order over the locations, starting from the first one, and the matrix it
+// was planned on. It falls back to straight-line distances, with a warning, when the distance provider fails.
+func planRoute(ctx context.Context, provider maps.DistanceProvider, locations []maps.Location) (
+	[]int, [][]maps.Route) {
+
+	var matrix [][]maps.Route
+	err := errNoDistanceProvider
+	if provider != nil {
+		matrix, err = maps.Matrix(ctx, provider, locations)
+	}
+
+	if err != nil {
+		if logger != nil && err != errNoDistanceProvider {
+			logger.Err.Printf(`api.shipment.route.go/planRoute/Matrix/falling back to straight-line distances/%v`, err)
+		}
+
+		matrix, _ = maps.Matrix(ctx, maps.HaversineProvider{}, locations)
+	}
+
+	cost := make([][]int, len(matrix))
+	for i, routes := range matrix {
+		cost[i] = make([]int, len(routes))
+		for j, route := range routes {
+			cost[i][j] = route.Distance
+			if fulfilment.RankBy == RankByDuration {
+				cost[i][j] = route.Duration
+			}
+		}
+	}
+
+	return maps.Tour(cost), matrix
+}
+
+// busiestWarehouse picks the warehouse most of the shipments are collected from, the earliest one on a tie.
+func busiestWarehouse(shipments []models.ShipmentModel) uuid.UUID {
+
+	count := make(map[uuid.UUID]int)
+	for _, shipment := range shipments {
+		count[shipment.WarehouseID]++
+	}
+
+	busiest := shipments[0].WarehouseID
+	for _, shipment := range shipments {
+		if count[shipment.WarehouseID] > count[busiest] {
+			busiest = shipment.WarehouseID
+		}
+	}
+
+	return busiest
+}
@@ -0,0 +1,227 @@
+package api
+
+import (
+	"afiqo-location/helpers"
+	"afiqo-location/models"
+	"afiqo-location/util"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gomodule/redigo/redis"
+	uuid "github.com/satori/go.uuid"
+	"net/http"
+	"time"
+)
+
+const (
+	SHIPMENT_EVENT = "SHIPMENT_EVENT"
+
+	StreamEventTracking = "tracking"
+	StreamEventStatus   = "status"
+	StreamEventLocation = "location"
+	StreamEventCourier  = "courier"
+	StreamEventPing     = "ping"
+)
+
+const streamHeartbeat = 15 * time.Second
+
+type (
+	ShipmentStreamParam struct {
+		ID uuid.UUID `json:"id"`
+	}
+
+	// StreamMessage is what travels over Redis pub/sub and is written out as one server-sent event.
+	StreamMessage struct {
+		Event string          `json:"event"`
+		Data  json.RawMessage `json:"data"`
+	}
+
+	StreamSender func(message StreamMessage) error
+)
+
+func shipmentEventChannel(shipmentID uuid.UUID) string {
+	return fmt.Sprintf(`%s:%s`, SHIPMENT_EVENT, shipmentID)
+}
+
+// publish fans a message out to every server instance; a failed publish only costs live listeners an update.
+func publish(ctx context.Context, log *helpers.Logger, channel, event string, data interface{}) {
+
+	payload, err := json.Marshal(data)
+	if err == nil {
+		payload, err = json.Marshal(StreamMessage{Event: event, Data: payload})
+	}
+
+	if err == nil {
+		err = helpers.PublishToCache(ctx, channel, string(payload))
+	}
+
+	if err != nil {
+		log.Err.Printf(`api.shipment.stream.go/publish/%s/%v`, channel, err)
+	}
+}
+
+// Stream sends the shipment's tracking view and then every status change and courier position until the
+// shipment is delivered or the client goes away. A shipment handed to another courier switches the stream to
+// the new courier's position. Errors are only returned before anything has been sent.
+func (s ShipmentModule) Stream(ctx context.Context, param ShipmentStreamParam, send StreamSender) *helpers.Error {
+
+	shipment, errs := s.visibleShipment(ctx, param.ID, "Stream")
+	if errs != nil {
+		return errs
+	}
+
+	conn := redis.PubSubConn{Conn: s.cache.Get()}
+	defer conn.Close()
+
+	courierID := shipment.CourierID
+
+	err := conn.Subscribe(shipmentEventChannel(shipment.ID), courierLocationKey(courierID))
+
+	if err != nil {
+		return helpers.ErrorWrap(err, s.name, "Stream/Subscribe", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	// Subscribe before taking the snapshot so nothing published in between is lost.
+	tracking, errs := s.Tracking(ctx, ShipmentDetailParam{ID: shipment.ID})
+	if errs != nil {
+		return errs
+	}
+
+	data, err := json.Marshal(tracking)
+
+	if err != nil {
+		return helpers.ErrorWrap(err, s.name, "Stream/Marshal", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	err = send(StreamMessage{Event: StreamEventTracking, Data: data})
+	if err != nil || shipment.Status == util.ShipmentStatusDelivered {
+		return nil
+	}
+
+	// A receive timeout closes a redigo connection, so replies are read in the background and the
+	// heartbeat is driven by a ticker instead. The reader stops once every channel is unsubscribed or the
+	// connection fails.
+	replies := make(chan interface{})
+
+	go func() {
+		defer close(replies)
+		for {
+			reply := conn.Receive()
+			replies <- reply
+
+			switch reply := reply.(type) {
+			case error:
+				return
+			case redis.Subscription:
+				if reply.Count == 0 {
+					return
+				}
+			}
+		}
+	}()
+
+	// Closing a subscribed connection reads from it too, so the reader has to be gone first.
+	defer func() {
+		conn.Unsubscribe()
+		for range replies {
+		}
+	}()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case <-heartbeat.C:
+			err = conn.Ping("")
+			if err == nil {
+				err = send(StreamMessage{Event: StreamEventPing})
+			}
+			if err != nil {
+				return nil
+			}
+
+		case reply, ok := <-replies:
+			if !ok {
+				return nil
+			}
+
+			switch reply := reply.(type) {
+			case redis.Message:
+				// A position the old courier sent before the switch can still be on its way.
+				if reply.Channel != shipmentEventChannel(shipment.ID) && reply.Channel != courierLocationKey(courierID) {
+					continue
+				}
+
+				var message StreamMessage
+
+				err = json.Unmarshal(reply.Data, &message)
+				if err != nil {
+					s.logger.Err.Printf(`api.shipment.stream.go/Stream/Unmarshal/%v`, err)
+					continue
+				}
+
+				err = send(message)
+				if err != nil {
+					return nil
+				}
+
+				if message.Event == StreamEventCourier {
+					var courier models.CourierResponse
+					if json.Unmarshal(message.Data, &courier) == nil && courier.ID != courierID {
+						err = s.followCourier(ctx, conn, courierID, courier.ID, send)
+						if err != nil {
+							return nil
+						}
+						courierID = courier.ID
+					}
+				}
+
+				if message.Event == StreamEventStatus {
+					var event models.ShipmentEventResponse
+					if json.Unmarshal(message.Data, &event) == nil &&
+						event.Status == util.GetShipmentStatus(util.ShipmentStatusDelivered) {
+						return nil
+					}
+				}
+
+			case error:
+				s.logger.Err.Printf(`api.shipment.stream.go/Stream/Receive/%v`, reply)
+				return nil
+			}
+		}
+	}
+}
+
+// followCourier moves the stream from one courier's position to another's and sends the new courier's last
+// known position, if any, so the map does not wait for their next fix. The shipment channel stays subscribed
+// throughout, which keeps the reader running.
+func (s ShipmentModule) followCourier(ctx context.Context, conn redis.PubSubConn, from, to uuid.UUID,
+	send StreamSender) error {
+
+	err := conn.Subscribe(courierLocationKey(to))
+	if err == nil {
+		err = conn.Unsubscribe(courierLocationKey(from))
+	}
+	if err != nil {
+		s.logger.Err.Printf(`api.shipment.stream.go/followCourier/%v`, err)
+		return err
+	}
+
+	location, err := NewCourierLocationModule(s.db, s.cache, s.logger).cachedLocation(ctx, to)
+	if err != nil {
+		return nil
+	}
+
+	data, err := json.Marshal(location)
+	if err != nil {
+		return nil
+	}
+
+	return send(StreamMessage{Event: StreamEventLocation, Data: data})
+}
@@ -223,3 +223,28 @@ func (s StockModule) Update(ctx context.Context, param StockUpdateParam) (interf
 	return response, nil
 
 }
+
+// syncProductStock sets product.stock to the product's total stock across every warehouse.
+func syncProductStock(ctx context.Context, db helpers.DBExecutor, productID, userID uuid.UUID) error {
+
+	stocks, err := models.GetAllStockByProductID(ctx, db, productID)
+	if err != nil {
+		return err
+	}
+
+	var totalStock uint
+	for _, stock := range stocks {
+		totalStock = totalStock + stock.Stock
+	}
+
+	productStock := models.ProductModel{
+		ID:    productID,
+		Stock: totalStock,
+		UpdatedBy: uuid.NullUUID{
+			UUID:  userID,
+			Valid: true,
+		},
+	}
+
+	return productStock.StockUpdate(ctx, db)
+}
@@ -209,7 +209,15 @@ func (s SupplierModule) Add(ctx context.Context, param SupplierAddParam) (interf
 		CreatedBy: uuid.FromStringOrNil(ctx.Value("user_id").(string)),
 	}
 
-	err := supplier.Insert(ctx, s.db)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Add/BeginTx", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	defer tx.Rollback()
+
+	err = supplier.Insert(ctx, tx)
 	if err != nil {
 		return nil, helpers.ErrorWrap(err, s.name, "Add/Insert", helpers.InternalServerError,
 			http.StatusInternalServerError)
@@ -239,9 +247,17 @@ func (s SupplierModule) Add(ctx context.Context, param SupplierAddParam) (interf
 		To:      supplier.Email,
 	}
 
-	go func() {
-		mail.SendEmail()
-	}()
+	err = queueMail(ctx, tx, mail)
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Add/queueMail", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, s.name, "Add/Commit", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
 
 	return supplier.Response(), nil
 }
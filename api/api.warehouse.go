@@ -2,9 +2,12 @@ package api
 
 import (
 	"afiqo-location/helpers"
+	"afiqo-location/maps"
 	"afiqo-location/models"
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"github.com/gomodule/redigo/redis"
 	uuid "github.com/satori/go.uuid"
 	"github.com/shopspring/decimal"
@@ -24,18 +27,24 @@ type (
 	}
 
 	WarehouseAddParam struct {
-		Name      string          `json:"name" validate:"required"`
-		Address   string          `json:"address" validate:"required"`
-		PhoneNo   string          `json:"phone_no" validate:"required"`
-		Latitude  decimal.Decimal `json:"latitude" validate:"required"`
-		Longitude decimal.Decimal `json:"longitude" validate:"required"`
+		Name          string          `json:"name" validate:"required"`
+		Address       string          `json:"address" validate:"required"`
+		PhoneNo       string          `json:"phone_no" validate:"required"`
+		Latitude      decimal.Decimal `json:"latitude"`
+		Longitude     decimal.Decimal `json:"longitude"`
+		ServiceRadius decimal.Decimal `json:"service_radius"`
+		ServiceArea   json.RawMessage `json:"service_area"`
 	}
 
 	WarehouseUpdateParam struct {
-		ID      uuid.UUID `json:"id"`
-		Name    string    `json:"name" validate:"max=20,min=4,required"`
-		Address string    `json:"address" validate:"required"`
-		PhoneNo string    `json:"phone_no" validate:"required"`
+		ID            uuid.UUID       `json:"id"`
+		Name          string          `json:"name" validate:"max=20,min=4,required"`
+		Address       string          `json:"address" validate:"required"`
+		PhoneNo       string          `json:"phone_no" validate:"required"`
+		Latitude      decimal.Decimal `json:"latitude"`
+		Longitude     decimal.Decimal `json:"longitude"`
+		ServiceRadius decimal.Decimal `json:"service_radius"`
+		ServiceArea   json.RawMessage `json:"service_area"`
 	}
 
 	WarehouseDeleteParam struct {
@@ -82,16 +91,30 @@ func (s WarehouseModule) List(ctx context.Context, filter helpers.Filter) (inter
 
 func (s WarehouseModule) Add(ctx context.Context, param WarehouseAddParam) (interface{}, *helpers.Error) {
 
+	serviceArea, errs := s.serviceArea(param.ServiceRadius, param.ServiceArea, "Add")
+	if errs != nil {
+		return nil, errs
+	}
+
+	location, mismatch, err := locate(ctx, maps.AddressGeocoder(), param.Address, param.Latitude, param.Longitude)
+
+	if err != nil {
+		return nil, locateError(err, s.name, "Add")
+	}
+
 	warehouse := models.WarehouseModel{
-		Name:      param.Name,
-		Address:   param.Address,
-		PhoneNo:   param.PhoneNo,
-		Latitude:  param.Latitude,
-		Longitude: param.Longitude,
-		CreatedBy: uuid.FromStringOrNil(ctx.Value("user_id").(string)),
+		Name:            param.Name,
+		Address:         param.Address,
+		PhoneNo:         param.PhoneNo,
+		Latitude:        location.Latitude,
+		Longitude:       location.Longitude,
+		AddressMismatch: mismatch,
+		ServiceRadius:   param.ServiceRadius,
+		ServiceArea:     serviceArea,
+		CreatedBy:       uuid.FromStringOrNil(ctx.Value("user_id").(string)),
 	}
 
-	err := warehouse.Insert(ctx, s.db)
+	err = warehouse.Insert(ctx, s.db)
 
 	if err != nil {
 		return nil, helpers.ErrorWrap(err, s.name, "Add/Insert", helpers.InternalServerError,
@@ -102,18 +125,51 @@ func (s WarehouseModule) Add(ctx context.Context, param WarehouseAddParam) (inte
 
 func (s WarehouseModule) Update(ctx context.Context, param WarehouseUpdateParam) (interface{}, *helpers.Error) {
 
+	serviceArea, errs := s.serviceArea(param.ServiceRadius, param.ServiceArea, "Update")
+	if errs != nil {
+		return nil, errs
+	}
+
+	current, err := models.GetOneWarehouse(ctx, s.db, param.ID)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, helpers.ErrorWrap(err, s.name, "Update/GetOneWarehouse", helpers.BadRequestMessage,
+				http.StatusNotFound)
+		}
+		return nil, helpers.ErrorWrap(err, s.name, "Update/GetOneWarehouse", helpers.InternalServerError,
+			http.StatusInternalServerError)
+	}
+
+	// Coordinates that are not sent stay as they are unless the address moved, then they are geocoded again.
+	latitude, longitude := param.Latitude, param.Longitude
+	if latitude.IsZero() && longitude.IsZero() && param.Address == current.Address {
+		latitude, longitude = current.Latitude, current.Longitude
+	}
+
+	location, mismatch, err := locate(ctx, maps.AddressGeocoder(), param.Address, latitude, longitude)
+
+	if err != nil {
+		return nil, locateError(err, s.name, "Update")
+	}
+
 	warehouse := models.WarehouseModel{
-		ID:      param.ID,
-		Name:    param.Name,
-		Address: param.Address,
-		PhoneNo: param.PhoneNo,
+		ID:              param.ID,
+		Name:            param.Name,
+		Address:         param.Address,
+		PhoneNo:         param.PhoneNo,
+		Latitude:        location.Latitude,
+		Longitude:       location.Longitude,
+		AddressMismatch: mismatch,
+		ServiceRadius:   param.ServiceRadius,
+		ServiceArea:     serviceArea,
 		UpdatedBy: uuid.NullUUID{
 			UUID:  uuid.FromStringOrNil(ctx.Value("user_id").(string)),
 			Valid: true,
 		},
 	}
 
-	err := warehouse.Update(ctx, s.db)
+	err = warehouse.Update(ctx, s.db)
 	if err != nil {
 		return nil, helpers.ErrorWrap(err, s.name, "Update/Update", helpers.InternalServerError,
 			http.StatusInternalServerError)
@@ -142,3 +198,26 @@ func (s WarehouseModule) Delete(ctx context.Context, param WarehouseDeleteParam)
 	return nil, nil
 
 }
+
+// serviceArea checks a warehouse's service area before it is stored. An absent or null area is stored as NULL.
+func (s WarehouseModule) serviceArea(radius decimal.Decimal, area json.RawMessage, caller string) (
+	sql.NullString, *helpers.Error) {
+
+	if radius.IsNegative() {
+		return sql.NullString{}, helpers.ErrorWrap(errors.New("Negative Service Radius"), s.name,
+			caller+"/serviceArea", helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	if len(area) == 0 || string(area) == "null" {
+		return sql.NullString{}, nil
+	}
+
+	_, err := maps.ParseArea(area)
+
+	if err != nil {
+		return sql.NullString{}, helpers.ErrorWrap(err, s.name, caller+"/ParseArea", helpers.BadRequestMessage,
+			http.StatusBadRequest)
+	}
+
+	return sql.NullString{String: string(area), Valid: true}, nil
+}
@@ -18,10 +18,12 @@ package cmd
 import (
 	"afiqo-location/api"
 	email2 "afiqo-location/email"
+	"afiqo-location/gateway"
 	"afiqo-location/helpers"
 	maps2 "afiqo-location/maps"
 	"afiqo-location/middleware"
 	"afiqo-location/routers"
+	"afiqo-location/storage"
 	"context"
 	"database/sql"
 	"fmt"
@@ -31,6 +33,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -54,6 +57,14 @@ var rootCmd = &cobra.Command{
 		initLogger()
 		initMaps()
 		initMail()
+		initFulfilment()
+		initCart()
+		initGeocoding()
+		initDispatch()
+		initStorage()
+		initGateway()
+		initPaymentExpiry()
+		initEmailOutbox()
 		api.Init(dbPool, cachePool, logger)
 		helpers.Init(logger, cachePool)
 		routers.Init(dbPool, cachePool, logger)
@@ -71,6 +82,18 @@ var rootCmd = &cobra.Command{
 			Handler:      router,
 		}
 
+		workerCtx, stopWorkers := context.WithCancel(context.Background())
+		var workers sync.WaitGroup
+		workers.Add(2)
+		go func() {
+			defer workers.Done()
+			api.NewPaymentModule(dbPool, cachePool, logger).ExpirePayments(workerCtx)
+		}()
+		go func() {
+			defer workers.Done()
+			api.NewEmailModule(dbPool, cachePool, logger).DeliverOutbox(workerCtx)
+		}()
+
 		idleConnsClosed := make(chan struct{})
 		go func() {
 			sigint := make(chan os.Signal, 1)
@@ -82,6 +105,9 @@ var rootCmd = &cobra.Command{
 			if err := server.Shutdown(ctx); err != nil {
 				logger.Out.WithError(err).Println("Server shutdown error.")
 			}
+			stopWorkers()
+			workers.Wait()
+			logger.Out.Println("Background workers stopped.")
 			logger.Out.Println("Core server shutdown.")
 			close(idleConnsClosed)
 		}()
@@ -193,10 +219,21 @@ func initLogger() {
 
 func initMaps() {
 	maps := maps2.Maps{
-		URL:    viper.GetString("maps.distance_url"),
-		ApiKey: viper.GetString("maps.api_key"),
+		Provider:        viper.GetString("maps.provider"),
+		URL:             viper.GetString("maps.distance_url"),
+		ApiKey:          viper.GetString("maps.api_key"),
+		Profile:         viper.GetString("maps.profile"),
+		SpeedKmH:        viper.GetFloat64("maps.speed_kmh"),
+		Geocoder:        viper.GetString("maps.geocoder"),
+		GeocoderFixture: viper.GetString("maps.geocoder_fixture"),
+	}
+
+	err := maps2.Init(maps)
+
+	if err != nil {
+		logger.Err.Println(fmt.Sprintf("err maps : %v", err))
+		os.Exit(0)
 	}
-	maps2.Init(maps)
 }
 
 func initMail() {
@@ -208,3 +245,84 @@ func initMail() {
 	}
 	mail.Init()
 }
+
+func initFulfilment() {
+	fulfilment := api.Fulfilment{
+		MaxWarehouses:   viper.GetInt("fulfilment.max_warehouses"),
+		Shortlist:       viper.GetInt("fulfilment.shortlist"),
+		RankBy:          viper.GetString("fulfilment.rank_by"),
+		HandlingMinutes: viper.GetInt("fulfilment.handling_minutes"),
+		ServiceRadius:   viper.GetFloat64("fulfilment.service_radius"),
+		StopMinutes:     viper.GetInt("fulfilment.stop_minutes"),
+	}
+	api.InitFulfilment(fulfilment)
+}
+
+func initCart() {
+	cart := api.Cart{
+		TTL: viper.GetInt("cart.ttl"),
+	}
+	api.InitCart(cart)
+}
+
+func initGeocoding() {
+	geocoding := api.Geocoding{
+		MismatchMetres: viper.GetFloat64("maps.mismatch_metres"),
+	}
+	api.InitGeocoding(geocoding)
+}
+
+func initDispatch() {
+	dispatch := api.Dispatch{
+		MaxLoad: viper.GetInt("dispatch.max_load"),
+	}
+	api.InitDispatch(dispatch)
+}
+
+func initStorage() {
+	blobStorage := storage.Storage{
+		Driver: viper.GetString("storage.driver"),
+		Path:   viper.GetString("storage.path"),
+	}
+
+	err := storage.Init(blobStorage)
+
+	if err != nil {
+		logger.Err.Println(fmt.Sprintf("err storage : %v", err))
+		os.Exit(0)
+	}
+}
+
+func initGateway() {
+	paymentGateway := gateway.Gateway{
+		Provider: viper.GetString("gateway.provider"),
+		Secret:   viper.GetString("gateway.secret"),
+		Currency: viper.GetString("gateway.currency"),
+	}
+
+	err := gateway.Init(paymentGateway)
+
+	if err != nil {
+		logger.Err.Println(fmt.Sprintf("err gateway : %v", err))
+		os.Exit(0)
+	}
+}
+
+func initPaymentExpiry() {
+	paymentExpiry := api.PaymentExpiry{
+		WindowMinutes:   viper.GetInt("payment.window_minutes"),
+		IntervalSeconds: viper.GetInt("payment.expiry_interval"),
+		BatchSize:       viper.GetInt("payment.expiry_batch"),
+	}
+	api.InitPaymentExpiry(paymentExpiry)
+}
+
+func initEmailOutbox() {
+	emailOutbox := api.EmailOutbox{
+		IntervalSeconds: viper.GetInt("mail.outbox_interval"),
+		MaxAttempts:     viper.GetInt("mail.max_attempts"),
+		BackoffSeconds:  viper.GetInt("mail.backoff"),
+		BatchSize:       viper.GetInt("mail.outbox_batch"),
+	}
+	api.InitEmailOutbox(emailOutbox)
+}
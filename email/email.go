@@ -3,7 +3,6 @@ package email
 import (
 	"github.com/matcornic/hermes/v2"
 	"gopkg.in/gomail.v2"
-	"log"
 )
 
 type Mail struct {
@@ -195,7 +194,9 @@ func (b MailData) GenerateForReceipt() (string, error) {
 	return emailBody, err
 }
 
-func (m Mail) SendEmail() {
+// SendEmail delivers the mail over SMTP. Callers queue mail in the outbox rather than sending it themselves,
+// so a failed delivery is retried instead of lost.
+func (m Mail) SendEmail() error {
 
 	mailer := gomail.NewMessage()
 	mailer.SetHeader("From", email)
@@ -210,10 +211,5 @@ func (m Mail) SendEmail() {
 		password,
 	)
 
-	err := dialer.DialAndSend(mailer)
-	if err != nil {
-		log.Fatal(err.Error())
-	}
-
-	log.Println("Mail sent!")
+	return dialer.DialAndSend(mailer)
 }
@@ -0,0 +1,90 @@
+package gateway
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	"sync"
+)
+
+// FakeGateway settles nothing. It remembers its intents in memory and signs webhooks with an HMAC-SHA256 of
+// the body under Secret, for tests and local runs.
+type FakeGateway struct {
+	Secret string
+
+	mutex   sync.Mutex
+	intents map[string]decimal.Decimal
+	refunds map[string]decimal.Decimal
+}
+
+func (g *FakeGateway) CreateIntent(ctx context.Context, intent Intent) (IntentResult, error) {
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.intents == nil {
+		g.intents = make(map[string]decimal.Decimal)
+	}
+
+	reference := fmt.Sprintf(`fake_pi_%s`, uuid.NewV4())
+	g.intents[reference] = intent.Amount
+
+	return IntentResult{
+		Reference:    reference,
+		ClientSecret: fmt.Sprintf(`%s_secret`, reference),
+	}, nil
+}
+
+func (g *FakeGateway) VerifyWebhook(payload []byte, signature string) (Event, error) {
+
+	expected, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, g.sign(payload)) {
+		return Event{}, ErrInvalidSignature
+	}
+
+	var event Event
+	err = json.Unmarshal(payload, &event)
+	if err != nil {
+		return Event{}, err
+	}
+
+	return event, nil
+}
+
+// Refund gives back part or all of an intent. Intents made before a restart are unknown to the fake and are
+// refunded without checks.
+func (g *FakeGateway) Refund(ctx context.Context, refund Refund) (RefundResult, error) {
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.refunds == nil {
+		g.refunds = make(map[string]decimal.Decimal)
+	}
+
+	if amount, ok := g.intents[refund.Reference]; ok {
+		if g.refunds[refund.Reference].Add(refund.Amount).GreaterThan(amount) {
+			return RefundResult{}, fmt.Errorf(`refund of %s exceeds intent %s`, refund.Amount, refund.Reference)
+		}
+	}
+
+	g.refunds[refund.Reference] = g.refunds[refund.Reference].Add(refund.Amount)
+
+	return RefundResult{Reference: fmt.Sprintf(`fake_re_%s`, uuid.NewV4())}, nil
+}
+
+// Sign returns the signature the fake expects on a webhook body, for simulating the gateway.
+func (g *FakeGateway) Sign(payload []byte) string {
+	return hex.EncodeToString(g.sign(payload))
+}
+
+func (g *FakeGateway) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(g.Secret))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,65 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/shopspring/decimal"
+	"testing"
+)
+
+func TestFakeGateway(t *testing.T) {
+
+	ctx := context.Background()
+	fake := &FakeGateway{Secret: "whsec"}
+
+	intent, err := fake.CreateIntent(ctx, Intent{PaymentID: "p1", Amount: decimal.NewFromInt(50), Currency: "MYR"})
+	if err != nil || intent.Reference == "" {
+		t.Fatalf("CreateIntent = %v, %v", intent, err)
+	}
+
+	payload, _ := json.Marshal(Event{ID: "evt_1", Type: EventPaymentSucceeded, Reference: intent.Reference,
+		Amount: decimal.NewFromInt(50)})
+
+	event, err := fake.VerifyWebhook(payload, fake.Sign(payload))
+	if err != nil || event.Reference != intent.Reference || event.Type != EventPaymentSucceeded {
+		t.Fatalf("VerifyWebhook = %v, %v", event, err)
+	}
+
+	_, err = fake.VerifyWebhook(payload, (&FakeGateway{Secret: "other"}).Sign(payload))
+	if err != ErrInvalidSignature {
+		t.Fatalf("foreign signature err = %v, want ErrInvalidSignature", err)
+	}
+
+	_, err = fake.VerifyWebhook(append(payload, ' '), fake.Sign(payload))
+	if err != ErrInvalidSignature {
+		t.Fatalf("tampered body err = %v, want ErrInvalidSignature", err)
+	}
+
+	_, err = fake.Refund(ctx, Refund{Reference: intent.Reference, Amount: decimal.NewFromInt(30)})
+	if err != nil {
+		t.Fatalf("Refund = %v", err)
+	}
+
+	_, err = fake.Refund(ctx, Refund{Reference: intent.Reference, Amount: decimal.NewFromInt(30)})
+	if err == nil {
+		t.Fatal("refunding more than the intent should fail")
+	}
+}
+
+func TestNewPaymentGateway(t *testing.T) {
+
+	_, err := NewPaymentGateway(Gateway{Provider: ProviderFake})
+	if err != ErrMissingSecret {
+		t.Fatalf("missing secret: %v", err)
+	}
+
+	_, err = NewPaymentGateway(Gateway{Secret: "whsec"})
+	if err == nil {
+		t.Fatal("no provider chose a gateway")
+	}
+
+	_, err = NewPaymentGateway(Gateway{Provider: ProviderFake, Secret: "whsec"})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,118 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	ProviderFake = "fake"
+
+	EventPaymentSucceeded = "payment.succeeded"
+	EventPaymentFailed    = "payment.failed"
+
+	defaultCurrency = "MYR"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrMissingSecret    = errors.New("payment gateway secret is not set")
+)
+
+type (
+	Gateway struct {
+		Provider string
+		Secret   string
+		Currency string
+	}
+
+	// Intent asks the gateway to collect an amount for one payment.
+	Intent struct {
+		PaymentID string
+		Amount    decimal.Decimal
+		Currency  string
+	}
+
+	// IntentResult identifies the intent at the gateway. The client secret is handed to the customer's app
+	// to complete the payment with the gateway directly.
+	IntentResult struct {
+		Reference    string
+		ClientSecret string
+	}
+
+	Refund struct {
+		Reference string
+		Amount    decimal.Decimal
+		Reason    string
+	}
+
+	RefundResult struct {
+		Reference string
+	}
+
+	// Event is a webhook notification about an intent, already checked to come from the gateway.
+	Event struct {
+		ID        string          `json:"id"`
+		Type      string          `json:"type"`
+		Reference string          `json:"reference"`
+		Amount    decimal.Decimal `json:"amount"`
+		Reason    string          `json:"reason,omitempty"`
+	}
+
+	PaymentGateway interface {
+		CreateIntent(ctx context.Context, intent Intent) (IntentResult, error)
+		// VerifyWebhook checks the signature of a webhook body and decodes it, failing with
+		// ErrInvalidSignature when the body was not signed by the gateway.
+		VerifyWebhook(payload []byte, signature string) (Event, error)
+		Refund(ctx context.Context, refund Refund) (RefundResult, error)
+	}
+)
+
+var (
+	currency       string
+	paymentGateway PaymentGateway
+)
+
+// Init chooses the payment gateway. The provider must be named explicitly and a secret given, without one any
+// caller could sign webhooks.
+func Init(gateway Gateway) error {
+
+	created, err := NewPaymentGateway(gateway)
+	if err != nil {
+		return err
+	}
+
+	paymentGateway = created
+
+	currency = gateway.Currency
+	if currency == "" {
+		currency = defaultCurrency
+	}
+
+	return nil
+}
+
+func NewPaymentGateway(gateway Gateway) (PaymentGateway, error) {
+	if gateway.Secret == "" {
+		return nil, ErrMissingSecret
+	}
+
+	switch gateway.Provider {
+	case ProviderFake:
+		return &FakeGateway{Secret: gateway.Secret}, nil
+	}
+
+	return nil, fmt.Errorf(`unknown payment gateway %q`, gateway.Provider)
+}
+
+// Provider returns the payment gateway chosen in Init.
+func Provider() PaymentGateway {
+	return paymentGateway
+}
+
+// Currency returns the currency payments are charged in.
+func Currency() string {
+	return currency
+}
@@ -57,13 +57,10 @@ func ConnectToCache(cacheOptions CacheOptions) *redis.Pool {
 }
 
 func SetDataToCache(ctx context.Context, id, value string) error {
-	conn, err := cachePool.Dial()
+	conn := cachePool.Get()
+	defer conn.Close()
 
-	if err != nil {
-		return err
-	}
-
-	_, err = conn.Do("SET", id, value)
+	_, err := conn.Do("SET", id, value)
 	if err != nil {
 		return err
 	}
@@ -72,13 +69,10 @@ func SetDataToCache(ctx context.Context, id, value string) error {
 }
 
 func SetDataToCacheWithExpiry(ctx context.Context, id, value string, expiryTime int) error {
-	conn, err := cachePool.Dial()
-
-	if err != nil {
-		return err
-	}
+	conn := cachePool.Get()
+	defer conn.Close()
 
-	_, err = conn.Do("SETEX", id, strconv.Itoa(expiryTime), value)
+	_, err := conn.Do("SETEX", id, strconv.Itoa(expiryTime), value)
 
 	if err != nil {
 		return err
@@ -88,11 +82,8 @@ func SetDataToCacheWithExpiry(ctx context.Context, id, value string, expiryTime
 }
 
 func GetDataFromCache(ctx context.Context, id string) (string, error) {
-	conn, err := cachePool.Dial()
-
-	if err != nil {
-		return "", err
-	}
+	conn := cachePool.Get()
+	defer conn.Close()
 
 	data, err := redis.String(conn.Do("GET", id))
 	if err != nil {
@@ -104,11 +95,8 @@ func GetDataFromCache(ctx context.Context, id string) (string, error) {
 }
 
 func GetKeysFromCache(ctx context.Context) ([]string, error) {
-	conn, err := cachePool.Dial()
-
-	if err != nil {
-		return nil, err
-	}
+	conn := cachePool.Get()
+	defer conn.Close()
 
 	data, err := redis.Strings(conn.Do("KEYS", "*"))
 	if err != nil {
@@ -120,11 +108,8 @@ func GetKeysFromCache(ctx context.Context) ([]string, error) {
 }
 
 func GetKeysFromCacheWithPrefix(ctx context.Context, prefix string) ([]string, error) {
-	conn, err := cachePool.Dial()
-
-	if err != nil {
-		return nil, err
-	}
+	conn := cachePool.Get()
+	defer conn.Close()
 
 	data, err := redis.Strings(conn.Do("KEYS", fmt.Sprintf(`%s*`, prefix)))
 	if err != nil {
@@ -136,12 +121,10 @@ func GetKeysFromCacheWithPrefix(ctx context.Context, prefix string) ([]string, e
 }
 
 func SetCacheExpiry(ctx context.Context, id string, expiryTime int) error {
-	conn, err := cachePool.Dial()
+	conn := cachePool.Get()
+	defer conn.Close()
 
-	if err != nil {
-		return err
-	}
-	_, err = conn.Do("EXPIRE", id, strconv.Itoa(expiryTime))
+	_, err := conn.Do("EXPIRE", id, strconv.Itoa(expiryTime))
 	if err != nil {
 		return err
 	}
@@ -150,12 +133,22 @@ func SetCacheExpiry(ctx context.Context, id string, expiryTime int) error {
 }
 
 func DeleteCache(ctx context.Context, id string) error {
-	conn, err := cachePool.Dial()
+	conn := cachePool.Get()
+	defer conn.Close()
 
+	_, err := conn.Do("DEL", id)
 	if err != nil {
 		return err
 	}
-	_, err = conn.Do("DEL", id)
+
+	return nil
+}
+
+func PublishToCache(ctx context.Context, channel, value string) error {
+	conn := cachePool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("PUBLISH", channel, value)
 	if err != nil {
 		return err
 	}
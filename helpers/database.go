@@ -1,6 +1,7 @@
 package helpers
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 )
@@ -35,3 +36,10 @@ func InitDB(options DBOptions) (*sql.DB, error) {
 	}
 	return db, nil
 }
+
+// DBExecutor is satisfied by both *sql.DB and *sql.Tx so model methods can run inside a transaction.
+type DBExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
@@ -44,11 +44,23 @@ func ErrorResponse(w http.ResponseWriter, message string, status int) {
 }
 
 const (
-	InternalServerError      = "Internal Server Error"
-	BadRequestMessage        = "Bad Request"
-	UnauthorizedMessage      = "Unauthorized"
-	ForbiddenMessage         = "Forbidden Message"
-	IncorrectEmailMessage    = "Incorrect Email"
-	IncorrectPasswordMessage = "Incorrect Password"
-	OrderErrorMessage        = "Not Your Order"
+	InternalServerError        = "Internal Server Error"
+	BadRequestMessage          = "Bad Request"
+	UnauthorizedMessage        = "Unauthorized"
+	ForbiddenMessage           = "Forbidden Message"
+	IncorrectEmailMessage      = "Incorrect Email"
+	IncorrectPasswordMessage   = "Incorrect Password"
+	OrderErrorMessage          = "Not Your Order"
+	InsufficientStockMessage   = "Insufficient Stock"
+	OrderStatusMessage         = "Invalid Order Status"
+	ShipmentStatusMessage      = "Invalid Shipment Status"
+	ShipmentErrorMessage       = "Not Your Shipment"
+	AddressNotFoundMessage     = "Address Not Found"
+	OutsideDeliveryAreaMessage = "Outside Delivery Area"
+	ProofRequiredMessage       = "Proof Of Delivery Required"
+	ProofNotFoundMessage       = "Proof Of Delivery Not Found"
+	PaymentStatusMessage       = "Invalid Payment Status"
+	PaymentGatewayMessage      = "Payment Gateway Unavailable"
+	PaymentAmountMessage       = "Payment Amount Mismatch"
+	RefundAmountMessage        = "Invalid Refund Amount"
 )
@@ -0,0 +1,53 @@
+package invoice
+
+import (
+	"html/template"
+	"io"
+)
+
+var htmlTemplate = template.Must(template.New("invoice").Funcs(template.FuncMap{
+	"money": money,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Invoice {{.Reference}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; color: #222; margin: 40px; }
+table { border-collapse: collapse; width: 100%; margin-top: 24px; }
+th, td { padding: 6px 8px; border-bottom: 1px solid #ddd; text-align: left; }
+.amount { text-align: right; white-space: nowrap; }
+.totals td { border: none; }
+</style>
+</head>
+<body>
+<h1>Afiqo Invoice</h1>
+<p>
+Invoice No: <strong>{{.Reference}}</strong><br>
+Date: {{.IssuedAt.Format "02 Jan 2006"}}<br>
+Order: {{.OrderID}}
+</p>
+<p>
+Bill To:<br>
+{{.CustomerName}}<br>
+{{.CustomerEmail}}<br>
+{{.DeliveryAddress}}
+</p>
+<table>
+<tr><th>Item</th><th class="amount">Quantity</th><th class="amount">Unit Price</th><th class="amount">Amount</th></tr>
+{{range .Lines}}<tr><td>{{.Description}}</td><td class="amount">{{.Quantity}}</td><td class="amount">{{money .UnitPrice}}</td><td class="amount">{{money .Amount}}</td></tr>
+{{end}}</table>
+<table class="totals">
+<tr><td>Subtotal</td><td class="amount">{{money .Subtotal}}</td></tr>
+<tr><td>Delivery Fee</td><td class="amount">{{money .DeliveryFee}}</td></tr>
+<tr><td><strong>Total</strong></td><td class="amount"><strong>{{money .Total}}</strong></td></tr>
+{{if .Refunded.IsPositive}}<tr><td>Refunded</td><td class="amount">-{{money .Refunded}}</td></tr>
+<tr><td><strong>Net Paid</strong></td><td class="amount"><strong>{{money .Net}}</strong></td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+func (i Invoice) RenderHTML(w io.Writer) error {
+	return htmlTemplate.Execute(w, i)
+}
@@ -0,0 +1,59 @@
+package invoice
+
+import (
+	"fmt"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+const (
+	FormatPDF  = "pdf"
+	FormatHTML = "html"
+)
+
+type (
+	Line struct {
+		Description string
+		Quantity    uint
+		UnitPrice   decimal.Decimal
+		Amount      decimal.Decimal
+	}
+
+	// Invoice is everything printed on an invoice. Amounts are in ringgit.
+	Invoice struct {
+		Number          int64
+		IssuedAt        time.Time
+		OrderID         string
+		CustomerName    string
+		CustomerEmail   string
+		DeliveryAddress string
+		Lines           []Line
+		DeliveryFee     decimal.Decimal
+		Refunded        decimal.Decimal
+	}
+)
+
+// Reference is the invoice number as printed, e.g. INV-000042.
+func (i Invoice) Reference() string {
+	return fmt.Sprintf(`INV-%06d`, i.Number)
+}
+
+func (i Invoice) Subtotal() decimal.Decimal {
+	subtotal := decimal.Zero
+	for _, line := range i.Lines {
+		subtotal = subtotal.Add(line.Amount)
+	}
+	return subtotal
+}
+
+func (i Invoice) Total() decimal.Decimal {
+	return i.Subtotal().Add(i.DeliveryFee)
+}
+
+func (i Invoice) Net() decimal.Decimal {
+	return i.Total().Sub(i.Refunded)
+}
+
+func money(amount decimal.Decimal) string {
+	return fmt.Sprintf(`RM %s`, amount.StringFixed(2))
+}
@@ -0,0 +1,91 @@
+package invoice
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sample(lines int) Invoice {
+
+	invoice := Invoice{
+		Number:          42,
+		IssuedAt:        time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC),
+		OrderID:         "9b2f6c1e-0000-4000-8000-000000000000",
+		CustomerName:    "Siti <Aminah>",
+		CustomerEmail:   "siti@example.com",
+		DeliveryAddress: "Persiaran Kayangan, Shah Alam",
+		DeliveryFee:     decimal.NewFromFloat(5.5),
+	}
+
+	for l := 0; l < lines; l++ {
+		invoice.Lines = append(invoice.Lines, Line{
+			Description: fmt.Sprintf(`Durian (Musang King) %d`, l),
+			Quantity:    2,
+			UnitPrice:   decimal.NewFromInt(10),
+			Amount:      decimal.NewFromInt(20),
+		})
+	}
+
+	return invoice
+}
+
+func TestRenderPDF(t *testing.T) {
+
+	var out bytes.Buffer
+
+	err := sample(80).RenderPDF(&out)
+	if err != nil {
+		t.Fatalf("RenderPDF = %v", err)
+	}
+
+	doc := out.String()
+
+	if !strings.HasPrefix(doc, "%PDF-1.4\n") || !strings.HasSuffix(doc, "%%EOF\n") {
+		t.Fatal("the document should start with a PDF header and end with EOF")
+	}
+
+	for _, want := range []string{"INV-000042", `Durian \(Musang King\) 79`, "RM 1605.50", "/Count 3"} {
+		if !strings.Contains(doc, want) {
+			t.Fatalf("the document should contain %q", want)
+		}
+	}
+
+	// Every cross-reference entry has to point at its object.
+	xref, _ := strconv.Atoi(regexp.MustCompile(`startxref\n(\d+)`).FindStringSubmatch(doc)[1])
+	if !strings.HasPrefix(doc[xref:], "xref\n") {
+		t.Fatal("startxref should point at the cross-reference table")
+	}
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(doc[xref:], -1)
+	for n, entry := range entries {
+		offset, _ := strconv.Atoi(entry[1])
+		if !strings.HasPrefix(doc[offset:], fmt.Sprintf("%d 0 obj", n+1)) {
+			t.Fatalf("xref entry %d points at %q", n+1, doc[offset:offset+10])
+		}
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+
+	invoice := sample(1)
+	invoice.Refunded = decimal.NewFromInt(5)
+
+	var out bytes.Buffer
+
+	err := invoice.RenderHTML(&out)
+	if err != nil {
+		t.Fatalf("RenderHTML = %v", err)
+	}
+
+	for _, want := range []string{"INV-000042", "Siti &lt;Aminah&gt;", "RM 25.50", "RM 20.50"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("the page should contain %q", want)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package invoice
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A4 in points, with the margins the layout keeps to.
+const (
+	pageWidth    = 595
+	pageHeight   = 842
+	marginLeft   = 50
+	marginRight  = 545
+	marginBottom = 80
+
+	descriptionRunes = 48
+)
+
+// Helvetica glyph widths in thousandths of the font size for what right aligned columns print. Anything else
+// is measured as a digit, close enough for the few letters that show up in amounts.
+var helveticaWidths = map[rune]float64{
+	' ': 278, '.': 278, ',': 278, '-': 333, 'R': 722, 'M': 833,
+}
+
+// pdf lays out text on A4 pages using the standard Helvetica fonts every reader has, so no font is embedded
+// and nothing outside the standard library is needed.
+type pdf struct {
+	pages []*bytes.Buffer
+	y     float64
+}
+
+func (i Invoice) RenderPDF(w io.Writer) error {
+
+	doc := &pdf{}
+	doc.addPage()
+
+	doc.text(marginLeft, doc.y, 20, true, "Afiqo Invoice")
+	doc.y -= 34
+
+	doc.text(marginLeft, doc.y, 11, false, "Invoice No: "+i.Reference())
+	doc.y -= 15
+	doc.text(marginLeft, doc.y, 11, false, "Date: "+i.IssuedAt.Format("02 Jan 2006"))
+	doc.y -= 15
+	doc.text(marginLeft, doc.y, 11, false, "Order: "+i.OrderID)
+	doc.y -= 28
+
+	doc.text(marginLeft, doc.y, 11, true, "Bill To")
+	doc.y -= 15
+	for _, line := range []string{i.CustomerName, i.CustomerEmail, i.DeliveryAddress} {
+		if line == "" {
+			continue
+		}
+		doc.text(marginLeft, doc.y, 11, false, line)
+		doc.y -= 15
+	}
+	doc.y -= 15
+
+	doc.tableHeader()
+
+	for _, line := range i.Lines {
+		if doc.y < marginBottom {
+			doc.addPage()
+			doc.tableHeader()
+		}
+
+		doc.text(marginLeft, doc.y, 10, false, truncate(line.Description, descriptionRunes))
+		doc.textRight(360, doc.y, 10, false, fmt.Sprintf(`%d`, line.Quantity))
+		doc.textRight(455, doc.y, 10, false, money(line.UnitPrice))
+		doc.textRight(marginRight, doc.y, 10, false, money(line.Amount))
+		doc.y -= 16
+	}
+
+	totals := [][2]string{
+		{"Subtotal", money(i.Subtotal())},
+		{"Delivery Fee", money(i.DeliveryFee)},
+		{"Total", money(i.Total())},
+	}
+	if i.Refunded.IsPositive() {
+		totals = append(totals, [2]string{"Refunded", "-" + money(i.Refunded)},
+			[2]string{"Net Paid", money(i.Net())})
+	}
+
+	if doc.y-float64(len(totals)*16) < marginBottom {
+		doc.addPage()
+	}
+
+	doc.line(marginLeft, doc.y+10, marginRight, doc.y+10)
+	doc.y -= 6
+	for _, total := range totals {
+		bold := total[0] == "Total" || total[0] == "Net Paid"
+		doc.text(355, doc.y, 11, bold, total[0])
+		doc.textRight(marginRight, doc.y, 11, bold, total[1])
+		doc.y -= 16
+	}
+
+	return doc.write(w)
+}
+
+func (p *pdf) addPage() {
+	p.pages = append(p.pages, &bytes.Buffer{})
+	p.y = pageHeight - 60
+}
+
+func (p *pdf) tableHeader() {
+	p.text(marginLeft, p.y, 10, true, "Item")
+	p.textRight(360, p.y, 10, true, "Quantity")
+	p.textRight(455, p.y, 10, true, "Unit Price")
+	p.textRight(marginRight, p.y, 10, true, "Amount")
+	p.line(marginLeft, p.y-5, marginRight, p.y-5)
+	p.y -= 20
+}
+
+func (p *pdf) text(x, y, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(p.pages[len(p.pages)-1], "BT /%s %.0f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, escape(s))
+}
+
+func (p *pdf) textRight(right, y, size float64, bold bool, s string) {
+	p.text(right-textWidth(s, size), y, size, bold, s)
+}
+
+func (p *pdf) line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(p.pages[len(p.pages)-1], "0.5 w %.2f %.2f m %.2f %.2f l S\n", x1, y1, x2, y2)
+}
+
+// write puts out the catalog, the page tree, both fonts and then a page and its content stream per page,
+// followed by the cross-reference table readers use to find them.
+func (p *pdf) write(w io.Writer) error {
+
+	var out bytes.Buffer
+	var offsets []int
+
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n")
+
+	var kids []string
+	for k := range p.pages {
+		kids = append(kids, fmt.Sprintf(`%d 0 R`, 5+2*k))
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf(`<< /Type /Pages /Kids [%s] /Count %d >>`, strings.Join(kids, " "), len(p.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for k, page := range p.pages {
+		object(fmt.Sprintf(`<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] `+
+			`/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>`, pageWidth, pageHeight, 6+2*k))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := out.WriteTo(w)
+	return err
+}
+
+// escape turns s into the bytes of a PDF literal string. Characters outside Latin-1 have no glyph in the
+// standard fonts and print as '?'.
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < ' ':
+			b.WriteByte(' ')
+		case r > 0xff:
+			b.WriteByte('?')
+		default:
+			b.WriteByte(byte(r))
+		}
+	}
+	return b.String()
+}
+
+func textWidth(s string, size float64) float64 {
+	width := 0.0
+	for _, r := range s {
+		glyph, ok := helveticaWidths[r]
+		if !ok {
+			glyph = 556
+		}
+		width += glyph
+	}
+	return width * size / 1000
+}
+
+func truncate(s string, runes int) string {
+	r := []rune(s)
+	if len(r) <= runes {
+		return s
+	}
+	return string(r[:runes-3]) + "..."
+}
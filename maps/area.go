@@ -0,0 +1,118 @@
+package maps
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidArea = errors.New("invalid service area")
+
+type (
+	// ring is a closed GeoJSON linear ring of [longitude, latitude] positions.
+	ring [][2]float64
+
+	// Area is a GeoJSON Polygon or MultiPolygon. The first ring of every polygon is its outline, the rest are
+	// holes.
+	Area struct {
+		polygons [][]ring
+	}
+
+	geoJSON struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+		Geometry    *geoJSON        `json:"geometry"`
+	}
+)
+
+// ParseArea reads a GeoJSON Polygon, MultiPolygon or a Feature wrapping one of them.
+func ParseArea(data []byte) (Area, error) {
+
+	var geometry geoJSON
+	err := json.Unmarshal(data, &geometry)
+	if err != nil {
+		return Area{}, fmt.Errorf(`%w: %v`, ErrInvalidArea, err)
+	}
+
+	if geometry.Type == "Feature" && geometry.Geometry != nil {
+		geometry = *geometry.Geometry
+	}
+
+	var area Area
+	switch geometry.Type {
+	case "Polygon":
+		var polygon []ring
+		err = json.Unmarshal(geometry.Coordinates, &polygon)
+		area.polygons = append(area.polygons, polygon)
+	case "MultiPolygon":
+		err = json.Unmarshal(geometry.Coordinates, &area.polygons)
+	default:
+		return Area{}, fmt.Errorf(`%w: unsupported type %q`, ErrInvalidArea, geometry.Type)
+	}
+
+	if err != nil {
+		return Area{}, fmt.Errorf(`%w: %v`, ErrInvalidArea, err)
+	}
+
+	if len(area.polygons) == 0 {
+		return Area{}, fmt.Errorf(`%w: no polygons`, ErrInvalidArea)
+	}
+
+	for _, polygon := range area.polygons {
+		if len(polygon) == 0 {
+			return Area{}, fmt.Errorf(`%w: empty polygon`, ErrInvalidArea)
+		}
+		for _, r := range polygon {
+			if len(r) < 4 || r[0] != r[len(r)-1] {
+				return Area{}, fmt.Errorf(`%w: rings need at least four positions and must be closed`,
+					ErrInvalidArea)
+			}
+		}
+	}
+
+	return area, nil
+}
+
+// Contains reports whether the location lies inside one of the area's polygons and outside its holes.
+func (a Area) Contains(location Location) bool {
+
+	x, _ := location.Longitude.Float64()
+	y, _ := location.Latitude.Float64()
+
+	for _, polygon := range a.polygons {
+		if !polygon[0].contains(x, y) {
+			continue
+		}
+
+		inHole := false
+		for _, hole := range polygon[1:] {
+			if hole.contains(x, y) {
+				inHole = true
+				break
+			}
+		}
+
+		if !inHole {
+			return true
+		}
+	}
+
+	return false
+}
+
+// contains casts a ray from the point and counts how many edges it crosses. Service areas are small enough
+// for longitude and latitude to be treated as plane coordinates.
+func (r ring) contains(x, y float64) bool {
+
+	inside := false
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		xi, yi := r[i][0], r[i][1]
+		xj, yj := r[j][0], r[j][1]
+
+		if (yi > y) != (yj > y) && x < (xj-xi)*(y-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
@@ -0,0 +1,41 @@
+package maps
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestArea(t *testing.T) {
+
+	// A box around Shah Alam and Petaling Jaya with a hole cut around Petaling Jaya.
+	area, err := ParseArea([]byte(`{"type":"Feature","geometry":{"type":"Polygon","coordinates":[
+		[[101.45,3.00],[101.65,3.00],[101.65,3.15],[101.45,3.15],[101.45,3.00]],
+		[[101.59,3.09],[101.62,3.09],[101.62,3.12],[101.59,3.12],[101.59,3.09]]]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !area.Contains(shahAlam) {
+		t.Fatal("Shah Alam should be inside the area")
+	}
+
+	if area.Contains(petalingJaya) {
+		t.Fatal("Petaling Jaya sits in the hole and should be outside the area")
+	}
+
+	if area.Contains(kualaLumpurKLC) {
+		t.Fatal("Kuala Lumpur should be outside the area")
+	}
+
+	for _, invalid := range []string{
+		`{"type":"Point","coordinates":[101.5,3.0]}`,
+		`{"type":"Polygon","coordinates":[[[101.45,3.00],[101.65,3.00],[101.65,3.15]]]}`,
+		`{"type":"Polygon","coordinates":[]}`,
+		`not json`,
+	} {
+		_, err = ParseArea([]byte(invalid))
+		if !errors.Is(err, ErrInvalidArea) {
+			t.Fatalf("ParseArea(%s) err = %v, want ErrInvalidArea", invalid, err)
+		}
+	}
+}
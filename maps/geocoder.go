@@ -0,0 +1,179 @@
+package maps
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+const (
+	GeocoderGoogle  = "google"
+	GeocoderFixture = "fixture"
+
+	geocodeURL = "/maps/api/geocode/json"
+
+	// fixtureReverseRadius is how close a location must be to a fixture to reverse geocode to it, in metres.
+	fixtureReverseRadius = 200
+)
+
+var ErrAddressNotFound = errors.New("address not found")
+
+type (
+	// Geocoder turns addresses into locations and back.
+	Geocoder interface {
+		Geocode(ctx context.Context, address string) (Location, error)
+		ReverseGeocode(ctx context.Context, location Location) (string, error)
+	}
+
+	GoogleGeocoder struct {
+		URL    string
+		ApiKey string
+	}
+
+	// FixtureGeocoder answers from a fixed address book, for tests and offline runs.
+	FixtureGeocoder struct {
+		Addresses map[string]Location
+	}
+
+	geocodeResponse struct {
+		Status  string `json:"status"`
+		Results []struct {
+			FormattedAddress string `json:"formatted_address"`
+			Geometry         struct {
+				Location struct {
+					Lat float64 `json:"lat"`
+					Lng float64 `json:"lng"`
+				} `json:"location"`
+			} `json:"geometry"`
+		} `json:"results"`
+	}
+
+	fixtureAddress struct {
+		Address   string  `json:"address"`
+		Latitude  float64 `json:"latitude"`
+		Longitude float64 `json:"longitude"`
+	}
+)
+
+func NewGeocoder(maps Maps) (Geocoder, error) {
+	switch maps.Geocoder {
+	case GeocoderGoogle, "":
+		return GoogleGeocoder{URL: maps.URL, ApiKey: maps.ApiKey}, nil
+	case GeocoderFixture:
+		return LoadFixtureGeocoder(maps.GeocoderFixture)
+	}
+
+	return nil, fmt.Errorf(`unknown geocoder %q`, maps.Geocoder)
+}
+
+func (g GoogleGeocoder) Geocode(ctx context.Context, address string) (Location, error) {
+
+	var geocode geocodeResponse
+
+	err := get(ctx, fmt.Sprintf("%s%s", g.URL, geocodeURL), map[string]string{
+		"key":     g.ApiKey,
+		"address": address,
+	}, &geocode)
+	if err != nil {
+		return Location{}, err
+	}
+
+	err = geocode.err()
+	if err != nil {
+		return Location{}, err
+	}
+
+	result := geocode.Results[0].Geometry.Location
+
+	return NewLocation(result.Lat, result.Lng), nil
+}
+
+func (g GoogleGeocoder) ReverseGeocode(ctx context.Context, location Location) (string, error) {
+
+	var geocode geocodeResponse
+
+	err := get(ctx, fmt.Sprintf("%s%s", g.URL, geocodeURL), map[string]string{
+		"key":    g.ApiKey,
+		"latlng": location.String(),
+	}, &geocode)
+	if err != nil {
+		return "", err
+	}
+
+	err = geocode.err()
+	if err != nil {
+		return "", err
+	}
+
+	return geocode.Results[0].FormattedAddress, nil
+}
+
+func (r geocodeResponse) err() error {
+	if r.Status == "ZERO_RESULTS" || (r.Status == "OK" && len(r.Results) == 0) {
+		return ErrAddressNotFound
+	}
+	if r.Status != "OK" {
+		return fmt.Errorf(`geocode status %s`, r.Status)
+	}
+	return nil
+}
+
+// LoadFixtureGeocoder reads a JSON list of {"address", "latitude", "longitude"} entries.
+func LoadFixtureGeocoder(path string) (FixtureGeocoder, error) {
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return FixtureGeocoder{}, err
+	}
+
+	var addresses []fixtureAddress
+	err = json.Unmarshal(data, &addresses)
+	if err != nil {
+		return FixtureGeocoder{}, err
+	}
+
+	geocoder := FixtureGeocoder{Addresses: make(map[string]Location)}
+	for _, address := range addresses {
+		geocoder.Addresses[address.Address] = NewLocation(address.Latitude, address.Longitude)
+	}
+
+	return geocoder, nil
+}
+
+func (g FixtureGeocoder) Geocode(ctx context.Context, address string) (Location, error) {
+
+	key := normaliseAddress(address)
+	for fixture, location := range g.Addresses {
+		if normaliseAddress(fixture) == key {
+			return location, nil
+		}
+	}
+
+	return Location{}, ErrAddressNotFound
+}
+
+func (g FixtureGeocoder) ReverseGeocode(ctx context.Context, location Location) (string, error) {
+
+	nearest := ""
+	nearestDistance := float64(fixtureReverseRadius)
+	for address, fixture := range g.Addresses {
+		distance := Haversine(location, fixture)
+		if distance <= nearestDistance {
+			nearest = address
+			nearestDistance = distance
+		}
+	}
+
+	if nearest == "" {
+		return "", ErrAddressNotFound
+	}
+
+	return nearest, nil
+}
+
+func normaliseAddress(address string) string {
+	return strings.Join(strings.Fields(strings.ToLower(strings.ReplaceAll(address, ",", " "))), " ")
+}
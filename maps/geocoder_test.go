@@ -0,0 +1,63 @@
+package maps
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFixtureGeocoder(t *testing.T) {
+
+	geocoder := FixtureGeocoder{Addresses: map[string]Location{
+		"Persiaran Kayangan, Shah Alam": shahAlam,
+	}}
+
+	location, err := geocoder.Geocode(context.Background(), "  persiaran kayangan   shah alam ")
+	if err != nil || location != shahAlam {
+		t.Fatalf("Geocode = %v, %v, want %v", location, err, shahAlam)
+	}
+
+	_, err = geocoder.Geocode(context.Background(), "Jalan Tak Wujud")
+	if err != ErrAddressNotFound {
+		t.Fatalf("unknown address err = %v, want ErrAddressNotFound", err)
+	}
+
+	address, err := geocoder.ReverseGeocode(context.Background(), shahAlam)
+	if err != nil || address != "Persiaran Kayangan, Shah Alam" {
+		t.Fatalf("ReverseGeocode = %q, %v", address, err)
+	}
+
+	_, err = geocoder.ReverseGeocode(context.Background(), petalingJaya)
+	if err != ErrAddressNotFound {
+		t.Fatalf("far location err = %v, want ErrAddressNotFound", err)
+	}
+}
+
+func TestGoogleGeocoder(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != geocodeURL {
+			t.Errorf("path = %s, want %s", r.URL.Path, geocodeURL)
+		}
+		if r.URL.Query().Get("address") == "nowhere" {
+			w.Write([]byte(`{"status":"ZERO_RESULTS","results":[]}`))
+			return
+		}
+		w.Write([]byte(`{"status":"OK","results":[{"formatted_address":"Shah Alam",
+			"geometry":{"location":{"lat":3.0738,"lng":101.5183}}}]}`))
+	}))
+	defer server.Close()
+
+	geocoder := GoogleGeocoder{URL: server.URL}
+
+	location, err := geocoder.Geocode(context.Background(), "shah alam")
+	if err != nil || !location.Latitude.Equal(shahAlam.Latitude) || !location.Longitude.Equal(shahAlam.Longitude) {
+		t.Fatalf("Geocode = %v, %v, want %v", location, err, shahAlam)
+	}
+
+	_, err = geocoder.Geocode(context.Background(), "nowhere")
+	if err != ErrAddressNotFound {
+		t.Fatalf("no results err = %v, want ErrAddressNotFound", err)
+	}
+}
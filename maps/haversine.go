@@ -0,0 +1,54 @@
+package maps
+
+import (
+	"context"
+	"math"
+)
+
+const (
+	earthRadius     = 6371000.0
+	defaultSpeedKmH = 30.0
+)
+
+// HaversineProvider estimates routes offline from the straight-line distance and an average speed.
+type HaversineProvider struct {
+	SpeedKmH float64
+}
+
+func (p HaversineProvider) Distances(ctx context.Context, origin Location, destinations []Location) ([]Route, error) {
+
+	speed := p.SpeedKmH
+	if speed <= 0 {
+		speed = defaultSpeedKmH
+	}
+
+	routes := make([]Route, len(destinations))
+	for i, destination := range destinations {
+		distance := Haversine(origin, destination)
+		routes[i] = Route{
+			Distance: int(math.Round(distance)),
+			Duration: int(math.Round(distance / (speed * 1000 / 3600))),
+		}
+	}
+
+	return routes, nil
+}
+
+// Haversine returns the great-circle distance between two locations in metres.
+func Haversine(a, b Location) float64 {
+
+	lat1, _ := a.Latitude.Float64()
+	lng1, _ := a.Longitude.Float64()
+	lat2, _ := b.Latitude.Float64()
+	lng2, _ := b.Longitude.Float64()
+
+	phi1 := lat1 * math.Pi / 180
+	phi2 := lat2 * math.Pi / 180
+	deltaPhi := (lat2 - lat1) * math.Pi / 180
+	deltaLambda := (lng2 - lng1) * math.Pi / 180
+
+	h := math.Sin(deltaPhi/2)*math.Sin(deltaPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(deltaLambda/2)*math.Sin(deltaLambda/2)
+
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
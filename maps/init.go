@@ -1,16 +1,49 @@
 package maps
 
 type Maps struct {
-	URL    string
-	ApiKey string
+	Provider        string
+	URL             string
+	ApiKey          string
+	Profile         string
+	SpeedKmH        float64
+	Geocoder        string
+	GeocoderFixture string
 }
 
 var (
-	apiKey string
-	url    string
+	apiKey   string
+	url      string
+	provider DistanceProvider
+	geocoder Geocoder
 )
 
-func Init(maps Maps) {
+func Init(maps Maps) error {
 	apiKey = maps.ApiKey
 	url = maps.URL
+
+	distanceProvider, err := NewDistanceProvider(maps)
+	if err != nil {
+		return err
+	}
+
+	provider = distanceProvider
+
+	addressGeocoder, err := NewGeocoder(maps)
+	if err != nil {
+		return err
+	}
+
+	geocoder = addressGeocoder
+
+	return nil
+}
+
+// Provider returns the distance provider chosen in Init.
+func Provider() DistanceProvider {
+	return provider
+}
+
+// AddressGeocoder returns the geocoder chosen in Init.
+func AddressGeocoder() Geocoder {
+	return geocoder
 }
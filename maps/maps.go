@@ -2,26 +2,84 @@ package maps
 
 import (
 	"context"
+	"fmt"
 	uuid "github.com/satori/go.uuid"
+	"strings"
 )
 
-func GetDistanceBetweenTwoLocations(ctx context.Context, origin, destination string, ID uuid.UUID) (DistanceMatrix, error) {
+const distanceMatrixURL = "/maps/api/distancematrix/json"
 
-	extraURL := "/maps/api/distancematrix/json"
+// GoogleProvider asks the Google Distance Matrix API for road distances.
+type GoogleProvider struct {
+	URL    string
+	ApiKey string
+}
 
-	var distance DistanceMatrix
+func GetDistanceBetweenTwoLocations(ctx context.Context, origin, destination string, ID uuid.UUID) (DistanceMatrix, error) {
 
-	distance.ID = ID
+	var distance DistanceMatrix
 
 	param := map[string]string{
 		"origins":      origin,
 		"destinations": destination,
 	}
 
-	err := Get(ctx, extraURL, param, &distance)
+	err := Get(ctx, distanceMatrixURL, param, &distance)
 	if err != nil {
-		return DistanceMatrix{}, nil
+		return DistanceMatrix{}, err
+	}
+
+	if distance.Status != "OK" {
+		return DistanceMatrix{}, fmt.Errorf(`distance matrix status %s`, distance.Status)
 	}
 
+	distance.ID = ID
+
 	return distance, nil
 }
+
+func (p GoogleProvider) Distances(ctx context.Context, origin Location, destinations []Location) ([]Route, error) {
+
+	if len(destinations) == 0 {
+		return nil, nil
+	}
+
+	var points []string
+	for _, destination := range destinations {
+		points = append(points, destination.String())
+	}
+
+	param := map[string]string{
+		"key":          p.ApiKey,
+		"origins":      origin.String(),
+		"destinations": strings.Join(points, "|"),
+	}
+
+	var distance DistanceMatrix
+
+	err := get(ctx, fmt.Sprintf("%s%s", p.URL, distanceMatrixURL), param, &distance)
+	if err != nil {
+		return nil, err
+	}
+
+	if distance.Status != "OK" {
+		return nil, fmt.Errorf(`distance matrix status %s`, distance.Status)
+	}
+
+	if len(distance.Rows) != 1 || len(distance.Rows[0].Elements) != len(destinations) {
+		return nil, fmt.Errorf(`distance matrix returned %d rows for 1 origin`, len(distance.Rows))
+	}
+
+	routes := make([]Route, len(destinations))
+	for i, element := range distance.Rows[0].Elements {
+		if element.Status != "OK" {
+			return nil, ErrNoRoute
+		}
+		routes[i] = Route{
+			Distance: element.Distance.Value,
+			Duration: element.Duration.Value,
+		}
+	}
+
+	return routes, nil
+}
@@ -0,0 +1,77 @@
+package maps
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const defaultOSRMProfile = "driving"
+
+// OSRMProvider reads road distances from the table service of an OSRM-compatible server.
+type OSRMProvider struct {
+	URL     string
+	Profile string
+}
+
+type osrmTable struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Distances [][]*float64 `json:"distances"`
+	Durations [][]*float64 `json:"durations"`
+}
+
+func (p OSRMProvider) Distances(ctx context.Context, origin Location, destinations []Location) ([]Route, error) {
+
+	if len(destinations) == 0 {
+		return nil, nil
+	}
+
+	profile := p.Profile
+	if profile == "" {
+		profile = defaultOSRMProfile
+	}
+
+	// OSRM takes coordinates as longitude,latitude pairs.
+	points := []string{fmt.Sprintf(`%s,%s`, origin.Longitude.String(), origin.Latitude.String())}
+	for _, destination := range destinations {
+		points = append(points, fmt.Sprintf(`%s,%s`, destination.Longitude.String(), destination.Latitude.String()))
+	}
+
+	param := map[string]string{
+		"sources":     "0",
+		"annotations": "distance,duration",
+	}
+
+	var table osrmTable
+
+	err := get(ctx, fmt.Sprintf(`%s/table/v1/%s/%s`, p.URL, profile, strings.Join(points, ";")), param, &table)
+	if err != nil {
+		return nil, err
+	}
+
+	if table.Code != "Ok" {
+		return nil, fmt.Errorf(`osrm table code %s : %s`, table.Code, table.Message)
+	}
+
+	if len(table.Distances) != 1 || len(table.Durations) != 1 ||
+		len(table.Distances[0]) != len(points) || len(table.Durations[0]) != len(points) {
+		return nil, fmt.Errorf(`osrm table returned an unexpected shape`)
+	}
+
+	routes := make([]Route, len(destinations))
+	for i := range destinations {
+		distance := table.Distances[0][i+1]
+		duration := table.Durations[0][i+1]
+		if distance == nil || duration == nil {
+			return nil, ErrNoRoute
+		}
+		routes[i] = Route{
+			Distance: int(math.Round(*distance)),
+			Duration: int(math.Round(*duration)),
+		}
+	}
+
+	return routes, nil
+}
@@ -0,0 +1,67 @@
+package maps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	ProviderGoogle    = "google"
+	ProviderHaversine = "haversine"
+	ProviderOSRM      = "osrm"
+)
+
+// MaxDestinations is the most destinations one Distances call may be given; Google's Distance Matrix refuses
+// more in a single request.
+const MaxDestinations = 25
+
+var ErrNoRoute = errors.New("no route between locations")
+
+type (
+	Location struct {
+		Latitude  decimal.Decimal
+		Longitude decimal.Decimal
+	}
+
+	// Route is the travel distance in metres and duration in seconds from an origin to one destination.
+	Route struct {
+		Distance int
+		Duration int
+	}
+
+	// DistanceProvider measures travel from one origin to many destinations. Routes are returned in the
+	// order of the destinations, and an unreachable destination fails the whole call with ErrNoRoute.
+	DistanceProvider interface {
+		Distances(ctx context.Context, origin Location, destinations []Location) ([]Route, error)
+	}
+)
+
+func NewDistanceProvider(maps Maps) (DistanceProvider, error) {
+	switch maps.Provider {
+	case ProviderGoogle, "":
+		return GoogleProvider{URL: maps.URL, ApiKey: maps.ApiKey}, nil
+	case ProviderHaversine:
+		return HaversineProvider{SpeedKmH: maps.SpeedKmH}, nil
+	case ProviderOSRM:
+		return OSRMProvider{URL: maps.URL, Profile: maps.Profile}, nil
+	}
+
+	return nil, fmt.Errorf(`unknown distance provider %q`, maps.Provider)
+}
+
+func NewLocation(latitude, longitude float64) Location {
+	return Location{
+		Latitude:  decimal.NewFromFloat(latitude),
+		Longitude: decimal.NewFromFloat(longitude),
+	}
+}
+
+func (l Location) IsZero() bool {
+	return l.Latitude.IsZero() && l.Longitude.IsZero()
+}
+
+func (l Location) String() string {
+	return fmt.Sprintf(`%s,%s`, l.Latitude.String(), l.Longitude.String())
+}
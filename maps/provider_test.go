@@ -0,0 +1,110 @@
+package maps
+
+import (
+	"context"
+	"github.com/shopspring/decimal"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var (
+	shahAlam       = Location{Latitude: decimal.NewFromFloat(3.0738), Longitude: decimal.NewFromFloat(101.5183)}
+	petalingJaya   = Location{Latitude: decimal.NewFromFloat(3.1073), Longitude: decimal.NewFromFloat(101.6067)}
+	kualaLumpurKLC = Location{Latitude: decimal.NewFromFloat(3.1579), Longitude: decimal.NewFromFloat(101.7116)}
+)
+
+func TestHaversineProvider(t *testing.T) {
+
+	routes, err := HaversineProvider{SpeedKmH: 36}.Distances(context.Background(), shahAlam,
+		[]Location{shahAlam, petalingJaya})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if routes[0].Distance != 0 || routes[0].Duration != 0 {
+		t.Fatalf("same place = %+v, want zero route", routes[0])
+	}
+
+	// Shah Alam to Petaling Jaya is roughly 10.5km in a straight line.
+	if routes[1].Distance < 10000 || routes[1].Distance > 11000 {
+		t.Fatalf("distance = %d, want about 10500", routes[1].Distance)
+	}
+
+	// 36km/h is 10m/s.
+	if diff := routes[1].Duration - routes[1].Distance/10; diff < -1 || diff > 1 {
+		t.Fatalf("duration = %d for %d metres, want distance / 10", routes[1].Duration, routes[1].Distance)
+	}
+}
+
+func TestOSRMProvider(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		want := "/table/v1/driving/101.5183,3.0738;101.6067,3.1073;101.7116,3.1579"
+		if r.URL.Path != want {
+			t.Errorf("path = %s, want %s", r.URL.Path, want)
+		}
+		w.Write([]byte(`{"code":"Ok","distances":[[0,14210.4,27102.6]],"durations":[[0,1102.2,2043.8]]}`))
+	}))
+	defer server.Close()
+
+	routes, err := OSRMProvider{URL: server.URL}.Distances(context.Background(), shahAlam,
+		[]Location{petalingJaya, kualaLumpurKLC})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if routes[0] != (Route{Distance: 14210, Duration: 1102}) || routes[1] != (Route{Distance: 27103, Duration: 2044}) {
+		t.Fatalf("routes = %+v", routes)
+	}
+}
+
+func TestOSRMProviderNoRoute(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":"Ok","distances":[[0,null]],"durations":[[0,null]]}`))
+	}))
+	defer server.Close()
+
+	_, err := OSRMProvider{URL: server.URL}.Distances(context.Background(), shahAlam, []Location{petalingJaya})
+	if err != ErrNoRoute {
+		t.Fatalf("err = %v, want ErrNoRoute", err)
+	}
+}
+
+func TestGoogleProviderPropagatesErrors(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"REQUEST_DENIED","rows":[]}`))
+	}))
+	defer server.Close()
+
+	_, err := GoogleProvider{URL: server.URL}.Distances(context.Background(), shahAlam, []Location{petalingJaya})
+	if err == nil {
+		t.Fatal("err = nil, want REQUEST_DENIED")
+	}
+
+	server.Close()
+
+	_, err = GoogleProvider{URL: server.URL}.Distances(context.Background(), shahAlam, []Location{petalingJaya})
+	if err == nil {
+		t.Fatal("err = nil, want connection error")
+	}
+}
+
+func TestNewDistanceProvider(t *testing.T) {
+
+	_, err := NewDistanceProvider(Maps{Provider: "carrier-pigeon"})
+	if err == nil {
+		t.Fatal("err = nil, want unknown provider")
+	}
+
+	provider, err := NewDistanceProvider(Maps{Provider: ProviderHaversine})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := provider.(HaversineProvider); !ok {
+		t.Fatalf("provider = %T, want HaversineProvider", provider)
+	}
+}
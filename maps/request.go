@@ -12,7 +12,18 @@ import (
 
 func Get(ctx context.Context, ExtraURL string, parameters map[string]string, data interface{}) error {
 
-	url := fmt.Sprintf("%s%s", url, ExtraURL)
+	query := map[string]string{
+		"key": apiKey,
+	}
+
+	for key, parameter := range parameters {
+		query[key] = parameter
+	}
+
+	return get(ctx, fmt.Sprintf("%s%s", url, ExtraURL), query, data)
+}
+
+func get(ctx context.Context, url string, parameters map[string]string, data interface{}) error {
 
 	client := &http.Client{}
 
@@ -23,16 +34,12 @@ func Get(ctx context.Context, ExtraURL string, parameters map[string]string, dat
 
 	q := req.URL.Query()
 
-	q.Add("key", apiKey)
-
 	for key, parameter := range parameters {
 		q.Add(key, parameter)
 	}
 
 	req.URL.RawQuery = q.Encode()
 
-	fmt.Println(req.URL)
-
 	resp, err := client.Do(req.WithContext(ctx))
 	if err != nil {
 		return err
@@ -45,6 +52,14 @@ func Get(ctx context.Context, ExtraURL string, parameters map[string]string, dat
 		return err
 	}
 
+	if resp.StatusCode >= http.StatusBadRequest {
+		var errorResponse ErrorModel
+		if json.Unmarshal(rBody, &errorResponse) == nil && errorResponse.Error.Message != "" {
+			return errors.New(fmt.Sprintf(`Invalid params %s`, errorResponse.Error.Message))
+		}
+		return errors.New(fmt.Sprintf(`Unexpected status %s`, resp.Status))
+	}
+
 	if err := json.Unmarshal(rBody, data); err != nil {
 		log.Printf("error on unmarshal %v", err)
 		var errorResponse ErrorModel
@@ -0,0 +1,89 @@
+package maps
+
+import "context"
+
+// Matrix measures the route from every location to every other one. Each origin takes one provider call per
+// MaxDestinations locations.
+func Matrix(ctx context.Context, provider DistanceProvider, locations []Location) ([][]Route, error) {
+
+	matrix := make([][]Route, len(locations))
+	for i, origin := range locations {
+		for start := 0; start < len(locations); start += MaxDestinations {
+			end := start + MaxDestinations
+			if end > len(locations) {
+				end = len(locations)
+			}
+
+			routes, err := provider.Distances(ctx, origin, locations[start:end])
+			if err != nil {
+				return nil, err
+			}
+			matrix[i] = append(matrix[i], routes...)
+		}
+	}
+
+	return matrix, nil
+}
+
+// Tour orders the stops of an open path that starts at index 0 and visits every other index once, keeping the
+// summed cost low. It builds a nearest-neighbour path and then improves it with 2-opt until no reversal of a
+// stretch of the path makes it shorter. Costs may be asymmetric.
+func Tour(cost [][]int) []int {
+
+	if len(cost) == 0 {
+		return nil
+	}
+
+	tour := []int{0}
+	visited := make([]bool, len(cost))
+	visited[0] = true
+
+	for len(tour) < len(cost) {
+		last := tour[len(tour)-1]
+		next := -1
+		for i := range cost {
+			if !visited[i] && (next < 0 || cost[last][i] < cost[last][next]) {
+				next = i
+			}
+		}
+		visited[next] = true
+		tour = append(tour, next)
+	}
+
+	for improved := true; improved; {
+		improved = false
+		for i := 1; i < len(tour)-1; i++ {
+			for j := i + 1; j < len(tour); j++ {
+				candidate := reverse(tour, i, j)
+				if TourCost(cost, candidate) < TourCost(cost, tour) {
+					tour = candidate
+					improved = true
+				}
+			}
+		}
+	}
+
+	return tour
+}
+
+// TourCost sums the cost of every leg of the path.
+func TourCost(cost [][]int, tour []int) int {
+
+	total := 0
+	for i := 1; i < len(tour); i++ {
+		total += cost[tour[i-1]][tour[i]]
+	}
+
+	return total
+}
+
+// reverse returns a copy of the tour with the stops from i to j, inclusive, in reverse order.
+func reverse(tour []int, i, j int) []int {
+
+	reversed := append([]int(nil), tour...)
+	for ; i < j; i, j = i+1, j-1 {
+		reversed[i], reversed[j] = reversed[j], reversed[i]
+	}
+
+	return reversed
+}
@@ -0,0 +1,98 @@
+package maps
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// lineCost is the cost matrix of stops standing on a straight line.
+func lineCost(positions ...int) [][]int {
+
+	cost := make([][]int, len(positions))
+	for i := range positions {
+		cost[i] = make([]int, len(positions))
+		for j := range positions {
+			cost[i][j] = positions[i] - positions[j]
+			if cost[i][j] < 0 {
+				cost[i][j] = -cost[i][j]
+			}
+		}
+	}
+
+	return cost
+}
+
+func TestTour(t *testing.T) {
+
+	// Nearest neighbour already walks up the line, 2-opt must keep it.
+	cost := lineCost(0, 10, 3, 11, 1)
+
+	tour := Tour(cost)
+	if want := []int{0, 4, 2, 1, 3}; !reflect.DeepEqual(tour, want) {
+		t.Fatalf("tour = %v, want %v", tour, want)
+	}
+
+	// Nearest neighbour goes 0, 1, -2, 4 for 10; clearing -2 first and then walking up costs 8.
+	cost = lineCost(0, 1, -2, 4)
+
+	tour = Tour(cost)
+	if want := []int{0, 2, 1, 3}; !reflect.DeepEqual(tour, want) || TourCost(cost, tour) != 8 {
+		t.Fatalf("tour = %v costing %d, want %v costing 8", tour, TourCost(cost, tour), want)
+	}
+}
+
+func TestMatrix(t *testing.T) {
+
+	matrix, err := Matrix(context.Background(), HaversineProvider{}, []Location{shahAlam, petalingJaya})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if matrix[0][0].Distance != 0 || matrix[0][1] != matrix[1][0] {
+		t.Fatalf("matrix = %v, want a zero diagonal and symmetric straight-line legs", matrix)
+	}
+}
+
+// batchProvider measures with haversine but refuses more destinations than one request may hold.
+type batchProvider struct {
+	calls int
+}
+
+func (p *batchProvider) Distances(ctx context.Context, origin Location, destinations []Location) ([]Route, error) {
+
+	p.calls++
+	if len(destinations) > MaxDestinations {
+		return nil, fmt.Errorf(`%d destinations in one request`, len(destinations))
+	}
+
+	return HaversineProvider{}.Distances(ctx, origin, destinations)
+}
+
+func TestMatrixBatches(t *testing.T) {
+
+	locations := make([]Location, MaxDestinations+5)
+	for i := range locations {
+		locations[i] = shahAlam
+		if i%2 == 1 {
+			locations[i] = petalingJaya
+		}
+	}
+
+	provider := &batchProvider{}
+
+	matrix, err := Matrix(context.Background(), provider, locations)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if provider.calls != 2*len(locations) {
+		t.Fatalf("provider called %d times, want 2 per origin", provider.calls)
+	}
+
+	last := len(locations) - 1
+	if len(matrix[0]) != len(locations) || matrix[0][last] != matrix[last][0] || matrix[last][last].Distance != 0 {
+		t.Fatalf("matrix = %v, want every leg in destination order", matrix)
+	}
+}
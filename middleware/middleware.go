@@ -34,6 +34,7 @@ func SessionMiddleware(next http.Handler) http.Handler {
 		}
 
 		ctx = context.WithValue(ctx, "user_id", sessionData.UserID.String())
+		ctx = context.WithValue(ctx, "role", sessionData.Role)
 		r = r.WithContext(ctx)
 		next.ServeHTTP(w, r)
 	})
@@ -0,0 +1,20 @@
+-- Orders can be fulfilled from several warehouses: every order line records the warehouse
+-- it is taken from and shipments are made once per warehouse.
+
+ALTER TABLE order_product ADD COLUMN warehouse_id UUID REFERENCES warehouse (id);
+
+UPDATE order_product op
+SET warehouse_id = o.warehouse_id
+FROM "order" o
+WHERE o.id = op.order_id;
+
+ALTER TABLE order_product ALTER COLUMN warehouse_id SET NOT NULL;
+
+ALTER TABLE shipment ADD COLUMN warehouse_id UUID REFERENCES warehouse (id);
+
+UPDATE shipment s
+SET warehouse_id = o.warehouse_id
+FROM "order" o
+WHERE o.id = s.order_id;
+
+ALTER TABLE shipment ALTER COLUMN warehouse_id SET NOT NULL;
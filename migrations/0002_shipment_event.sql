@@ -0,0 +1,19 @@
+-- Every shipment status change is kept as an event so a parcel's progress can be traced.
+
+CREATE TABLE shipment_event
+(
+    id          UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    shipment_id UUID        NOT NULL REFERENCES shipment (id),
+    status      INT         NOT NULL,
+    latitude    NUMERIC,
+    longitude   NUMERIC,
+    actor_role  VARCHAR(20) NOT NULL,
+    created_by  UUID        NOT NULL,
+    created_at  TIMESTAMP   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX shipment_event_shipment_id_idx ON shipment_event (shipment_id, created_at);
+
+INSERT INTO shipment_event (shipment_id, status, actor_role, created_by, created_at)
+SELECT id, status, 'admin', created_by, created_at
+FROM shipment;
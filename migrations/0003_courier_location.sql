@@ -0,0 +1,14 @@
+-- GPS fixes reported by couriers. The latest fix per courier is also kept in the cache.
+
+CREATE TABLE courier_location
+(
+    id          UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    courier_id  UUID      NOT NULL REFERENCES courier (id),
+    latitude    NUMERIC   NOT NULL,
+    longitude   NUMERIC   NOT NULL,
+    accuracy    NUMERIC   NOT NULL DEFAULT 0,
+    recorded_at TIMESTAMP NOT NULL,
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX courier_location_courier_id_idx ON courier_location (courier_id, recorded_at DESC);
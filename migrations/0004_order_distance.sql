@@ -0,0 +1,4 @@
+-- Road distance in kilometres from the order's warehouse to the delivery address.
+
+ALTER TABLE "order"
+    ADD COLUMN distance NUMERIC NOT NULL DEFAULT 0;
@@ -0,0 +1,8 @@
+-- Delivery is priced as a base fee plus a per-km rate, free above a subtotal threshold.
+
+ALTER TABLE configuration
+    ADD COLUMN delivery_fee_per_km     NUMERIC NOT NULL DEFAULT 0,
+    ADD COLUMN free_delivery_threshold NUMERIC NOT NULL DEFAULT 0;
+
+ALTER TABLE "order"
+    ADD COLUMN delivery_fee NUMERIC NOT NULL DEFAULT 0;
@@ -0,0 +1,11 @@
+-- Server side shopping cart. Redis holds a copy per customer with a TTL, this table is the source of truth.
+
+CREATE TABLE cart_item
+(
+    customer_id UUID      NOT NULL REFERENCES customer (id),
+    product_id  UUID      NOT NULL REFERENCES product (id),
+    quantity    INT       NOT NULL CHECK (quantity > 0),
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMP,
+    PRIMARY KEY (customer_id, product_id)
+);
@@ -0,0 +1,7 @@
+-- Set when the coordinates sent with an address sit too far from where the address geocodes to.
+
+ALTER TABLE warehouse
+    ADD COLUMN address_mismatch BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE "order"
+    ADD COLUMN address_mismatch BOOLEAN NOT NULL DEFAULT false;
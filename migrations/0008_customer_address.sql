@@ -0,0 +1,21 @@
+-- Saved delivery locations. A customer has at most one default address.
+
+CREATE TABLE customer_address
+(
+    id               UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    customer_id      UUID      NOT NULL REFERENCES customer (id),
+    label            VARCHAR   NOT NULL,
+    address          VARCHAR   NOT NULL,
+    latitude         NUMERIC   NOT NULL,
+    longitude        NUMERIC   NOT NULL,
+    address_mismatch BOOLEAN   NOT NULL DEFAULT false,
+    is_default       BOOLEAN   NOT NULL DEFAULT false,
+    is_delete        BOOLEAN   NOT NULL DEFAULT false,
+    created_at       TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMP
+);
+
+CREATE INDEX customer_address_customer_id_idx ON customer_address (customer_id) WHERE is_delete = false;
+
+CREATE UNIQUE INDEX customer_address_default_idx ON customer_address (customer_id)
+    WHERE is_default = true AND is_delete = false;
@@ -0,0 +1,6 @@
+-- Where a warehouse delivers to: a radius in km around it, a GeoJSON Polygon or MultiPolygon, or both, in which
+-- case the polygon wins. A warehouse with neither falls back to the configured default radius.
+
+ALTER TABLE warehouse
+    ADD COLUMN service_radius NUMERIC NOT NULL DEFAULT 0 CHECK (service_radius >= 0),
+    ADD COLUMN service_area   JSONB;
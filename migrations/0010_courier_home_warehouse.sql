@@ -0,0 +1,4 @@
+-- Couriers can be based at a warehouse. Automatic dispatch prefers couriers based where a shipment is packed.
+
+ALTER TABLE courier
+    ADD COLUMN warehouse_id UUID REFERENCES warehouse (id);
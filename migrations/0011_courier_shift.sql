@@ -0,0 +1,20 @@
+-- Weekly working hours of couriers. weekday follows Go's time.Weekday, Sunday is 0. A shift can name the
+-- warehouse the courier works from and caps how many undelivered parcels they hold at once, 0 is no cap.
+
+CREATE TABLE courier_shift
+(
+    id           UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    courier_id   UUID      NOT NULL REFERENCES courier (id),
+    warehouse_id UUID REFERENCES warehouse (id),
+    weekday      SMALLINT  NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+    start_time   TIME      NOT NULL,
+    end_time     TIME      NOT NULL CHECK (end_time > start_time),
+    max_parcels  INT       NOT NULL DEFAULT 0 CHECK (max_parcels >= 0),
+    is_delete    BOOLEAN   NOT NULL DEFAULT false,
+    created_by   UUID      NOT NULL,
+    created_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_by   UUID,
+    updated_at   TIMESTAMP
+);
+
+CREATE INDEX courier_shift_courier_id_idx ON courier_shift (courier_id) WHERE is_delete = false;
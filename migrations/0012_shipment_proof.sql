@@ -0,0 +1,17 @@
+-- Evidence collected by the courier when a shipment is delivered. The photo and signature live in the blob
+-- store, only their keys and content types are kept here.
+
+CREATE TABLE shipment_proof
+(
+    id             UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    shipment_id    UUID         NOT NULL UNIQUE REFERENCES shipment (id),
+    recipient_name VARCHAR(255) NOT NULL,
+    photo_key      VARCHAR(255) NOT NULL,
+    photo_type     VARCHAR(100) NOT NULL,
+    signature_key  VARCHAR(255) NOT NULL,
+    signature_type VARCHAR(100) NOT NULL,
+    latitude       NUMERIC      NOT NULL,
+    longitude      NUMERIC      NOT NULL,
+    created_by     UUID         NOT NULL,
+    created_at     TIMESTAMP    NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,11 @@
+-- Payments are settled by the payment gateway. amount is what the gateway must collect and reference is the
+-- gateway's intent, status 2 marks a payment the gateway reported as failed.
+
+ALTER TABLE payment
+    ADD COLUMN amount    NUMERIC      NOT NULL DEFAULT 0,
+    ADD COLUMN reference VARCHAR(255) UNIQUE;
+
+UPDATE payment
+SET amount = o.total_price
+FROM "order" o
+WHERE o.id = payment.order_id;
@@ -0,0 +1,18 @@
+-- Money given back on a paid payment. A refund is pending while the gateway is asked, refunds that failed at the
+-- gateway are kept for the record but do not count against the payment.
+
+CREATE TABLE payment_refund
+(
+    id         UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    payment_id UUID         NOT NULL REFERENCES payment (id),
+    amount     NUMERIC      NOT NULL CHECK (amount > 0),
+    reason     VARCHAR(255) NOT NULL,
+    status     INT          NOT NULL DEFAULT 0,
+    reference  VARCHAR(255),
+    created_by UUID         NOT NULL,
+    created_at TIMESTAMP    NOT NULL DEFAULT NOW(),
+    updated_by UUID,
+    updated_at TIMESTAMP
+);
+
+CREATE INDEX payment_refund_payment_id_idx ON payment_refund (payment_id, created_at);
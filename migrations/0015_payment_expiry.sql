@@ -0,0 +1,4 @@
+-- Orders waiting for payment are swept by the payment expiry worker. Status 5 marks a payment whose window ran
+-- out before the customer paid.
+
+CREATE INDEX payment_awaiting_created_at_idx ON payment (created_at) WHERE status IN (0, 2) AND is_delete = false;
@@ -0,0 +1,10 @@
+-- One invoice per paid order. Numbers are handed out without gaps in the order invoices are first issued.
+
+CREATE TABLE invoice
+(
+    id         UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    order_id   UUID      NOT NULL UNIQUE REFERENCES "order" (id),
+    number     BIGINT    NOT NULL UNIQUE,
+    created_by UUID      NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,20 @@
+-- Mail waiting to be sent. Callers write here, in their own transaction where they have one, and the outbox
+-- worker sends it, retrying with backoff until max attempts mark it failed with the last error kept.
+
+CREATE TABLE email_outbox
+(
+    id              UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    recipient       VARCHAR(255) NOT NULL,
+    subject         VARCHAR(255) NOT NULL,
+    body            TEXT         NOT NULL,
+    status          INT          NOT NULL DEFAULT 0,
+    attempts        INT          NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP    NOT NULL DEFAULT NOW(),
+    last_error      TEXT,
+    sent_at         TIMESTAMP,
+    created_by      UUID         NOT NULL,
+    created_at      TIMESTAMP    NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMP
+);
+
+CREATE INDEX email_outbox_pending_idx ON email_outbox (next_attempt_at) WHERE status = 0;
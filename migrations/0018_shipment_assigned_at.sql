@@ -0,0 +1,14 @@
+-- When the shipment was handed to its current courier. A shift's max_parcels caps how many parcels the courier
+-- is handed between the start and end of that shift, whether delivered yet or not.
+
+ALTER TABLE shipment
+    ADD COLUMN assigned_at TIMESTAMP;
+
+UPDATE shipment
+SET assigned_at = COALESCE(updated_at, created_at);
+
+ALTER TABLE shipment
+    ALTER COLUMN assigned_at SET DEFAULT NOW(),
+    ALTER COLUMN assigned_at SET NOT NULL;
+
+CREATE INDEX shipment_courier_assigned_at_idx ON shipment (courier_id, assigned_at) WHERE is_delete = false;
@@ -0,0 +1,158 @@
+package models
+
+import (
+	"afiqo-location/helpers"
+	"context"
+	"fmt"
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+type (
+	CartItemModel struct {
+		CustomerID uuid.UUID
+		ProductID  uuid.UUID
+		Quantity   uint
+		CreatedAt  time.Time
+		UpdatedAt  pq.NullTime
+	}
+)
+
+func GetAllCartItemByCustomerID(ctx context.Context, db helpers.DBExecutor, customerID uuid.UUID) (
+	[]CartItemModel, error) {
+
+	query := fmt.Sprintf(`
+		SELECT
+			customer_id,
+			product_id,
+			quantity,
+			created_at,
+			updated_at
+		FROM
+			cart_item
+		WHERE
+			customer_id = $1
+		ORDER BY
+			created_at
+	`)
+
+	rows, err := db.QueryContext(ctx, query, customerID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var cartItems []CartItemModel
+	for rows.Next() {
+		var cartItem CartItemModel
+
+		rows.Scan(
+			&cartItem.CustomerID,
+			&cartItem.ProductID,
+			&cartItem.Quantity,
+			&cartItem.CreatedAt,
+			&cartItem.UpdatedAt,
+		)
+
+		cartItems = append(cartItems, cartItem)
+	}
+
+	return cartItems, nil
+
+}
+
+// Save sets the item's quantity, adding it to the cart when it is not there yet.
+func (s *CartItemModel) Save(ctx context.Context, db helpers.DBExecutor) error {
+
+	query := fmt.Sprintf(`
+		INSERT INTO cart_item(
+			customer_id,
+			product_id,
+			quantity,
+			created_at)
+		VALUES(
+			$1,$2,$3,now())
+		ON CONFLICT (customer_id, product_id) DO UPDATE
+		SET
+			quantity = EXCLUDED.quantity,
+			updated_at = now()
+		RETURNING
+			created_at, updated_at
+	`)
+
+	err := db.QueryRowContext(ctx, query,
+		s.CustomerID, s.ProductID, s.Quantity).Scan(
+		&s.CreatedAt, &s.UpdatedAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+func (s *CartItemModel) Delete(ctx context.Context, db helpers.DBExecutor) error {
+
+	query := fmt.Sprintf(`
+		DELETE FROM
+			cart_item
+		WHERE
+			customer_id = $1
+		AND
+			product_id = $2
+		RETURNING
+			quantity
+	`)
+
+	err := db.QueryRowContext(ctx, query,
+		s.CustomerID, s.ProductID).Scan(
+		&s.Quantity,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+// DeleteOrderedCartItem removes the items an order was placed from. Items added, or quantities changed, while the
+// order was being placed are left in the cart.
+func DeleteOrderedCartItem(ctx context.Context, db helpers.DBExecutor, customerID uuid.UUID,
+	cartItems []CartItemModel) error {
+
+	query := fmt.Sprintf(`
+		DELETE FROM
+			cart_item c
+		USING
+			unnest($2::uuid[], $3::int[]) AS o(product_id, quantity)
+		WHERE
+			c.customer_id = $1
+		AND
+			c.product_id = o.product_id
+		AND
+			c.quantity = o.quantity
+	`)
+
+	var productIDs []uuid.UUID
+	var quantities []int64
+	for _, cartItem := range cartItems {
+		productIDs = append(productIDs, cartItem.ProductID)
+		quantities = append(quantities, int64(cartItem.Quantity))
+	}
+
+	_, err := db.ExecContext(ctx, query, customerID, pq.Array(uuidStrings(productIDs)), pq.Array(quantities))
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"afiqo-location/helpers"
 	"context"
 	"database/sql"
 	"fmt"
@@ -12,44 +13,52 @@ import (
 
 type (
 	ConfigurationModel struct {
-		ID          uuid.UUID
-		DeliveryFee decimal.Decimal
-		IsDelete    bool
-		CreatedBy   uuid.UUID
-		CreatedAt   time.Time
-		UpdatedBy   uuid.NullUUID
-		UpdatedAt   pq.NullTime
+		ID                    uuid.UUID
+		DeliveryFee           decimal.Decimal
+		DeliveryFeePerKm      decimal.Decimal
+		FreeDeliveryThreshold decimal.Decimal
+		IsDelete              bool
+		CreatedBy             uuid.UUID
+		CreatedAt             time.Time
+		UpdatedBy             uuid.NullUUID
+		UpdatedAt             pq.NullTime
 	}
 
 	ConfigurationResponse struct {
-		ID          uuid.UUID       `json:"id"`
-		DeliveryFee decimal.Decimal `json:"delivery_fee"`
-		IsDelete    bool            `json:"is_delete"`
-		CreatedBy   uuid.UUID       `json:"created_by"`
-		CreatedAt   time.Time       `json:"created_at"`
-		UpdatedBy   uuid.UUID       `json:"updated_by"`
-		UpdatedAt   time.Time       `json:"updated_at"`
+		ID                    uuid.UUID       `json:"id"`
+		DeliveryFee           decimal.Decimal `json:"delivery_fee"`
+		DeliveryFeePerKm      decimal.Decimal `json:"delivery_fee_per_km"`
+		FreeDeliveryThreshold decimal.Decimal `json:"free_delivery_threshold"`
+		IsDelete              bool            `json:"is_delete"`
+		CreatedBy             uuid.UUID       `json:"created_by"`
+		CreatedAt             time.Time       `json:"created_at"`
+		UpdatedBy             uuid.UUID       `json:"updated_by"`
+		UpdatedAt             time.Time       `json:"updated_at"`
 	}
 )
 
 func (s ConfigurationModel) Response() ConfigurationResponse {
 	return ConfigurationResponse{
-		ID:          s.ID,
-		DeliveryFee: s.DeliveryFee,
-		IsDelete:    s.IsDelete,
-		CreatedBy:   s.CreatedBy,
-		CreatedAt:   s.CreatedAt,
-		UpdatedBy:   s.UpdatedBy.UUID,
-		UpdatedAt:   s.UpdatedAt.Time,
+		ID:                    s.ID,
+		DeliveryFee:           s.DeliveryFee,
+		DeliveryFeePerKm:      s.DeliveryFeePerKm,
+		FreeDeliveryThreshold: s.FreeDeliveryThreshold,
+		IsDelete:              s.IsDelete,
+		CreatedBy:             s.CreatedBy,
+		CreatedAt:             s.CreatedAt,
+		UpdatedBy:             s.UpdatedBy.UUID,
+		UpdatedAt:             s.UpdatedAt.Time,
 	}
 }
 
-func GetConfiguration(ctx context.Context, db *sql.DB) (ConfigurationModel, error) {
+func GetConfiguration(ctx context.Context, db helpers.DBExecutor) (ConfigurationModel, error) {
 
 	query := fmt.Sprintf(`
 		SELECT
 			id,
 			delivery_fee,
+			delivery_fee_per_km,
+			free_delivery_threshold,
 			is_delete,
 			created_by,
 			created_at,
@@ -63,6 +72,8 @@ func GetConfiguration(ctx context.Context, db *sql.DB) (ConfigurationModel, erro
 	err := db.QueryRowContext(ctx, query).Scan(
 		&configuration.ID,
 		&configuration.DeliveryFee,
+		&configuration.DeliveryFeePerKm,
+		&configuration.FreeDeliveryThreshold,
 		&configuration.IsDelete,
 		&configuration.CreatedBy,
 		&configuration.CreatedAt,
@@ -83,13 +94,23 @@ func (s *ConfigurationModel) Update(ctx context.Context, db *sql.DB) error {
 	query := fmt.Sprintf(`
 		UPDATE configuration
 		SET
-			delivery_fee=$1
+			delivery_fee=$1,
+			delivery_fee_per_km=$2,
+			free_delivery_threshold=$3,
 			updated_at=NOW(),
-			updated_by=$2
+			updated_by=$4
+		RETURNING
+			id,is_delete,created_by,created_at,updated_at
 		`)
 
-	db.QueryRowContext(ctx, query,
-		s.DeliveryFee, s.UpdatedBy)
+	err := db.QueryRowContext(ctx, query,
+		s.DeliveryFee, s.DeliveryFeePerKm, s.FreeDeliveryThreshold, s.UpdatedBy).Scan(
+		&s.ID, &s.IsDelete, &s.CreatedBy, &s.CreatedAt, &s.UpdatedAt,
+	)
+
+	if err != nil {
+		return err
+	}
 
 	return nil
 
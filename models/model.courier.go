@@ -13,45 +13,48 @@ import (
 
 type (
 	CourierModel struct {
-		ID        uuid.UUID
-		Name      string
-		Address   string
-		PhoneNo   string
-		Email     string
-		Password  string
-		IsActive  bool
-		CreatedBy uuid.UUID
-		CreatedAt time.Time
-		UpdatedBy uuid.NullUUID
-		UpdatedAt pq.NullTime
+		ID          uuid.UUID
+		Name        string
+		Address     string
+		PhoneNo     string
+		Email       string
+		Password    string
+		IsActive    bool
+		WarehouseID uuid.NullUUID
+		CreatedBy   uuid.UUID
+		CreatedAt   time.Time
+		UpdatedBy   uuid.NullUUID
+		UpdatedAt   pq.NullTime
 	}
 
 	CourierResponse struct {
-		ID        uuid.UUID `json:"id"`
-		Name      string    `json:"name"`
-		Address   string    `json:"address"`
-		PhoneNo   string    `json:"phone_no"`
-		Email     string    `json:"email"`
-		IsActive  bool      `json:"is_active"`
-		CreatedBy uuid.UUID `json:"created_by"`
-		CreatedAt time.Time `json:"created_at"`
-		UpdatedBy uuid.UUID `json:"updated_by"`
-		UpdatedAt time.Time `json:"updated_at"`
+		ID          uuid.UUID `json:"id"`
+		Name        string    `json:"name"`
+		Address     string    `json:"address"`
+		PhoneNo     string    `json:"phone_no"`
+		Email       string    `json:"email"`
+		IsActive    bool      `json:"is_active"`
+		WarehouseID uuid.UUID `json:"warehouse_id"`
+		CreatedBy   uuid.UUID `json:"created_by"`
+		CreatedAt   time.Time `json:"created_at"`
+		UpdatedBy   uuid.UUID `json:"updated_by"`
+		UpdatedAt   time.Time `json:"updated_at"`
 	}
 )
 
 func (s CourierModel) Response() CourierResponse {
 	return CourierResponse{
-		ID:        s.ID,
-		Name:      s.Name,
-		Address:   s.Address,
-		PhoneNo:   s.PhoneNo,
-		Email:     s.Email,
-		IsActive:  s.IsActive,
-		CreatedBy: s.CreatedBy,
-		CreatedAt: s.CreatedAt,
-		UpdatedBy: s.UpdatedBy.UUID,
-		UpdatedAt: s.UpdatedAt.Time,
+		ID:          s.ID,
+		Name:        s.Name,
+		Address:     s.Address,
+		PhoneNo:     s.PhoneNo,
+		Email:       s.Email,
+		IsActive:    s.IsActive,
+		WarehouseID: s.WarehouseID.UUID,
+		CreatedBy:   s.CreatedBy,
+		CreatedAt:   s.CreatedAt,
+		UpdatedBy:   s.UpdatedBy.UUID,
+		UpdatedAt:   s.UpdatedAt.Time,
 	}
 }
 
@@ -66,6 +69,7 @@ func GetOneCourier(ctx context.Context, db *sql.DB, courierID uuid.UUID) (Courie
 			password,
 			phone_no,
 			is_active,
+			warehouse_id,
 			created_by,
 			created_at,
 			updated_by,
@@ -85,6 +89,56 @@ func GetOneCourier(ctx context.Context, db *sql.DB, courierID uuid.UUID) (Courie
 		&courier.Password,
 		&courier.PhoneNo,
 		&courier.IsActive,
+		&courier.WarehouseID,
+		&courier.CreatedBy,
+		&courier.CreatedAt,
+		&courier.UpdatedBy,
+		&courier.UpdatedAt,
+	)
+
+	if err != nil {
+		return CourierModel{}, err
+	}
+
+	return courier, nil
+
+}
+
+// GetOneCourierForUpdate locks the courier row until the transaction ends, so shipments are handed to a courier
+// one at a time.
+func GetOneCourierForUpdate(ctx context.Context, db helpers.DBExecutor, courierID uuid.UUID) (CourierModel, error) {
+
+	query := fmt.Sprintf(`
+		SELECT
+			id,
+			name,
+			email,
+			address,
+			password,
+			phone_no,
+			is_active,
+			warehouse_id,
+			created_by,
+			created_at,
+			updated_by,
+			updated_at
+		FROM 
+			courier
+		WHERE 
+			id = $1
+		FOR UPDATE
+	`)
+
+	var courier CourierModel
+	err := db.QueryRowContext(ctx, query, courierID).Scan(
+		&courier.ID,
+		&courier.Name,
+		&courier.Email,
+		&courier.Address,
+		&courier.Password,
+		&courier.PhoneNo,
+		&courier.IsActive,
+		&courier.WarehouseID,
 		&courier.CreatedBy,
 		&courier.CreatedAt,
 		&courier.UpdatedBy,
@@ -116,6 +170,7 @@ func GetAllCourier(ctx context.Context, db *sql.DB, filter helpers.Filter) ([]Co
 			password,
 			phone_no,
 			is_active,
+			warehouse_id,
 			created_by,
 			created_at,
 			updated_by,
@@ -148,6 +203,65 @@ func GetAllCourier(ctx context.Context, db *sql.DB, filter helpers.Filter) ([]Co
 			&courier.Password,
 			&courier.PhoneNo,
 			&courier.IsActive,
+			&courier.WarehouseID,
+			&courier.CreatedBy,
+			&courier.CreatedAt,
+			&courier.UpdatedBy,
+			&courier.UpdatedAt,
+		)
+
+		couriers = append(couriers, courier)
+	}
+
+	return couriers, nil
+
+}
+
+func GetAllActiveCourier(ctx context.Context, db helpers.DBExecutor) ([]CourierModel, error) {
+
+	query := fmt.Sprintf(`
+		SELECT
+			id,
+			name,
+			address,
+			email,
+			password,
+			phone_no,
+			is_active,
+			warehouse_id,
+			created_by,
+			created_at,
+			updated_by,
+			updated_at
+		FROM
+			courier
+		WHERE
+			is_active = true
+		ORDER BY
+			created_at
+	`)
+
+	rows, err := db.QueryContext(ctx, query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var couriers []CourierModel
+	for rows.Next() {
+		var courier CourierModel
+
+		rows.Scan(
+			&courier.ID,
+			&courier.Name,
+			&courier.Address,
+			&courier.Email,
+			&courier.Password,
+			&courier.PhoneNo,
+			&courier.IsActive,
+			&courier.WarehouseID,
 			&courier.CreatedBy,
 			&courier.CreatedAt,
 			&courier.UpdatedBy,
@@ -172,6 +286,7 @@ func GetOneCourierByEmail(ctx context.Context, db *sql.DB, email string) (Courie
 			password,
 			phone_no,
 			is_active,
+			warehouse_id,
 			created_by,
 			created_at,
 			updated_by,
@@ -193,6 +308,7 @@ func GetOneCourierByEmail(ctx context.Context, db *sql.DB, email string) (Courie
 		&courier.Password,
 		&courier.PhoneNo,
 		&courier.IsActive,
+		&courier.WarehouseID,
 		&courier.CreatedBy,
 		&courier.CreatedAt,
 		&courier.UpdatedBy,
@@ -207,7 +323,7 @@ func GetOneCourierByEmail(ctx context.Context, db *sql.DB, email string) (Courie
 
 }
 
-func (s *CourierModel) Insert(ctx context.Context, db *sql.DB) error {
+func (s *CourierModel) Insert(ctx context.Context, db helpers.DBExecutor) error {
 
 	password, err := bcrypt.GenerateFromPassword([]byte(s.Password), 12)
 
@@ -222,16 +338,17 @@ func (s *CourierModel) Insert(ctx context.Context, db *sql.DB) error {
 			email,
 			password,
 			phone_no,
+			warehouse_id,
 			created_by,
 			created_at
 		)VALUES(
-			$1,$2,$3,$4,$5,$6,now())
+			$1,$2,$3,$4,$5,$6,$7,now())
 		RETURNING 
 			id, created_at,is_active
 	`)
 
 	err = db.QueryRowContext(ctx, query,
-		s.Name, s.Address, s.Email, password, s.PhoneNo, s.CreatedBy).Scan(
+		s.Name, s.Address, s.Email, password, s.PhoneNo, s.WarehouseID, s.CreatedBy).Scan(
 		&s.ID, &s.CreatedAt, &s.IsActive,
 	)
 
@@ -250,17 +367,18 @@ func (s *CourierModel) Update(ctx context.Context, db *sql.DB) error {
 		SET
 			name=$1,
 			phone_no=$2,
-			address=$3
+			address=$3,
+			warehouse_id=$4,
 			updated_at=NOW(),
-			updated_by=$4
+			updated_by=$5
 		WHERE 
-			id=$5
+			id=$6
 		RETURNING 
 			id,created_at,updated_at,created_by,is_active,email
 	`)
 
 	err := db.QueryRowContext(ctx, query,
-		s.Name, s.PhoneNo, s.Address, s.UpdatedBy, s.ID).Scan(
+		s.Name, s.PhoneNo, s.Address, s.WarehouseID, s.UpdatedBy, s.ID).Scan(
 		&s.ID, &s.CreatedAt, &s.UpdatedAt, &s.CreatedBy, &s.IsActive, &s.Email,
 	)
 
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"afiqo-location/helpers"
+	"context"
+	"fmt"
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+type (
+	CourierLocationModel struct {
+		ID         uuid.UUID
+		CourierID  uuid.UUID
+		Latitude   decimal.Decimal
+		Longitude  decimal.Decimal
+		Accuracy   decimal.Decimal
+		RecordedAt time.Time
+		CreatedAt  time.Time
+	}
+
+	CourierLocationResponse struct {
+		CourierID  uuid.UUID       `json:"courier_id"`
+		Latitude   decimal.Decimal `json:"latitude"`
+		Longitude  decimal.Decimal `json:"longitude"`
+		Accuracy   decimal.Decimal `json:"accuracy"`
+		RecordedAt time.Time       `json:"recorded_at"`
+	}
+)
+
+func (s CourierLocationModel) Response() CourierLocationResponse {
+	return CourierLocationResponse{
+		CourierID:  s.CourierID,
+		Latitude:   s.Latitude,
+		Longitude:  s.Longitude,
+		Accuracy:   s.Accuracy,
+		RecordedAt: s.RecordedAt,
+	}
+}
+
+func GetLatestCourierLocation(ctx context.Context, db helpers.DBExecutor, courierID uuid.UUID) (
+	CourierLocationModel, error) {
+
+	query := fmt.Sprintf(`
+		SELECT
+			id,
+			courier_id,
+			latitude,
+			longitude,
+			accuracy,
+			recorded_at,
+			created_at
+		FROM
+			courier_location
+		WHERE
+			courier_id = $1
+		ORDER BY
+			recorded_at DESC
+		LIMIT 1
+	`)
+
+	var courierLocation CourierLocationModel
+
+	err := db.QueryRowContext(ctx, query, courierID).Scan(
+		&courierLocation.ID,
+		&courierLocation.CourierID,
+		&courierLocation.Latitude,
+		&courierLocation.Longitude,
+		&courierLocation.Accuracy,
+		&courierLocation.RecordedAt,
+		&courierLocation.CreatedAt,
+	)
+
+	if err != nil {
+		return CourierLocationModel{}, err
+	}
+
+	return courierLocation, nil
+
+}
+
+func (s *CourierLocationModel) Insert(ctx context.Context, db helpers.DBExecutor) error {
+
+	query := fmt.Sprintf(`
+		INSERT INTO courier_location(
+			courier_id,
+			latitude,
+			longitude,
+			accuracy,
+			recorded_at,
+			created_at)
+		VALUES(
+			$1,$2,$3,$4,$5,now())
+		RETURNING
+			id, created_at
+	`)
+
+	err := db.QueryRowContext(ctx, query,
+		s.CourierID, s.Latitude, s.Longitude, s.Accuracy, s.RecordedAt).Scan(
+		&s.ID, &s.CreatedAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
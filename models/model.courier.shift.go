@@ -0,0 +1,271 @@
+package models
+
+import (
+	"afiqo-location/helpers"
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+type (
+	CourierShiftModel struct {
+		ID          uuid.UUID
+		CourierID   uuid.UUID
+		WarehouseID uuid.NullUUID
+		Weekday     int
+		StartTime   string
+		EndTime     string
+		MaxParcels  int
+		IsDelete    bool
+		CreatedBy   uuid.UUID
+		CreatedAt   time.Time
+		UpdatedBy   uuid.NullUUID
+		UpdatedAt   pq.NullTime
+	}
+
+	CourierShiftResponse struct {
+		ID          uuid.UUID `json:"id"`
+		CourierID   uuid.UUID `json:"courier_id"`
+		WarehouseID uuid.UUID `json:"warehouse_id"`
+		Weekday     int       `json:"weekday"`
+		StartTime   string    `json:"start_time"`
+		EndTime     string    `json:"end_time"`
+		MaxParcels  int       `json:"max_parcels"`
+		IsDelete    bool      `json:"is_delete"`
+		CreatedBy   uuid.UUID `json:"created_by"`
+		CreatedAt   time.Time `json:"created_at"`
+		UpdatedBy   uuid.UUID `json:"updated_by"`
+		UpdatedAt   time.Time `json:"updated_at"`
+	}
+)
+
+func (s CourierShiftModel) Response() CourierShiftResponse {
+	return CourierShiftResponse{
+		ID:          s.ID,
+		CourierID:   s.CourierID,
+		WarehouseID: s.WarehouseID.UUID,
+		Weekday:     s.Weekday,
+		StartTime:   s.StartTime,
+		EndTime:     s.EndTime,
+		MaxParcels:  s.MaxParcels,
+		IsDelete:    s.IsDelete,
+		CreatedBy:   s.CreatedBy,
+		CreatedAt:   s.CreatedAt,
+		UpdatedBy:   s.UpdatedBy.UUID,
+		UpdatedAt:   s.UpdatedAt.Time,
+	}
+}
+
+func GetOneCourierShift(ctx context.Context, db helpers.DBExecutor, shiftID uuid.UUID) (CourierShiftModel, error) {
+
+	query := fmt.Sprintf(`
+		SELECT
+			id,
+			courier_id,
+			warehouse_id,
+			weekday,
+			TO_CHAR(start_time, 'HH24:MI'),
+			TO_CHAR(end_time, 'HH24:MI'),
+			max_parcels,
+			is_delete,
+			created_by,
+			created_at,
+			updated_by,
+			updated_at
+		FROM
+			courier_shift
+		WHERE
+			id = $1
+		AND
+			is_delete = false
+	`)
+
+	var shift CourierShiftModel
+	err := db.QueryRowContext(ctx, query, shiftID).Scan(
+		&shift.ID,
+		&shift.CourierID,
+		&shift.WarehouseID,
+		&shift.Weekday,
+		&shift.StartTime,
+		&shift.EndTime,
+		&shift.MaxParcels,
+		&shift.IsDelete,
+		&shift.CreatedBy,
+		&shift.CreatedAt,
+		&shift.UpdatedBy,
+		&shift.UpdatedAt,
+	)
+
+	if err != nil {
+		return CourierShiftModel{}, err
+	}
+
+	return shift, nil
+
+}
+
+// GetAllCourierShift lists shifts by weekday and start time, for one courier when filter.CourierID is set.
+func GetAllCourierShift(ctx context.Context, db helpers.DBExecutor, filter helpers.Filter) (
+	[]CourierShiftModel, error) {
+
+	var courierQuery string
+
+	if filter.CourierID != uuid.Nil {
+		courierQuery = fmt.Sprintf(`AND courier_id = '%s'`, filter.CourierID)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			id,
+			courier_id,
+			warehouse_id,
+			weekday,
+			TO_CHAR(start_time, 'HH24:MI'),
+			TO_CHAR(end_time, 'HH24:MI'),
+			max_parcels,
+			is_delete,
+			created_by,
+			created_at,
+			updated_by,
+			updated_at
+		FROM
+			courier_shift
+		WHERE
+			is_delete = false
+		%s
+		ORDER BY
+			weekday, start_time
+		LIMIT $1 OFFSET $2`,
+		courierQuery)
+
+	// A zero limit means every shift, LIMIT NULL is LIMIT ALL in postgres.
+	limit := sql.NullInt64{
+		Int64: int64(filter.Limit),
+		Valid: filter.Limit > 0,
+	}
+
+	rows, err := db.QueryContext(ctx, query, limit, filter.Offset)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var shifts []CourierShiftModel
+	for rows.Next() {
+		var shift CourierShiftModel
+
+		rows.Scan(
+			&shift.ID,
+			&shift.CourierID,
+			&shift.WarehouseID,
+			&shift.Weekday,
+			&shift.StartTime,
+			&shift.EndTime,
+			&shift.MaxParcels,
+			&shift.IsDelete,
+			&shift.CreatedBy,
+			&shift.CreatedAt,
+			&shift.UpdatedBy,
+			&shift.UpdatedAt,
+		)
+
+		shifts = append(shifts, shift)
+	}
+
+	return shifts, nil
+
+}
+
+func (s *CourierShiftModel) Insert(ctx context.Context, db helpers.DBExecutor) error {
+
+	query := fmt.Sprintf(`
+		INSERT INTO courier_shift(
+			courier_id,
+			warehouse_id,
+			weekday,
+			start_time,
+			end_time,
+			max_parcels,
+			created_by,
+			created_at)
+		VALUES(
+			$1,$2,$3,$4,$5,$6,$7,now())
+		RETURNING
+			id, created_at
+	`)
+
+	err := db.QueryRowContext(ctx, query,
+		s.CourierID, s.WarehouseID, s.Weekday, s.StartTime, s.EndTime, s.MaxParcels, s.CreatedBy).Scan(
+		&s.ID, &s.CreatedAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+func (s *CourierShiftModel) Update(ctx context.Context, db helpers.DBExecutor) error {
+
+	query := fmt.Sprintf(`
+		UPDATE courier_shift
+		SET
+			warehouse_id=$1,
+			weekday=$2,
+			start_time=$3,
+			end_time=$4,
+			max_parcels=$5,
+			updated_at=NOW(),
+			updated_by=$6
+		WHERE
+			id=$7
+		AND
+			is_delete=false
+		RETURNING
+			courier_id,created_at,updated_at,created_by
+	`)
+
+	err := db.QueryRowContext(ctx, query,
+		s.WarehouseID, s.Weekday, s.StartTime, s.EndTime, s.MaxParcels, s.UpdatedBy, s.ID).Scan(
+		&s.CourierID, &s.CreatedAt, &s.UpdatedAt, &s.CreatedBy,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+func (s *CourierShiftModel) Delete(ctx context.Context, db helpers.DBExecutor) error {
+
+	query := fmt.Sprintf(`
+		UPDATE courier_shift
+		SET
+			is_delete=true,
+			updated_by=$1,
+			updated_at=NOW()
+		WHERE
+			id=$2
+		AND
+			is_delete=false
+		RETURNING
+			id
+	`)
+
+	err := db.QueryRowContext(ctx, query, s.UpdatedBy, s.ID).Scan(&s.ID)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
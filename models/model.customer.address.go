@@ -0,0 +1,278 @@
+package models
+
+import (
+	"afiqo-location/helpers"
+	"context"
+	"fmt"
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+type (
+	CustomerAddressModel struct {
+		ID              uuid.UUID
+		CustomerID      uuid.UUID
+		Label           string
+		Address         string
+		Latitude        decimal.Decimal
+		Longitude       decimal.Decimal
+		AddressMismatch bool
+		IsDefault       bool
+		IsDelete        bool
+		CreatedAt       time.Time
+		UpdatedAt       pq.NullTime
+	}
+
+	CustomerAddressResponse struct {
+		ID              uuid.UUID       `json:"id"`
+		CustomerID      uuid.UUID       `json:"customer_id"`
+		Label           string          `json:"label"`
+		Address         string          `json:"address"`
+		Latitude        decimal.Decimal `json:"latitude"`
+		Longitude       decimal.Decimal `json:"longitude"`
+		AddressMismatch bool            `json:"address_mismatch"`
+		IsDefault       bool            `json:"is_default"`
+		CreatedAt       time.Time       `json:"created_at"`
+		UpdatedAt       time.Time       `json:"updated_at"`
+	}
+)
+
+func (s CustomerAddressModel) Response() CustomerAddressResponse {
+	return CustomerAddressResponse{
+		ID:              s.ID,
+		CustomerID:      s.CustomerID,
+		Label:           s.Label,
+		Address:         s.Address,
+		Latitude:        s.Latitude,
+		Longitude:       s.Longitude,
+		AddressMismatch: s.AddressMismatch,
+		IsDefault:       s.IsDefault,
+		CreatedAt:       s.CreatedAt,
+		UpdatedAt:       s.UpdatedAt.Time,
+	}
+}
+
+// GetOneCustomerAddress only finds addresses that belong to the customer and have not been deleted.
+func GetOneCustomerAddress(ctx context.Context, db helpers.DBExecutor, addressID, customerID uuid.UUID) (
+	CustomerAddressModel, error) {
+
+	query := fmt.Sprintf(`
+		SELECT
+			id,
+			customer_id,
+			label,
+			address,
+			latitude,
+			longitude,
+			address_mismatch,
+			is_default,
+			is_delete,
+			created_at,
+			updated_at
+		FROM customer_address
+		WHERE
+			id = $1
+		AND
+			customer_id = $2
+		AND
+			is_delete = false
+	`)
+
+	var address CustomerAddressModel
+	err := db.QueryRowContext(ctx, query, addressID, customerID).Scan(
+		&address.ID,
+		&address.CustomerID,
+		&address.Label,
+		&address.Address,
+		&address.Latitude,
+		&address.Longitude,
+		&address.AddressMismatch,
+		&address.IsDefault,
+		&address.IsDelete,
+		&address.CreatedAt,
+		&address.UpdatedAt,
+	)
+
+	if err != nil {
+		return CustomerAddressModel{}, err
+	}
+
+	return address, nil
+
+}
+
+func GetAllCustomerAddressByCustomerID(ctx context.Context, db helpers.DBExecutor, customerID uuid.UUID) (
+	[]CustomerAddressModel, error) {
+
+	query := fmt.Sprintf(`
+		SELECT
+			id,
+			customer_id,
+			label,
+			address,
+			latitude,
+			longitude,
+			address_mismatch,
+			is_default,
+			is_delete,
+			created_at,
+			updated_at
+		FROM customer_address
+		WHERE
+			customer_id = $1
+		AND
+			is_delete = false
+		ORDER BY
+			is_default DESC, created_at
+	`)
+
+	rows, err := db.QueryContext(ctx, query, customerID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var addresses []CustomerAddressModel
+	for rows.Next() {
+		var address CustomerAddressModel
+
+		rows.Scan(
+			&address.ID,
+			&address.CustomerID,
+			&address.Label,
+			&address.Address,
+			&address.Latitude,
+			&address.Longitude,
+			&address.AddressMismatch,
+			&address.IsDefault,
+			&address.IsDelete,
+			&address.CreatedAt,
+			&address.UpdatedAt,
+		)
+
+		addresses = append(addresses, address)
+	}
+
+	return addresses, nil
+
+}
+
+// ClearDefaultCustomerAddress unsets the customer's default address so another one can take its place.
+func ClearDefaultCustomerAddress(ctx context.Context, db helpers.DBExecutor, customerID uuid.UUID) error {
+
+	query := fmt.Sprintf(`
+		UPDATE customer_address
+		SET
+			is_default=false,
+			updated_at=NOW()
+		WHERE
+			customer_id=$1
+		AND
+			is_default=true
+	`)
+
+	_, err := db.ExecContext(ctx, query, customerID)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *CustomerAddressModel) Insert(ctx context.Context, db helpers.DBExecutor) error {
+
+	query := fmt.Sprintf(`
+		INSERT INTO customer_address(
+			customer_id,
+			label,
+			address,
+			latitude,
+			longitude,
+			address_mismatch,
+			is_default,
+			created_at)
+		VALUES(
+			$1,$2,$3,$4,$5,$6,$7,now())
+		RETURNING
+			id, created_at
+	`)
+
+	err := db.QueryRowContext(ctx, query,
+		s.CustomerID, s.Label, s.Address, s.Latitude, s.Longitude, s.AddressMismatch, s.IsDefault).Scan(
+		&s.ID, &s.CreatedAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+func (s *CustomerAddressModel) Update(ctx context.Context, db helpers.DBExecutor) error {
+
+	query := fmt.Sprintf(`
+		UPDATE customer_address
+		SET
+			label=$1,
+			address=$2,
+			latitude=$3,
+			longitude=$4,
+			address_mismatch=$5,
+			is_default=$6,
+			updated_at=NOW()
+		WHERE
+			id=$7
+		AND
+			customer_id=$8
+		AND
+			is_delete=false
+		RETURNING
+			created_at,updated_at
+	`)
+
+	err := db.QueryRowContext(ctx, query,
+		s.Label, s.Address, s.Latitude, s.Longitude, s.AddressMismatch, s.IsDefault, s.ID, s.CustomerID).Scan(
+		&s.CreatedAt, &s.UpdatedAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+func (s *CustomerAddressModel) Delete(ctx context.Context, db helpers.DBExecutor) error {
+
+	query := fmt.Sprintf(`
+		UPDATE customer_address
+		SET
+			is_delete=true,
+			is_default=false,
+			updated_at=NOW()
+		WHERE
+			id=$1
+		AND
+			customer_id=$2
+		AND
+			is_delete=false
+		RETURNING
+			id
+	`)
+
+	err := db.QueryRowContext(ctx, query, s.ID, s.CustomerID).Scan(&s.ID)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
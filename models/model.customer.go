@@ -66,7 +66,7 @@ func (s CustomerModel) Response() CustomerResponse {
 
 }
 
-func GetOneCustomer(ctx context.Context, db *sql.DB, customerID uuid.UUID) (CustomerModel, error) {
+func GetOneCustomer(ctx context.Context, db helpers.DBExecutor, customerID uuid.UUID) (CustomerModel, error) {
 
 	query := fmt.Sprintf(`
 		SELECT
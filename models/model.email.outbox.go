@@ -0,0 +1,157 @@
+package models
+
+import (
+	"afiqo-location/helpers"
+	"afiqo-location/util"
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+type EmailOutboxModel struct {
+	ID            uuid.UUID
+	Recipient     string
+	Subject       string
+	Body          string
+	Status        int
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     sql.NullString
+	SentAt        pq.NullTime
+	CreatedBy     uuid.UUID
+	CreatedAt     time.Time
+	UpdatedAt     pq.NullTime
+}
+
+// ClaimDueEmailOutbox picks pending mail that is due and pushes its next attempt back by lease, so other
+// workers leave it alone while it is being sent. Mail whose worker died is picked up again after the lease.
+func ClaimDueEmailOutbox(ctx context.Context, db helpers.DBExecutor, lease time.Duration, limit int) (
+	[]EmailOutboxModel, error) {
+
+	query := fmt.Sprintf(`
+		UPDATE email_outbox
+		SET
+			next_attempt_at = NOW() + $1 * INTERVAL '1 second',
+			updated_at = NOW()
+		WHERE
+			id IN (
+				SELECT
+					id
+				FROM
+					email_outbox
+				WHERE
+					status = $2
+				AND
+					next_attempt_at <= NOW()
+				ORDER BY
+					next_attempt_at ASC
+				LIMIT $3
+				FOR UPDATE SKIP LOCKED)
+		RETURNING
+			id,
+			recipient,
+			subject,
+			body,
+			status,
+			attempts,
+			next_attempt_at,
+			last_error,
+			sent_at,
+			created_by,
+			created_at,
+			updated_at
+	`)
+
+	rows, err := db.QueryContext(ctx, query, int(lease.Seconds()), util.EmailStatusPending, limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var mails []EmailOutboxModel
+	for rows.Next() {
+		var mail EmailOutboxModel
+
+		rows.Scan(
+			&mail.ID,
+			&mail.Recipient,
+			&mail.Subject,
+			&mail.Body,
+			&mail.Status,
+			&mail.Attempts,
+			&mail.NextAttemptAt,
+			&mail.LastError,
+			&mail.SentAt,
+			&mail.CreatedBy,
+			&mail.CreatedAt,
+			&mail.UpdatedAt,
+		)
+
+		mails = append(mails, mail)
+	}
+
+	return mails, nil
+}
+
+func (s *EmailOutboxModel) Insert(ctx context.Context, db helpers.DBExecutor) error {
+
+	query := fmt.Sprintf(`
+		INSERT INTO email_outbox(
+			recipient,
+			subject,
+			body,
+			created_by,
+			created_at)
+		VALUES(
+			$1,$2,$3,$4,now())
+		RETURNING
+			id, status, attempts, next_attempt_at, created_at
+	`)
+
+	err := db.QueryRowContext(ctx, query,
+		s.Recipient, s.Subject, s.Body, s.CreatedBy).Scan(
+		&s.ID, &s.Status, &s.Attempts, &s.NextAttemptAt, &s.CreatedAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateAttempt records the outcome of one send: s.Status, s.Attempts, s.NextAttemptAt and s.LastError as
+// set by the caller, with sent_at stamped once the mail is sent.
+func (s *EmailOutboxModel) UpdateAttempt(ctx context.Context, db helpers.DBExecutor) error {
+
+	query := fmt.Sprintf(`
+		UPDATE email_outbox
+		SET
+			status = $1,
+			attempts = $2,
+			next_attempt_at = $3,
+			last_error = $4,
+			sent_at = CASE WHEN $1 = $5 THEN NOW() ELSE sent_at END,
+			updated_at = NOW()
+		WHERE
+			id = $6
+		RETURNING
+			sent_at, updated_at
+	`)
+
+	err := db.QueryRowContext(ctx, query,
+		s.Status, s.Attempts, s.NextAttemptAt, s.LastError, util.EmailStatusSent, s.ID).Scan(
+		&s.SentAt, &s.UpdatedAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"afiqo-location/helpers"
+	"context"
+	"fmt"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+type InvoiceModel struct {
+	ID        uuid.UUID
+	OrderID   uuid.UUID
+	Number    int64
+	CreatedBy uuid.UUID
+	CreatedAt time.Time
+}
+
+func GetOneInvoiceByOrderID(ctx context.Context, db helpers.DBExecutor, orderID uuid.UUID) (InvoiceModel, error) {
+
+	query := fmt.Sprintf(`
+		SELECT
+			id,
+			order_id,
+			number,
+			created_by,
+			created_at
+		FROM
+			invoice
+		WHERE
+			order_id = $1
+	`)
+
+	var invoice InvoiceModel
+	err := db.QueryRowContext(ctx, query, orderID).Scan(
+		&invoice.ID,
+		&invoice.OrderID,
+		&invoice.Number,
+		&invoice.CreatedBy,
+		&invoice.CreatedAt,
+	)
+
+	if err != nil {
+		return InvoiceModel{}, err
+	}
+
+	return invoice, nil
+}
+
+// Insert takes the next invoice number. The table is locked until the transaction ends so two invoices can
+// never take the same number or leave a gap. It fails with sql.ErrNoRows when the order already has one.
+func (s *InvoiceModel) Insert(ctx context.Context, db helpers.DBExecutor) error {
+
+	_, err := db.ExecContext(ctx, `LOCK TABLE invoice IN SHARE ROW EXCLUSIVE MODE`)
+
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO invoice(
+			order_id,
+			number,
+			created_by,
+			created_at)
+		SELECT
+			$1, COALESCE(MAX(number), 0) + 1, $2, now()
+		FROM
+			invoice
+		HAVING
+			NOT EXISTS (SELECT 1 FROM invoice WHERE order_id = $1)
+		RETURNING
+			id, number, created_at
+	`)
+
+	err = db.QueryRowContext(ctx, query,
+		s.OrderID, s.CreatedBy).Scan(
+		&s.ID, &s.Number, &s.CreatedAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
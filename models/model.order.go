@@ -24,6 +24,9 @@ type (
 		Longitude        decimal.Decimal
 		Status           int
 		TotalPrice       decimal.Decimal
+		Distance         decimal.Decimal
+		DeliveryFee      decimal.Decimal
+		AddressMismatch  bool
 		IsDelete         bool
 		CreatedBy        uuid.UUID
 		CreatedAt        time.Time
@@ -32,20 +35,24 @@ type (
 	}
 
 	OrderResponse struct {
-		ID               uuid.UUID         `json:"id"`
-		Customer         CustomerResponse  `json:"customer"`
-		Warehouse        WarehouseResponse `json:"warehouse"`
-		DeliveryDatetime time.Time         `json:"delivery_datetime"`
-		DeliveryAddress  string            `json:"delivery_address"`
-		Latitude         decimal.Decimal   `json:"latitude"`
-		Longitude        decimal.Decimal   `json:"longitude"`
-		Status           string            `json:"status"`
-		TotalPrice       decimal.Decimal   `json:"total_price"`
-		IsDelete         bool              `json:"is_delete"`
-		CreatedBy        uuid.UUID         `json:"created_by"`
-		CreatedAt        time.Time         `json:"created_at"`
-		UpdatedBy        uuid.UUID         `json:"updated_by"`
-		UpdatedAt        time.Time         `json:"updated_at"`
+		ID               uuid.UUID           `json:"id"`
+		Customer         CustomerResponse    `json:"customer"`
+		Warehouse        WarehouseResponse   `json:"warehouse"`
+		Warehouses       []WarehouseResponse `json:"warehouses"`
+		DeliveryDatetime time.Time           `json:"delivery_datetime"`
+		DeliveryAddress  string              `json:"delivery_address"`
+		Latitude         decimal.Decimal     `json:"latitude"`
+		Longitude        decimal.Decimal     `json:"longitude"`
+		Status           string              `json:"status"`
+		TotalPrice       decimal.Decimal     `json:"total_price"`
+		Distance         decimal.Decimal     `json:"distance"`
+		DeliveryFee      decimal.Decimal     `json:"delivery_fee"`
+		AddressMismatch  bool                `json:"address_mismatch"`
+		IsDelete         bool                `json:"is_delete"`
+		CreatedBy        uuid.UUID           `json:"created_by"`
+		CreatedAt        time.Time           `json:"created_at"`
+		UpdatedBy        uuid.UUID           `json:"updated_by"`
+		UpdatedAt        time.Time           `json:"updated_at"`
 	}
 )
 
@@ -63,18 +70,33 @@ func (s OrderModel) Response(ctx context.Context, db *sql.DB, logger *helpers.Lo
 		return OrderResponse{}, nil
 	}
 
+	warehouses, err := GetAllWarehouseByOrderID(ctx, db, s.ID)
+	if err != nil {
+		logger.Err.Printf(`model.order.go/GetAllWarehouseByOrderID/%v`, err)
+		return OrderResponse{}, nil
+	}
+
+	var warehouseResponses []WarehouseResponse
+	for _, warehouse := range warehouses {
+		warehouseResponses = append(warehouseResponses, warehouse.Response())
+	}
+
 	status := util.GetOrderStatus(s.Status)
 
 	return OrderResponse{
 		ID:               s.ID,
 		Customer:         customer.Response(),
 		Warehouse:        warehouse.Response(),
+		Warehouses:       warehouseResponses,
 		DeliveryDatetime: s.DeliveryDatetime,
 		DeliveryAddress:  s.DeliveryAddress,
 		Longitude:        s.Longitude,
 		Latitude:         s.Latitude,
 		Status:           status,
 		TotalPrice:       s.TotalPrice,
+		Distance:         s.Distance,
+		DeliveryFee:      s.DeliveryFee,
+		AddressMismatch:  s.AddressMismatch,
 		IsDelete:         s.IsDelete,
 		CreatedBy:        s.CreatedBy,
 		CreatedAt:        s.CreatedAt,
@@ -84,7 +106,7 @@ func (s OrderModel) Response(ctx context.Context, db *sql.DB, logger *helpers.Lo
 
 }
 
-func GetOneOrder(ctx context.Context, db *sql.DB, orderID uuid.UUID) (OrderModel, error) {
+func GetOneOrder(ctx context.Context, db helpers.DBExecutor, orderID uuid.UUID) (OrderModel, error) {
 
 	query := fmt.Sprintf(`
 		SELECT
@@ -97,6 +119,9 @@ func GetOneOrder(ctx context.Context, db *sql.DB, orderID uuid.UUID) (OrderModel
 			longitude,
 			status,
 			total_price,
+			distance,
+			delivery_fee,
+			address_mismatch,
 			is_delete,
 			created_by,
 			created_at,
@@ -119,6 +144,9 @@ func GetOneOrder(ctx context.Context, db *sql.DB, orderID uuid.UUID) (OrderModel
 		&order.Longitude,
 		&order.Status,
 		&order.TotalPrice,
+		&order.Distance,
+		&order.DeliveryFee,
+		&order.AddressMismatch,
 		&order.IsDelete,
 		&order.CreatedBy,
 		&order.CreatedAt,
@@ -166,6 +194,9 @@ func GetAllOrder(ctx context.Context, db *sql.DB, filter helpers.Filter) ([]Orde
 			longitude,
 			status,
 			total_price,
+			distance,
+			delivery_fee,
+			address_mismatch,
 			is_delete,
 			created_by,
 			created_at,
@@ -201,6 +232,9 @@ func GetAllOrder(ctx context.Context, db *sql.DB, filter helpers.Filter) ([]Orde
 			&order.Longitude,
 			&order.Status,
 			&order.TotalPrice,
+			&order.Distance,
+			&order.DeliveryFee,
+			&order.AddressMismatch,
 			&order.IsDelete,
 			&order.CreatedBy,
 			&order.CreatedAt,
@@ -229,6 +263,9 @@ func GetAllOrderByCustomerID(ctx context.Context, db *sql.DB, filter helpers.Fil
 			longitude,
 			status,
 			total_price,
+			distance,
+			delivery_fee,
+			address_mismatch,
 			is_delete,
 			created_by,
 			created_at,
@@ -266,6 +303,9 @@ func GetAllOrderByCustomerID(ctx context.Context, db *sql.DB, filter helpers.Fil
 			&order.Longitude,
 			&order.Status,
 			&order.TotalPrice,
+			&order.Distance,
+			&order.DeliveryFee,
+			&order.AddressMismatch,
 			&order.IsDelete,
 			&order.CreatedBy,
 			&order.CreatedAt,
@@ -280,7 +320,7 @@ func GetAllOrderByCustomerID(ctx context.Context, db *sql.DB, filter helpers.Fil
 
 }
 
-func (s *OrderModel) Insert(ctx context.Context, db *sql.DB) error {
+func (s *OrderModel) Insert(ctx context.Context, db helpers.DBExecutor) error {
 
 	query := fmt.Sprintf(`
 		INSERT INTO "order"(
@@ -292,17 +332,19 @@ func (s *OrderModel) Insert(ctx context.Context, db *sql.DB) error {
 			longitude,
 			status,
 			total_price,
+			distance,
+			address_mismatch,
 			created_by,
 			created_at
 		)VALUES(
-			$1,$2,$3,$4,$5,$6,$7,$8,$9,now())
+			$1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,now())
 		RETURNING 
 			id, created_at,is_delete
 	`)
 
 	err := db.QueryRowContext(ctx, query,
 		s.WarehouseID, s.CustomerID, s.DeliveryDatetime, s.DeliveryAddress, s.Latitude, s.Longitude, s.Status, s.TotalPrice,
-		s.CreatedBy).Scan(
+		s.Distance, s.AddressMismatch, s.CreatedBy).Scan(
 		&s.ID, &s.CreatedAt, &s.IsDelete,
 	)
 
@@ -314,7 +356,7 @@ func (s *OrderModel) Insert(ctx context.Context, db *sql.DB) error {
 
 }
 
-func (s *OrderModel) UpdateStatus(ctx context.Context, db *sql.DB) error {
+func (s *OrderModel) UpdateStatus(ctx context.Context, db helpers.DBExecutor) error {
 
 	query := fmt.Sprintf(`
 		UPDATE "order"
@@ -341,22 +383,53 @@ func (s *OrderModel) UpdateStatus(ctx context.Context, db *sql.DB) error {
 
 }
 
-func (s *OrderModel) UpdatePrice(ctx context.Context, db *sql.DB) error {
+// TransitStatus only updates the order while it is still in the from status.
+func (s *OrderModel) TransitStatus(ctx context.Context, db helpers.DBExecutor, from int) error {
 
 	query := fmt.Sprintf(`
 		UPDATE "order"
 		SET
-			total_price=$1,
+			status=$1,
 			updated_at=NOW(),
 			updated_by=$2
 		WHERE 
 			id=$3
+		AND 
+			status=$4
+		RETURNING 
+			id,created_at,updated_at,created_by
+	`)
+
+	err := db.QueryRowContext(ctx, query,
+		s.Status, s.UpdatedBy, s.ID, from).Scan(
+		&s.ID, &s.CreatedAt, &s.UpdatedAt, &s.CreatedBy,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+func (s *OrderModel) UpdatePrice(ctx context.Context, db helpers.DBExecutor) error {
+
+	query := fmt.Sprintf(`
+		UPDATE "order"
+		SET
+			total_price=$1,
+			delivery_fee=$2,
+			updated_at=NOW(),
+			updated_by=$3
+		WHERE 
+			id=$4
 		RETURNING 
 			id,created_at,updated_at,created_by
 	`)
 
 	err := db.QueryRowContext(ctx, query,
-		s.TotalPrice, s.UpdatedBy, s.ID).Scan(
+		s.TotalPrice, s.DeliveryFee, s.UpdatedBy, s.ID).Scan(
 		&s.ID, &s.CreatedAt, &s.UpdatedAt, &s.CreatedBy,
 	)
 
@@ -13,29 +13,31 @@ import (
 
 type (
 	OrderProductModel struct {
-		ID        uuid.UUID
-		OrderID   uuid.UUID
-		ProductID uuid.UUID
-		Quantity  uint
-		SubTotal  decimal.Decimal
-		IsDelete  bool
-		CreatedBy uuid.UUID
-		CreatedAt time.Time
-		UpdatedBy uuid.NullUUID
-		UpdatedAt pq.NullTime
+		ID          uuid.UUID
+		OrderID     uuid.UUID
+		ProductID   uuid.UUID
+		WarehouseID uuid.UUID
+		Quantity    uint
+		SubTotal    decimal.Decimal
+		IsDelete    bool
+		CreatedBy   uuid.UUID
+		CreatedAt   time.Time
+		UpdatedBy   uuid.NullUUID
+		UpdatedAt   pq.NullTime
 	}
 
 	OrderProductResponse struct {
-		ID        uuid.UUID       `json:"id"`
-		Order     OrderResponse   `json:"order"`
-		Product   ProductResponse `json:"product"`
-		Quantity  uint            `json:"quantity"`
-		SubTotal  decimal.Decimal `json:"sub_total"`
-		IsDelete  bool            `json:"is_delete"`
-		CreatedBy uuid.UUID       `json:"created_by"`
-		CreatedAt time.Time       `json:"created_at"`
-		UpdatedBy uuid.UUID       `json:"updated_by"`
-		UpdatedAt time.Time       `json:"updated_at"`
+		ID        uuid.UUID         `json:"id"`
+		Order     OrderResponse     `json:"order"`
+		Product   ProductResponse   `json:"product"`
+		Warehouse WarehouseResponse `json:"warehouse"`
+		Quantity  uint              `json:"quantity"`
+		SubTotal  decimal.Decimal   `json:"sub_total"`
+		IsDelete  bool              `json:"is_delete"`
+		CreatedBy uuid.UUID         `json:"created_by"`
+		CreatedAt time.Time         `json:"created_at"`
+		UpdatedBy uuid.UUID         `json:"updated_by"`
+		UpdatedAt time.Time         `json:"updated_at"`
 	}
 )
 
@@ -66,9 +68,16 @@ func (s OrderProductModel) Response(ctx context.Context, db *sql.DB, logger *hel
 		return OrderProductResponse{}, err
 	}
 
+	warehouse, err := GetOneWarehouse(ctx, db, s.WarehouseID)
+	if err != nil {
+		logger.Err.Printf(`model.order.product.go/GetOneWarehouse/%v`, err)
+		return OrderProductResponse{}, err
+	}
+
 	return OrderProductResponse{
 		Order:     orderResponse,
 		Product:   productResponse,
+		Warehouse: warehouse.Response(),
 		Quantity:  s.Quantity,
 		SubTotal:  s.SubTotal,
 		IsDelete:  s.IsDelete,
@@ -87,6 +96,7 @@ func GetOneOrderProduct(ctx context.Context, db *sql.DB, orderProductID uuid.UUI
 			id,
 			order_id,
 			product_id,
+			warehouse_id,
 			quantity,
 			subtotal,
 			is_delete,
@@ -106,6 +116,7 @@ func GetOneOrderProduct(ctx context.Context, db *sql.DB, orderProductID uuid.UUI
 		&orderProduct.ID,
 		&orderProduct.OrderID,
 		&orderProduct.ProductID,
+		&orderProduct.WarehouseID,
 		&orderProduct.Quantity,
 		&orderProduct.SubTotal,
 		&orderProduct.IsDelete,
@@ -130,6 +141,7 @@ func GetAllOrderProduct(ctx context.Context, db *sql.DB, filter helpers.Filter)
 			id,
 			order_id,
 			product_id,
+			warehouse_id,
 			quantity,
 			subtotal,
 			is_delete,
@@ -160,6 +172,7 @@ func GetAllOrderProduct(ctx context.Context, db *sql.DB, filter helpers.Filter)
 			&orderProduct.ID,
 			&orderProduct.OrderID,
 			&orderProduct.ProductID,
+			&orderProduct.WarehouseID,
 			&orderProduct.Quantity,
 			&orderProduct.SubTotal,
 			&orderProduct.IsDelete,
@@ -176,7 +189,7 @@ func GetAllOrderProduct(ctx context.Context, db *sql.DB, filter helpers.Filter)
 
 }
 
-func GetAllOrderProductByOrderID(ctx context.Context, db *sql.DB, orderID uuid.UUID) (
+func GetAllOrderProductByOrderID(ctx context.Context, db helpers.DBExecutor, orderID uuid.UUID) (
 	[]OrderProductModel, error) {
 
 	query := fmt.Sprintf(`
@@ -184,6 +197,7 @@ func GetAllOrderProductByOrderID(ctx context.Context, db *sql.DB, orderID uuid.U
 			id,
 			order_id,
 			product_id,
+			warehouse_id,
 			quantity,
 			subtotal,
 			is_delete,
@@ -213,6 +227,7 @@ func GetAllOrderProductByOrderID(ctx context.Context, db *sql.DB, orderID uuid.U
 			&orderProduct.ID,
 			&orderProduct.OrderID,
 			&orderProduct.ProductID,
+			&orderProduct.WarehouseID,
 			&orderProduct.Quantity,
 			&orderProduct.SubTotal,
 			&orderProduct.IsDelete,
@@ -229,24 +244,25 @@ func GetAllOrderProductByOrderID(ctx context.Context, db *sql.DB, orderID uuid.U
 
 }
 
-func (s *OrderProductModel) Insert(ctx context.Context, db *sql.DB) error {
+func (s *OrderProductModel) Insert(ctx context.Context, db helpers.DBExecutor) error {
 
 	query := fmt.Sprintf(`
 		INSERT INTO order_product(
 			order_id,
 			product_id,
+			warehouse_id,
 			quantity,
 			subtotal,
 			created_by,
 			created_at)
 		VALUES(
-			$1,$2,$3,$4,$5,now())
+			$1,$2,$3,$4,$5,$6,now())
 		RETURNING 
 			id, created_at,is_delete
 	`)
 
 	err := db.QueryRowContext(ctx, query,
-		s.OrderID, s.ProductID, s.Quantity, s.SubTotal, s.CreatedBy).Scan(
+		s.OrderID, s.ProductID, s.WarehouseID, s.Quantity, s.SubTotal, s.CreatedBy).Scan(
 		&s.ID, &s.CreatedAt, &s.IsDelete,
 	)
 
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"github.com/lib/pq"
 	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
 	"time"
 )
 
@@ -16,6 +17,8 @@ type (
 		ID        uuid.UUID
 		OrderID   uuid.UUID
 		Status    int
+		Amount    decimal.Decimal
+		Reference sql.NullString
 		IsDelete  bool
 		CreatedBy uuid.UUID
 		CreatedAt time.Time
@@ -24,14 +27,19 @@ type (
 	}
 
 	PaymentResponse struct {
-		ID        uuid.UUID     `json:"id"`
-		Order     OrderResponse `json:"order"`
-		Status    string        `json:"status"`
-		IsDelete  bool          `json:"is_delete"`
-		CreatedBy uuid.UUID     `json:"created_by"`
-		CreatedAt time.Time     `json:"created_at"`
-		UpdatedBy uuid.UUID     `json:"updated_by"`
-		UpdatedAt time.Time     `json:"updated_at"`
+		ID        uuid.UUID               `json:"id"`
+		Order     OrderResponse           `json:"order"`
+		Status    string                  `json:"status"`
+		Amount    decimal.Decimal         `json:"amount"`
+		Refunded  decimal.Decimal         `json:"refunded"`
+		Net       decimal.Decimal         `json:"net"`
+		Refunds   []PaymentRefundResponse `json:"refunds"`
+		Reference string                  `json:"reference"`
+		IsDelete  bool                    `json:"is_delete"`
+		CreatedBy uuid.UUID               `json:"created_by"`
+		CreatedAt time.Time               `json:"created_at"`
+		UpdatedBy uuid.UUID               `json:"updated_by"`
+		UpdatedAt time.Time               `json:"updated_at"`
 	}
 )
 
@@ -49,12 +57,36 @@ func (s PaymentModel) Response(ctx context.Context, db *sql.DB, logger *helpers.
 		return PaymentResponse{}, err
 	}
 
+	refunds, err := GetAllPaymentRefundByPaymentID(ctx, db, s.ID)
+	if err != nil {
+		logger.Err.Printf(`model.payment.go/GetAllPaymentRefundByPaymentID/%v`, err)
+		return PaymentResponse{}, err
+	}
+
+	refunded, _ := RefundTotals(refunds)
+
+	var refundResponses []PaymentRefundResponse
+	for _, refund := range refunds {
+		refundResponses = append(refundResponses, refund.Response())
+	}
+
+	// Only money actually collected can be net of refunds.
+	net := decimal.Zero
+	if s.Status != util.PaymentStatusUnpaid && s.Status != util.PaymentStatusFailed {
+		net = s.Amount.Sub(refunded)
+	}
+
 	status := util.GetPaymentStatus(s.Status)
 
 	return PaymentResponse{
 		ID:        s.ID,
 		Order:     orderResponse,
 		Status:    status,
+		Amount:    s.Amount,
+		Refunded:  refunded,
+		Net:       net,
+		Refunds:   refundResponses,
+		Reference: s.Reference.String,
 		IsDelete:  s.IsDelete,
 		CreatedBy: s.CreatedBy,
 		CreatedAt: s.CreatedAt,
@@ -71,6 +103,141 @@ func GetOnePayment(ctx context.Context, db *sql.DB, paymentID uuid.UUID) (Paymen
 			id,
 			order_id,
 			status,
+			amount,
+			reference,
+			is_delete,
+			created_by,
+			created_at,
+			updated_by,
+			updated_at
+		FROM 
+			payment 
+		WHERE
+			id = $1
+	`)
+
+	var payment PaymentModel
+
+	err := db.QueryRowContext(ctx, query, paymentID).Scan(
+		&payment.ID,
+		&payment.OrderID,
+		&payment.Status,
+		&payment.Amount,
+		&payment.Reference,
+		&payment.IsDelete,
+		&payment.CreatedBy,
+		&payment.CreatedAt,
+		&payment.UpdatedBy,
+		&payment.UpdatedAt,
+	)
+
+	if err != nil {
+		return PaymentModel{}, err
+	}
+
+	return payment, nil
+
+}
+
+func GetOnePaymentByReference(ctx context.Context, db helpers.DBExecutor, reference string) (PaymentModel, error) {
+
+	query := fmt.Sprintf(`
+		SELECT
+			id,
+			order_id,
+			status,
+			amount,
+			reference,
+			is_delete,
+			created_by,
+			created_at,
+			updated_by,
+			updated_at
+		FROM 
+			payment 
+		WHERE
+			reference = $1
+	`)
+
+	var payment PaymentModel
+
+	err := db.QueryRowContext(ctx, query, reference).Scan(
+		&payment.ID,
+		&payment.OrderID,
+		&payment.Status,
+		&payment.Amount,
+		&payment.Reference,
+		&payment.IsDelete,
+		&payment.CreatedBy,
+		&payment.CreatedAt,
+		&payment.UpdatedBy,
+		&payment.UpdatedAt,
+	)
+
+	if err != nil {
+		return PaymentModel{}, err
+	}
+
+	return payment, nil
+
+}
+
+func GetOnePaymentByOrderID(ctx context.Context, db helpers.DBExecutor, orderID uuid.UUID) (PaymentModel, error) {
+
+	query := fmt.Sprintf(`
+		SELECT
+			id,
+			order_id,
+			status,
+			amount,
+			reference,
+			is_delete,
+			created_by,
+			created_at,
+			updated_by,
+			updated_at
+		FROM 
+			payment 
+		WHERE
+			order_id = $1
+		AND
+			is_delete = false
+	`)
+
+	var payment PaymentModel
+
+	err := db.QueryRowContext(ctx, query, orderID).Scan(
+		&payment.ID,
+		&payment.OrderID,
+		&payment.Status,
+		&payment.Amount,
+		&payment.Reference,
+		&payment.IsDelete,
+		&payment.CreatedBy,
+		&payment.CreatedAt,
+		&payment.UpdatedBy,
+		&payment.UpdatedAt,
+	)
+
+	if err != nil {
+		return PaymentModel{}, err
+	}
+
+	return payment, nil
+
+}
+
+// GetOnePaymentForUpdate locks the payment row until the transaction ends, so refunds are counted one at a
+// time.
+func GetOnePaymentForUpdate(ctx context.Context, db helpers.DBExecutor, paymentID uuid.UUID) (PaymentModel, error) {
+
+	query := fmt.Sprintf(`
+		SELECT
+			id,
+			order_id,
+			status,
+			amount,
+			reference,
 			is_delete,
 			created_by,
 			created_at,
@@ -80,6 +247,7 @@ func GetOnePayment(ctx context.Context, db *sql.DB, paymentID uuid.UUID) (Paymen
 			payment 
 		WHERE
 			id = $1
+		FOR UPDATE
 	`)
 
 	var payment PaymentModel
@@ -88,6 +256,8 @@ func GetOnePayment(ctx context.Context, db *sql.DB, paymentID uuid.UUID) (Paymen
 		&payment.ID,
 		&payment.OrderID,
 		&payment.Status,
+		&payment.Amount,
+		&payment.Reference,
 		&payment.IsDelete,
 		&payment.CreatedBy,
 		&payment.CreatedAt,
@@ -110,6 +280,8 @@ func GetAllPayment(ctx context.Context, db *sql.DB, filter helpers.Filter) ([]Pa
 			id,
 			order_id,
 			status,
+			amount,
+			reference,
 			is_delete,
 			created_by,
 			created_at,
@@ -138,6 +310,8 @@ func GetAllPayment(ctx context.Context, db *sql.DB, filter helpers.Filter) ([]Pa
 			&payment.ID,
 			&payment.OrderID,
 			&payment.Status,
+			&payment.Amount,
+			&payment.Reference,
 			&payment.IsDelete,
 			&payment.CreatedBy,
 			&payment.CreatedAt,
@@ -152,22 +326,89 @@ func GetAllPayment(ctx context.Context, db *sql.DB, filter helpers.Filter) ([]Pa
 
 }
 
-func (s *PaymentModel) Insert(ctx context.Context, db *sql.DB) error {
+// GetAllExpiredPayment finds payments still waiting for money since before deadline on orders that are
+// still open, oldest first.
+func GetAllExpiredPayment(ctx context.Context, db helpers.DBExecutor, deadline time.Time, limit int) (
+	[]PaymentModel, error) {
+
+	query := fmt.Sprintf(`
+		SELECT
+			p.id,
+			p.order_id,
+			p.status,
+			p.amount,
+			p.reference,
+			p.is_delete,
+			p.created_by,
+			p.created_at,
+			p.updated_by,
+			p.updated_at
+		FROM 
+			payment p
+		JOIN 
+			"order" o ON o.id = p.order_id
+		WHERE
+			p.status IN ($1, $2)
+		AND
+			p.is_delete = false
+		AND
+			p.created_at < $3
+		AND
+			o.status = $4
+		ORDER BY 
+			p.created_at ASC
+		LIMIT $5`)
+
+	rows, err := db.QueryContext(ctx, query, util.PaymentStatusUnpaid, util.PaymentStatusFailed, deadline,
+		util.OrderStatusOpen, limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var payments []PaymentModel
+	for rows.Next() {
+		var payment PaymentModel
+
+		rows.Scan(
+			&payment.ID,
+			&payment.OrderID,
+			&payment.Status,
+			&payment.Amount,
+			&payment.Reference,
+			&payment.IsDelete,
+			&payment.CreatedBy,
+			&payment.CreatedAt,
+			&payment.UpdatedBy,
+			&payment.UpdatedAt,
+		)
+
+		payments = append(payments, payment)
+	}
+
+	return payments, nil
+
+}
+
+func (s *PaymentModel) Insert(ctx context.Context, db helpers.DBExecutor) error {
 
 	query := fmt.Sprintf(`
 		INSERT INTO payment(
 			order_id,
 			status,
+			amount,
 			created_by,
 			created_at)
 		VALUES(
-			$1,$2,$3,now())
+			$1,$2,$3,$4,now())
 		RETURNING 
 			id, created_at,is_delete
 	`)
 
 	err := db.QueryRowContext(ctx, query,
-		s.OrderID, s.Status, s.CreatedBy).Scan(
+		s.OrderID, s.Status, s.Amount, s.CreatedBy).Scan(
 		&s.ID, &s.CreatedAt, &s.IsDelete,
 	)
 
@@ -179,7 +420,7 @@ func (s *PaymentModel) Insert(ctx context.Context, db *sql.DB) error {
 
 }
 
-func (s *PaymentModel) Update(ctx context.Context, db *sql.DB) error {
+func (s *PaymentModel) Update(ctx context.Context, db helpers.DBExecutor) error {
 
 	query := fmt.Sprintf(`
 		UPDATE payment
@@ -206,6 +447,68 @@ func (s *PaymentModel) Update(ctx context.Context, db *sql.DB) error {
 
 }
 
+// TransitStatus moves the payment to s.Status only while it is still in status from, so a webhook delivered
+// twice cannot settle a payment twice.
+func (s *PaymentModel) TransitStatus(ctx context.Context, db helpers.DBExecutor, from int) error {
+
+	query := fmt.Sprintf(`
+		UPDATE payment
+		SET
+			status=$1,
+			updated_at=NOW(),
+			updated_by=$2
+		WHERE 
+			id=$3
+		AND 
+			status=$4
+		RETURNING 
+			id,created_at,updated_at,created_by
+	`)
+
+	err := db.QueryRowContext(ctx, query,
+		s.Status, s.UpdatedBy, s.ID, from).Scan(
+		&s.ID, &s.CreatedAt, &s.UpdatedAt, &s.CreatedBy,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+// UpdateReference links the payment to a new gateway intent and makes it payable again.
+func (s *PaymentModel) UpdateReference(ctx context.Context, db helpers.DBExecutor) error {
+
+	query := fmt.Sprintf(`
+		UPDATE payment
+		SET
+			reference=$1,
+			status=$2,
+			updated_at=NOW(),
+			updated_by=$3
+		WHERE 
+			id=$4
+		AND 
+			status IN ($2,$5)
+		RETURNING 
+			id,created_at,updated_at,created_by
+	`)
+
+	err := db.QueryRowContext(ctx, query,
+		s.Reference, util.PaymentStatusUnpaid, s.UpdatedBy, s.ID, util.PaymentStatusFailed).Scan(
+		&s.ID, &s.CreatedAt, &s.UpdatedAt, &s.CreatedBy,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
 func (s *PaymentModel) Delete(ctx context.Context, db *sql.DB) error {
 
 	query := fmt.Sprintf(`
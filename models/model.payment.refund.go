@@ -0,0 +1,182 @@
+package models
+
+import (
+	"afiqo-location/helpers"
+	"afiqo-location/util"
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+type (
+	PaymentRefundModel struct {
+		ID        uuid.UUID
+		PaymentID uuid.UUID
+		Amount    decimal.Decimal
+		Reason    string
+		Status    int
+		Reference sql.NullString
+		CreatedBy uuid.UUID
+		CreatedAt time.Time
+		UpdatedBy uuid.NullUUID
+		UpdatedAt pq.NullTime
+	}
+
+	PaymentRefundResponse struct {
+		ID        uuid.UUID       `json:"id"`
+		PaymentID uuid.UUID       `json:"payment_id"`
+		Amount    decimal.Decimal `json:"amount"`
+		Reason    string          `json:"reason"`
+		Status    string          `json:"status"`
+		Reference string          `json:"reference"`
+		CreatedBy uuid.UUID       `json:"created_by"`
+		CreatedAt time.Time       `json:"created_at"`
+		UpdatedBy uuid.UUID       `json:"updated_by"`
+		UpdatedAt time.Time       `json:"updated_at"`
+	}
+)
+
+func (s PaymentRefundModel) Response() PaymentRefundResponse {
+	return PaymentRefundResponse{
+		ID:        s.ID,
+		PaymentID: s.PaymentID,
+		Amount:    s.Amount,
+		Reason:    s.Reason,
+		Status:    util.GetRefundStatus(s.Status),
+		Reference: s.Reference.String,
+		CreatedBy: s.CreatedBy,
+		CreatedAt: s.CreatedAt,
+		UpdatedBy: s.UpdatedBy.UUID,
+		UpdatedAt: s.UpdatedAt.Time,
+	}
+}
+
+// RefundTotals adds up the refunds that went through and those still holding money back, pending included.
+func RefundTotals(refunds []PaymentRefundModel) (refunded, held decimal.Decimal) {
+	for _, refund := range refunds {
+		switch refund.Status {
+		case util.RefundStatusSucceeded:
+			refunded = refunded.Add(refund.Amount)
+			held = held.Add(refund.Amount)
+		case util.RefundStatusPending:
+			held = held.Add(refund.Amount)
+		}
+	}
+	return refunded, held
+}
+
+func GetAllPaymentRefundByPaymentID(ctx context.Context, db helpers.DBExecutor, paymentID uuid.UUID) (
+	[]PaymentRefundModel, error) {
+
+	query := fmt.Sprintf(`
+		SELECT
+			id,
+			payment_id,
+			amount,
+			reason,
+			status,
+			reference,
+			created_by,
+			created_at,
+			updated_by,
+			updated_at
+		FROM
+			payment_refund
+		WHERE
+			payment_id = $1
+		ORDER BY
+			created_at ASC
+	`)
+
+	rows, err := db.QueryContext(ctx, query, paymentID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var refunds []PaymentRefundModel
+	for rows.Next() {
+		var refund PaymentRefundModel
+
+		rows.Scan(
+			&refund.ID,
+			&refund.PaymentID,
+			&refund.Amount,
+			&refund.Reason,
+			&refund.Status,
+			&refund.Reference,
+			&refund.CreatedBy,
+			&refund.CreatedAt,
+			&refund.UpdatedBy,
+			&refund.UpdatedAt,
+		)
+
+		refunds = append(refunds, refund)
+	}
+
+	return refunds, nil
+}
+
+func (s *PaymentRefundModel) Insert(ctx context.Context, db helpers.DBExecutor) error {
+
+	query := fmt.Sprintf(`
+		INSERT INTO payment_refund(
+			payment_id,
+			amount,
+			reason,
+			status,
+			created_by,
+			created_at)
+		VALUES(
+			$1,$2,$3,$4,$5,now())
+		RETURNING
+			id, created_at
+	`)
+
+	err := db.QueryRowContext(ctx, query,
+		s.PaymentID, s.Amount, s.Reason, s.Status, s.CreatedBy).Scan(
+		&s.ID, &s.CreatedAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateStatus records the gateway's answer on a pending refund.
+func (s *PaymentRefundModel) UpdateStatus(ctx context.Context, db helpers.DBExecutor) error {
+
+	query := fmt.Sprintf(`
+		UPDATE payment_refund
+		SET
+			status=$1,
+			reference=$2,
+			updated_at=NOW(),
+			updated_by=$3
+		WHERE
+			id=$4
+		AND
+			status=$5
+		RETURNING
+			updated_at
+	`)
+
+	err := db.QueryRowContext(ctx, query,
+		s.Status, s.Reference, s.UpdatedBy, s.ID, util.RefundStatusPending).Scan(
+		&s.UpdatedAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
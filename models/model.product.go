@@ -72,7 +72,7 @@ func (s ProductModel) Response(ctx context.Context, db *sql.DB, logger *helpers.
 	}, nil
 }
 
-func GetOneProduct(ctx context.Context, db *sql.DB, productID uuid.UUID) (ProductModel, error) {
+func GetOneProduct(ctx context.Context, db helpers.DBExecutor, productID uuid.UUID) (ProductModel, error) {
 
 	query := fmt.Sprintf(`
 		SELECT
@@ -197,23 +197,29 @@ func GetAllProduct(ctx context.Context, db *sql.DB, filter helpers.Filter) ([]Pr
 
 }
 
-func GetAllProductForCustomer(ctx context.Context, db *sql.DB, filter helpers.Filter, warehouseID uuid.UUID) (
+func GetAllProductForCustomer(ctx context.Context, db *sql.DB, filter helpers.Filter, warehouseIDs []uuid.UUID) (
 	[]ProductModel, error) {
 
 	var filters []string
 
 	if filter.CategoryID != uuid.Nil {
 		filters = append(filters, fmt.Sprintf(`
-			category_id = '%s'`,
+			p.category_id = '%s'`,
 			filter.CategoryID))
 	}
 
 	if filter.SupplierID != uuid.Nil {
 		filters = append(filters, fmt.Sprintf(`
-			supplier_id = '%s'`,
+			p.supplier_id = '%s'`,
 			filter.SupplierID))
 	}
 
+	if filter.ProductID != uuid.Nil {
+		filters = append(filters, fmt.Sprintf(`
+			p.id = '%s'`,
+			filter.ProductID))
+	}
+
 	filterJoin := strings.Join(filters, " AND ")
 	if filterJoin != "" {
 		filterJoin = fmt.Sprintf("AND %s", filterJoin)
@@ -225,7 +231,7 @@ func GetAllProductForCustomer(ctx context.Context, db *sql.DB, filter helpers.Fi
 			p.supplier_id,
 			p.category_id,
 			p.name,
-			s.stock,
+			SUM(s.stock),
 			p.price,
 			p.description,
 			p.is_delete,
@@ -242,14 +248,18 @@ func GetAllProductForCustomer(ctx context.Context, db *sql.DB, filter helpers.Fi
 		WHERE 
 			p.is_delete = false
 		AND 
-			s.warehouse_id = $1
+			s.is_delete = false
+		AND 
+			s.warehouse_id = ANY($1::uuid[])
 		%s
+		GROUP BY
+			p.id
 		ORDER BY 
-			name  %s   
+			p.name  %s   
 		LIMIT $2 OFFSET $3`,
 		filterJoin, filter.Dir)
 
-	rows, err := db.QueryContext(ctx, query, warehouseID, filter.Limit, filter.Offset)
+	rows, err := db.QueryContext(ctx, query, pq.Array(uuidStrings(warehouseIDs)), filter.Limit, filter.Offset)
 
 	if err != nil {
 		return nil, err
@@ -406,7 +416,7 @@ func (s *ProductModel) Update(ctx context.Context, db *sql.DB) error {
 
 }
 
-func (s *ProductModel) StockUpdate(ctx context.Context, db *sql.DB) error {
+func (s *ProductModel) StockUpdate(ctx context.Context, db helpers.DBExecutor) error {
 
 	query := fmt.Sprintf(`
 		UPDATE product
@@ -0,0 +1,129 @@
+package models
+
+import (
+	"afiqo-location/helpers"
+	"afiqo-location/util"
+	"context"
+	"fmt"
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+type (
+	ShipmentEventModel struct {
+		ID         uuid.UUID
+		ShipmentID uuid.UUID
+		Status     int
+		Latitude   decimal.NullDecimal
+		Longitude  decimal.NullDecimal
+		ActorRole  string
+		CreatedBy  uuid.UUID
+		CreatedAt  time.Time
+	}
+
+	ShipmentEventResponse struct {
+		ID         uuid.UUID           `json:"id"`
+		ShipmentID uuid.UUID           `json:"shipment_id"`
+		Status     string              `json:"status"`
+		Latitude   decimal.NullDecimal `json:"latitude"`
+		Longitude  decimal.NullDecimal `json:"longitude"`
+		ActorRole  string              `json:"actor_role"`
+		CreatedBy  uuid.UUID           `json:"created_by"`
+		CreatedAt  time.Time           `json:"created_at"`
+	}
+)
+
+func (s ShipmentEventModel) Response() ShipmentEventResponse {
+	return ShipmentEventResponse{
+		ID:         s.ID,
+		ShipmentID: s.ShipmentID,
+		Status:     util.GetShipmentStatus(s.Status),
+		Latitude:   s.Latitude,
+		Longitude:  s.Longitude,
+		ActorRole:  s.ActorRole,
+		CreatedBy:  s.CreatedBy,
+		CreatedAt:  s.CreatedAt,
+	}
+}
+
+func GetAllShipmentEventByShipmentID(ctx context.Context, db helpers.DBExecutor, shipmentID uuid.UUID) (
+	[]ShipmentEventModel, error) {
+
+	query := fmt.Sprintf(`
+		SELECT
+			id,
+			shipment_id,
+			status,
+			latitude,
+			longitude,
+			actor_role,
+			created_by,
+			created_at
+		FROM
+			shipment_event
+		WHERE
+			shipment_id = $1
+		ORDER BY
+			created_at ASC
+	`)
+
+	rows, err := db.QueryContext(ctx, query, shipmentID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var shipmentEvents []ShipmentEventModel
+	for rows.Next() {
+		var shipmentEvent ShipmentEventModel
+
+		rows.Scan(
+			&shipmentEvent.ID,
+			&shipmentEvent.ShipmentID,
+			&shipmentEvent.Status,
+			&shipmentEvent.Latitude,
+			&shipmentEvent.Longitude,
+			&shipmentEvent.ActorRole,
+			&shipmentEvent.CreatedBy,
+			&shipmentEvent.CreatedAt,
+		)
+
+		shipmentEvents = append(shipmentEvents, shipmentEvent)
+	}
+
+	return shipmentEvents, nil
+
+}
+
+func (s *ShipmentEventModel) Insert(ctx context.Context, db helpers.DBExecutor) error {
+
+	query := fmt.Sprintf(`
+		INSERT INTO shipment_event(
+			shipment_id,
+			status,
+			latitude,
+			longitude,
+			actor_role,
+			created_by,
+			created_at)
+		VALUES(
+			$1,$2,$3,$4,$5,$6,now())
+		RETURNING
+			id, created_at
+	`)
+
+	err := db.QueryRowContext(ctx, query,
+		s.ShipmentID, s.Status, s.Latitude, s.Longitude, s.ActorRole, s.CreatedBy).Scan(
+		&s.ID, &s.CreatedAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
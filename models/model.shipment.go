@@ -14,27 +14,29 @@ import (
 
 type (
 	ShipmentModel struct {
-		ID        uuid.UUID
-		CourierID uuid.UUID
-		OrderID   uuid.UUID
-		Status    int
-		IsDelete  bool
-		CreatedBy uuid.UUID
-		CreatedAt time.Time
-		UpdatedBy uuid.NullUUID
-		UpdatedAt pq.NullTime
+		ID          uuid.UUID
+		CourierID   uuid.UUID
+		OrderID     uuid.UUID
+		WarehouseID uuid.UUID
+		Status      int
+		IsDelete    bool
+		CreatedBy   uuid.UUID
+		CreatedAt   time.Time
+		UpdatedBy   uuid.NullUUID
+		UpdatedAt   pq.NullTime
 	}
 
 	ShipmentResponse struct {
-		ID        uuid.UUID       `json:"id"`
-		Courier   CourierResponse `json:"courier"`
-		Order     OrderResponse   `json:"order"`
-		Status    string          `json:"status"`
-		IsDelete  bool            `json:"is_delete"`
-		CreatedBy uuid.UUID       `json:"created_by"`
-		CreatedAt time.Time       `json:"created_at"`
-		UpdatedBy uuid.UUID       `json:"updated_by"`
-		UpdatedAt time.Time       `json:"updated_at"`
+		ID        uuid.UUID         `json:"id"`
+		Courier   CourierResponse   `json:"courier"`
+		Order     OrderResponse     `json:"order"`
+		Warehouse WarehouseResponse `json:"warehouse"`
+		Status    string            `json:"status"`
+		IsDelete  bool              `json:"is_delete"`
+		CreatedBy uuid.UUID         `json:"created_by"`
+		CreatedAt time.Time         `json:"created_at"`
+		UpdatedBy uuid.UUID         `json:"updated_by"`
+		UpdatedAt time.Time         `json:"updated_at"`
 	}
 )
 
@@ -58,12 +60,19 @@ func (s ShipmentModel) Response(ctx context.Context, db *sql.DB, logger *helpers
 		return ShipmentResponse{}, nil
 	}
 
+	warehouse, err := GetOneWarehouse(ctx, db, s.WarehouseID)
+	if err != nil {
+		logger.Err.Printf(`model.shipment.go/GetOneWarehouse/%v`, err)
+		return ShipmentResponse{}, nil
+	}
+
 	status := util.GetShipmentStatus(s.Status)
 
 	return ShipmentResponse{
 		ID:        s.ID,
 		Courier:   courier.Response(),
 		Order:     orderResponse,
+		Warehouse: warehouse.Response(),
 		Status:    status,
 		IsDelete:  s.IsDelete,
 		CreatedBy: s.CreatedBy,
@@ -80,6 +89,7 @@ func GetOneShipment(ctx context.Context, db *sql.DB, shipmentID uuid.UUID) (Ship
 			id,
 			courier_id,
 			order_id,
+			warehouse_id,
 			status,
 			is_delete,
 			created_by,
@@ -97,6 +107,54 @@ func GetOneShipment(ctx context.Context, db *sql.DB, shipmentID uuid.UUID) (Ship
 		&shipment.ID,
 		&shipment.CourierID,
 		&shipment.OrderID,
+		&shipment.WarehouseID,
+		&shipment.Status,
+		&shipment.IsDelete,
+		&shipment.CreatedBy,
+		&shipment.CreatedAt,
+		&shipment.UpdatedBy,
+		&shipment.UpdatedAt,
+	)
+
+	if err != nil {
+		return ShipmentModel{}, err
+	}
+
+	return shipment, nil
+
+}
+
+func GetOneShipmentByOrderAndWarehouse(ctx context.Context, db *sql.DB, orderID, warehouseID uuid.UUID) (
+	ShipmentModel, error) {
+
+	query := fmt.Sprintf(`
+		SELECT
+			id,
+			courier_id,
+			order_id,
+			warehouse_id,
+			status,
+			is_delete,
+			created_by,
+			created_at,
+			updated_by,
+			updated_at
+		FROM 
+			shipment
+		WHERE 
+			is_delete = false
+		AND 
+			order_id = $1
+		AND 
+			warehouse_id = $2
+	`)
+
+	var shipment ShipmentModel
+	err := db.QueryRowContext(ctx, query, orderID, warehouseID).Scan(
+		&shipment.ID,
+		&shipment.CourierID,
+		&shipment.OrderID,
+		&shipment.WarehouseID,
 		&shipment.Status,
 		&shipment.IsDelete,
 		&shipment.CreatedBy,
@@ -139,6 +197,7 @@ func GetAllShipment(ctx context.Context, db *sql.DB, filter helpers.Filter) ([]S
 			id,
 			courier_id,
 			order_id,
+			warehouse_id,
 			status,
 			is_delete,
 			created_by,
@@ -169,6 +228,62 @@ func GetAllShipment(ctx context.Context, db *sql.DB, filter helpers.Filter) ([]S
 			&shipment.ID,
 			&shipment.CourierID,
 			&shipment.OrderID,
+			&shipment.WarehouseID,
+			&shipment.Status,
+			&shipment.IsDelete,
+			&shipment.CreatedBy,
+			&shipment.CreatedAt,
+			&shipment.UpdatedBy,
+			&shipment.UpdatedAt,
+		)
+
+		shipments = append(shipments, shipment)
+	}
+
+	return shipments, nil
+
+}
+
+func GetAllShipmentByOrderID(ctx context.Context, db helpers.DBExecutor, orderID uuid.UUID) (
+	[]ShipmentModel, error) {
+
+	query := fmt.Sprintf(`
+		SELECT
+			id,
+			courier_id,
+			order_id,
+			warehouse_id,
+			status,
+			is_delete,
+			created_by,
+			created_at,
+			updated_by,
+			updated_at
+		FROM 
+			shipment
+		WHERE 
+			is_delete = false
+		AND 
+			order_id = $1
+	`)
+
+	rows, err := db.QueryContext(ctx, query, orderID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var shipments []ShipmentModel
+	for rows.Next() {
+		var shipment ShipmentModel
+
+		rows.Scan(
+			&shipment.ID,
+			&shipment.CourierID,
+			&shipment.OrderID,
+			&shipment.WarehouseID,
 			&shipment.Status,
 			&shipment.IsDelete,
 			&shipment.CreatedBy,
@@ -192,6 +307,7 @@ func GetAllShipmentByCustomerID(ctx context.Context, db *sql.DB, filter helpers.
 			s.id,
 			s.courier_id,
 			s.order_id,
+			s.warehouse_id,
 			s.status,
 			s.is_delete,
 			s.created_by,
@@ -225,6 +341,7 @@ func GetAllShipmentByCustomerID(ctx context.Context, db *sql.DB, filter helpers.
 			&shipment.ID,
 			&shipment.CourierID,
 			&shipment.OrderID,
+			&shipment.WarehouseID,
 			&shipment.Status,
 			&shipment.IsDelete,
 			&shipment.CreatedBy,
@@ -248,6 +365,7 @@ func GetAllShipmentByCourierID(ctx context.Context, db *sql.DB, filter helpers.F
 			id,
 			courier_id,
 			order_id,
+			warehouse_id,
 			status,
 			is_delete,
 			created_by,
@@ -281,6 +399,66 @@ func GetAllShipmentByCourierID(ctx context.Context, db *sql.DB, filter helpers.F
 			&shipment.ID,
 			&shipment.CourierID,
 			&shipment.OrderID,
+			&shipment.WarehouseID,
+			&shipment.Status,
+			&shipment.IsDelete,
+			&shipment.CreatedBy,
+			&shipment.CreatedAt,
+			&shipment.UpdatedBy,
+			&shipment.UpdatedAt,
+		)
+
+		shipments = append(shipments, shipment)
+	}
+
+	return shipments, nil
+
+}
+
+// GetAllUndeliveredShipmentByCourierID returns the shipments a courier still has to deliver, oldest first.
+func GetAllUndeliveredShipmentByCourierID(ctx context.Context, db helpers.DBExecutor, courierID uuid.UUID) (
+	[]ShipmentModel, error) {
+
+	query := fmt.Sprintf(`
+		SELECT
+			id,
+			courier_id,
+			order_id,
+			warehouse_id,
+			status,
+			is_delete,
+			created_by,
+			created_at,
+			updated_by,
+			updated_at
+		FROM
+			shipment
+		WHERE
+			is_delete = false
+		AND
+			courier_id = $1
+		AND
+			status <> $2
+		ORDER BY
+			created_at`)
+
+	rows, err := db.QueryContext(ctx, query, courierID, util.ShipmentStatusDelivered)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var shipments []ShipmentModel
+	for rows.Next() {
+		var shipment ShipmentModel
+
+		rows.Scan(
+			&shipment.ID,
+			&shipment.CourierID,
+			&shipment.OrderID,
+			&shipment.WarehouseID,
 			&shipment.Status,
 			&shipment.IsDelete,
 			&shipment.CreatedBy,
@@ -296,23 +474,123 @@ func GetAllShipmentByCourierID(ctx context.Context, db *sql.DB, filter helpers.F
 
 }
 
-func (s *ShipmentModel) Insert(ctx context.Context, db *sql.DB) error {
+// CountUndeliveredShipmentByCourier returns how many shipments each courier still has to deliver. Couriers
+// with none are left out.
+func CountUndeliveredShipmentByCourier(ctx context.Context, db helpers.DBExecutor) (map[uuid.UUID]int, error) {
+
+	query := fmt.Sprintf(`
+		SELECT
+			courier_id,
+			COUNT(id)
+		FROM
+			shipment
+		WHERE
+			is_delete = false
+		AND
+			status <> $1
+		GROUP BY
+			courier_id`)
+
+	rows, err := db.QueryContext(ctx, query, util.ShipmentStatusDelivered)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	counts := make(map[uuid.UUID]int)
+	for rows.Next() {
+		var courierID uuid.UUID
+		var count int
+
+		rows.Scan(
+			&courierID,
+			&count,
+		)
+
+		counts[courierID] = count
+	}
+
+	return counts, nil
+
+}
+
+// CountUndeliveredShipmentByCourierID returns how many shipments one courier still has to deliver.
+func CountUndeliveredShipmentByCourierID(ctx context.Context, db helpers.DBExecutor, courierID uuid.UUID) (
+	int, error) {
+
+	query := fmt.Sprintf(`
+		SELECT
+			COUNT(id)
+		FROM
+			shipment
+		WHERE
+			is_delete = false
+		AND
+			courier_id = $1
+		AND
+			status <> $2`)
+
+	var count int
+	err := db.QueryRowContext(ctx, query, courierID, util.ShipmentStatusDelivered).Scan(&count)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+
+}
+
+// CountShipmentAssignedToCourier returns how many shipments were handed to one courier in [from, to).
+func CountShipmentAssignedToCourier(ctx context.Context, db helpers.DBExecutor, courierID uuid.UUID, from,
+	to time.Time) (int, error) {
+
+	query := fmt.Sprintf(`
+		SELECT
+			COUNT(id)
+		FROM
+			shipment
+		WHERE
+			is_delete = false
+		AND
+			courier_id = $1
+		AND
+			assigned_at >= $2
+		AND
+			assigned_at < $3`)
+
+	var count int
+	err := db.QueryRowContext(ctx, query, courierID, from, to).Scan(&count)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+
+}
+
+func (s *ShipmentModel) Insert(ctx context.Context, db helpers.DBExecutor) error {
 
 	query := fmt.Sprintf(`
 		INSERT INTO shipment(
 			courier_id,
 			order_id,
+			warehouse_id,
 			status,
 			created_by,
-			created_at)
+			created_at,
+			assigned_at)
 		VALUES(
-			$1,$2,$3,$4,now())
+			$1,$2,$3,$4,$5,now(),now())
 		RETURNING 
 			id, created_at,is_delete
 	`)
 
 	err := db.QueryRowContext(ctx, query,
-		s.CourierID, s.OrderID, s.Status, s.CreatedBy).Scan(
+		s.CourierID, s.OrderID, s.WarehouseID, s.Status, s.CreatedBy).Scan(
 		&s.ID, &s.CreatedAt, &s.IsDelete,
 	)
 
@@ -350,7 +628,73 @@ func (s *ShipmentModel) UpdateStatus(ctx context.Context, db *sql.DB) error {
 	return nil
 
 }
-func (s *ShipmentModel) Delete(ctx context.Context, db *sql.DB) error {
+
+// UpdateCourier hands a shipment that is not delivered yet to another courier.
+func (s *ShipmentModel) UpdateCourier(ctx context.Context, db helpers.DBExecutor) error {
+
+	query := fmt.Sprintf(`
+		UPDATE shipment
+		SET
+			assigned_at=CASE WHEN courier_id = $1 THEN assigned_at ELSE NOW() END,
+			courier_id=$1,
+			updated_at=NOW(),
+			updated_by=$2
+		WHERE
+			id=$3
+		AND
+			status <> $4
+		AND
+			is_delete = false
+		RETURNING
+			order_id,warehouse_id,status,created_at,updated_at,created_by
+	`)
+
+	err := db.QueryRowContext(ctx, query,
+		s.CourierID, s.UpdatedBy, s.ID, util.ShipmentStatusDelivered).Scan(
+		&s.OrderID, &s.WarehouseID, &s.Status, &s.CreatedAt, &s.UpdatedAt, &s.CreatedBy,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+// TransitStatus only updates the shipment while it is still in the from status and not deleted.
+func (s *ShipmentModel) TransitStatus(ctx context.Context, db helpers.DBExecutor, from int) error {
+
+	query := fmt.Sprintf(`
+		UPDATE shipment
+		SET
+			status=$1,
+			updated_at=NOW(),
+			updated_by=$2
+		WHERE 
+			id=$3
+		AND 
+			status=$4
+		AND
+			is_delete = false
+		RETURNING 
+			id,courier_id,order_id,warehouse_id,created_at,updated_at,created_by
+	`)
+
+	err := db.QueryRowContext(ctx, query,
+		s.Status, s.UpdatedBy, s.ID, from).Scan(
+		&s.ID, &s.CourierID, &s.OrderID, &s.WarehouseID, &s.CreatedAt, &s.UpdatedAt, &s.CreatedBy,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+func (s *ShipmentModel) Delete(ctx context.Context, db helpers.DBExecutor) error {
 
 	query := fmt.Sprintf(`
 		UPDATE shipment
@@ -0,0 +1,129 @@
+package models
+
+import (
+	"afiqo-location/helpers"
+	"context"
+	"fmt"
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+type (
+	ShipmentProofModel struct {
+		ID            uuid.UUID
+		ShipmentID    uuid.UUID
+		RecipientName string
+		PhotoKey      string
+		PhotoType     string
+		SignatureKey  string
+		SignatureType string
+		Latitude      decimal.Decimal
+		Longitude     decimal.Decimal
+		CreatedBy     uuid.UUID
+		CreatedAt     time.Time
+	}
+
+	ShipmentProofResponse struct {
+		ID            uuid.UUID       `json:"id"`
+		ShipmentID    uuid.UUID       `json:"shipment_id"`
+		RecipientName string          `json:"recipient_name"`
+		PhotoURL      string          `json:"photo_url"`
+		SignatureURL  string          `json:"signature_url"`
+		Latitude      decimal.Decimal `json:"latitude"`
+		Longitude     decimal.Decimal `json:"longitude"`
+		CreatedBy     uuid.UUID       `json:"created_by"`
+		CreatedAt     time.Time       `json:"created_at"`
+	}
+)
+
+func (s ShipmentProofModel) Response() ShipmentProofResponse {
+	return ShipmentProofResponse{
+		ID:            s.ID,
+		ShipmentID:    s.ShipmentID,
+		RecipientName: s.RecipientName,
+		PhotoURL:      fmt.Sprintf(`/api/v1/shipments/%s/proof/photo`, s.ShipmentID),
+		SignatureURL:  fmt.Sprintf(`/api/v1/shipments/%s/proof/signature`, s.ShipmentID),
+		Latitude:      s.Latitude,
+		Longitude:     s.Longitude,
+		CreatedBy:     s.CreatedBy,
+		CreatedAt:     s.CreatedAt,
+	}
+}
+
+func GetOneShipmentProofByShipmentID(ctx context.Context, db helpers.DBExecutor, shipmentID uuid.UUID) (
+	ShipmentProofModel, error) {
+
+	query := fmt.Sprintf(`
+		SELECT
+			id,
+			shipment_id,
+			recipient_name,
+			photo_key,
+			photo_type,
+			signature_key,
+			signature_type,
+			latitude,
+			longitude,
+			created_by,
+			created_at
+		FROM
+			shipment_proof
+		WHERE
+			shipment_id = $1
+	`)
+
+	var shipmentProof ShipmentProofModel
+	err := db.QueryRowContext(ctx, query, shipmentID).Scan(
+		&shipmentProof.ID,
+		&shipmentProof.ShipmentID,
+		&shipmentProof.RecipientName,
+		&shipmentProof.PhotoKey,
+		&shipmentProof.PhotoType,
+		&shipmentProof.SignatureKey,
+		&shipmentProof.SignatureType,
+		&shipmentProof.Latitude,
+		&shipmentProof.Longitude,
+		&shipmentProof.CreatedBy,
+		&shipmentProof.CreatedAt,
+	)
+
+	if err != nil {
+		return ShipmentProofModel{}, err
+	}
+
+	return shipmentProof, nil
+}
+
+func (s *ShipmentProofModel) Insert(ctx context.Context, db helpers.DBExecutor) error {
+
+	query := fmt.Sprintf(`
+		INSERT INTO shipment_proof(
+			shipment_id,
+			recipient_name,
+			photo_key,
+			photo_type,
+			signature_key,
+			signature_type,
+			latitude,
+			longitude,
+			created_by,
+			created_at)
+		VALUES(
+			$1,$2,$3,$4,$5,$6,$7,$8,$9,now())
+		RETURNING
+			id, created_at
+	`)
+
+	err := db.QueryRowContext(ctx, query,
+		s.ShipmentID, s.RecipientName, s.PhotoKey, s.PhotoType, s.SignatureKey, s.SignatureType,
+		s.Latitude, s.Longitude, s.CreatedBy).Scan(
+		&s.ID, &s.CreatedAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
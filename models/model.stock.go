@@ -114,7 +114,7 @@ func GetOneStock(ctx context.Context, db *sql.DB, stockID uuid.UUID) (
 
 }
 
-func GetOneStockByProductAndWarehouse(ctx context.Context, db *sql.DB, warehouseID, productID uuid.UUID) (
+func GetOneStockByProductAndWarehouse(ctx context.Context, db helpers.DBExecutor, warehouseID, productID uuid.UUID) (
 	StockModel, error) {
 
 	query := fmt.Sprintf(`
@@ -305,7 +305,7 @@ func GetAllStockBySupplierID(ctx context.Context, db *sql.DB, filter helpers.Fil
 
 }
 
-func GetAllStockByProductID(ctx context.Context, db *sql.DB, productID uuid.UUID) (
+func GetAllStockByProductID(ctx context.Context, db helpers.DBExecutor, productID uuid.UUID) (
 	[]StockModel, error) {
 
 	query := fmt.Sprintf(`
@@ -356,6 +356,71 @@ func GetAllStockByProductID(ctx context.Context, db *sql.DB, productID uuid.UUID
 
 }
 
+func GetAllStockByWarehousesAndProducts(ctx context.Context, db helpers.DBExecutor, warehouseIDs,
+	productIDs []uuid.UUID) ([]StockModel, error) {
+
+	query := fmt.Sprintf(`
+		SELECT
+			id,
+			warehouse_id,
+			product_id,
+			stock,
+			is_delete,
+			created_by,
+			created_at,
+			updated_by,
+			updated_at
+		FROM 
+			stock
+		WHERE 
+			is_delete = false
+		AND 
+			stock > 0
+		AND 
+			warehouse_id = ANY($1::uuid[])
+		AND 
+			product_id = ANY($2::uuid[])
+	`)
+
+	rows, err := db.QueryContext(ctx, query, pq.Array(uuidStrings(warehouseIDs)), pq.Array(uuidStrings(productIDs)))
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var stocks []StockModel
+	for rows.Next() {
+		var stock StockModel
+
+		rows.Scan(
+			&stock.ID,
+			&stock.WarehouseID,
+			&stock.ProductID,
+			&stock.Stock,
+			&stock.IsDelete,
+			&stock.CreatedBy,
+			&stock.CreatedAt,
+			&stock.UpdatedBy,
+			&stock.UpdatedAt,
+		)
+
+		stocks = append(stocks, stock)
+	}
+
+	return stocks, nil
+
+}
+
+func uuidStrings(ids []uuid.UUID) []string {
+	var values []string
+	for _, id := range ids {
+		values = append(values, id.String())
+	}
+	return values
+}
+
 func (s *StockModel) Insert(ctx context.Context, db *sql.DB) error {
 
 	query := fmt.Sprintf(`
@@ -383,7 +448,7 @@ func (s *StockModel) Insert(ctx context.Context, db *sql.DB) error {
 	return nil
 
 }
-func (s *StockModel) Update(ctx context.Context, db *sql.DB) error {
+func (s *StockModel) Update(ctx context.Context, db helpers.DBExecutor) error {
 
 	query := fmt.Sprintf(`
 		UPDATE stock
@@ -410,6 +475,68 @@ func (s *StockModel) Update(ctx context.Context, db *sql.DB) error {
 
 }
 
+func (s *StockModel) Reserve(ctx context.Context, db helpers.DBExecutor, quantity uint) error {
+
+	query := fmt.Sprintf(`
+		UPDATE stock
+		SET
+			stock = stock - $1,
+			updated_at=NOW(),
+			updated_by=$2
+		WHERE 
+			warehouse_id=$3
+		AND 
+			product_id=$4
+		AND 
+			is_delete = false
+		AND 
+			stock >= $1
+		RETURNING 
+			id,stock,created_at,updated_at,created_by
+	`)
+
+	err := db.QueryRowContext(ctx, query,
+		quantity, s.UpdatedBy, s.WarehouseID, s.ProductID).Scan(
+		&s.ID, &s.Stock, &s.CreatedAt, &s.UpdatedAt, &s.CreatedBy,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+func (s *StockModel) Restock(ctx context.Context, db helpers.DBExecutor, quantity uint) error {
+
+	query := fmt.Sprintf(`
+		UPDATE stock
+		SET
+			stock = stock + $1,
+			updated_at=NOW(),
+			updated_by=$2
+		WHERE 
+			warehouse_id=$3
+		AND 
+			product_id=$4
+		RETURNING 
+			id,stock,created_at,updated_at,created_by
+	`)
+
+	err := db.QueryRowContext(ctx, query,
+		quantity, s.UpdatedBy, s.WarehouseID, s.ProductID).Scan(
+		&s.ID, &s.Stock, &s.CreatedAt, &s.UpdatedAt, &s.CreatedBy,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
 func (s *StockModel) Delete(ctx context.Context, db *sql.DB) error {
 
 	query := fmt.Sprintf(`
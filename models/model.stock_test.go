@@ -0,0 +1,207 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	"os"
+	"sync"
+	"testing"
+)
+
+//set AFIQO_TEST_DATABASE_URL to a local postgres with the afiqo schema to run
+
+func testDB(t *testing.T) *sql.DB {
+	dsn := os.Getenv("AFIQO_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("AFIQO_TEST_DATABASE_URL not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Ping()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func testStock(t *testing.T, db *sql.DB, quantity uint) StockModel {
+
+	ctx := context.Background()
+	userID := uuid.NewV4()
+
+	supplier := SupplierModel{
+		Name:      "Test Supplier",
+		PhoneNo:   "0123456789",
+		Email:     fmt.Sprintf(`%s@test.afiqo`, uuid.NewV4()),
+		Password:  "password",
+		CreatedBy: userID,
+	}
+	err := supplier.Insert(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	category := CategoryModel{
+		Name:        "Test Category",
+		Description: "Test Category",
+		CreatedBy:   userID,
+	}
+	err = category.Insert(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	product := ProductModel{
+		SupplierID:  supplier.ID,
+		CategoryID:  category.ID,
+		Name:        "Test Product",
+		Price:       decimal.NewFromInt(10),
+		Description: "Test Product",
+		CreatedBy:   userID,
+	}
+	err = product.Insert(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	warehouse := WarehouseModel{
+		Name:      "Test Warehouse",
+		Address:   "Test Address",
+		Latitude:  decimal.NewFromFloat(3.0738),
+		Longitude: decimal.NewFromFloat(101.5183),
+		PhoneNo:   "0123456789",
+		CreatedBy: userID,
+	}
+	err = warehouse.Insert(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stock := StockModel{
+		WarehouseID: warehouse.ID,
+		ProductID:   product.ID,
+		Stock:       quantity,
+		CreatedBy:   userID,
+	}
+	err = stock.Insert(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM stock WHERE id = $1`, stock.ID)
+		db.Exec(`DELETE FROM warehouse WHERE id = $1`, warehouse.ID)
+		db.Exec(`DELETE FROM product WHERE id = $1`, product.ID)
+		db.Exec(`DELETE FROM category WHERE id = $1`, category.ID)
+		db.Exec(`DELETE FROM supplier WHERE id = $1`, supplier.ID)
+	})
+
+	return stock
+}
+
+func TestStockReserveConcurrentOrders(t *testing.T) {
+
+	ctx := context.Background()
+	db := testDB(t)
+	defer db.Close()
+
+	const available = 10
+	const orders = 25
+
+	stock := testStock(t, db, available)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	reserved := 0
+
+	for i := 0; i < orders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			tx, err := db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer tx.Rollback()
+
+			reserve := StockModel{
+				WarehouseID: stock.WarehouseID,
+				ProductID:   stock.ProductID,
+				UpdatedBy:   uuid.NullUUID{UUID: uuid.NewV4(), Valid: true},
+			}
+
+			err = reserve.Reserve(ctx, tx, 1)
+			if err == sql.ErrNoRows {
+				return
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			err = tx.Commit()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			mu.Lock()
+			reserved++
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	if reserved != available {
+		t.Fatalf("reserved %d orders, want %d", reserved, available)
+	}
+
+	result, err := GetOneStock(ctx, db, stock.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Stock != 0 {
+		t.Fatalf("stock left %d, want 0", result.Stock)
+	}
+}
+
+func TestStockReserveMoreThanAvailable(t *testing.T) {
+
+	ctx := context.Background()
+	db := testDB(t)
+	defer db.Close()
+
+	stock := testStock(t, db, 3)
+
+	reserve := StockModel{
+		WarehouseID: stock.WarehouseID,
+		ProductID:   stock.ProductID,
+		UpdatedBy:   uuid.NullUUID{UUID: uuid.NewV4(), Valid: true},
+	}
+
+	err := reserve.Reserve(ctx, db, 4)
+	if err != sql.ErrNoRows {
+		t.Fatalf("got %v, want sql.ErrNoRows", err)
+	}
+
+	result, err := GetOneStock(ctx, db, stock.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Stock != 3 {
+		t.Fatalf("stock changed to %d, want 3", result.Stock)
+	}
+}
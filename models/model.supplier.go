@@ -199,7 +199,7 @@ func GetOneSupplierByEmail(ctx context.Context, db *sql.DB, email string) (Suppl
 
 }
 
-func (s *SupplierModel) Insert(ctx context.Context, db *sql.DB) error {
+func (s *SupplierModel) Insert(ctx context.Context, db helpers.DBExecutor) error {
 
 	password, err := bcrypt.GenerateFromPassword([]byte(s.Password), 12)
 
@@ -4,6 +4,7 @@ import (
 	"afiqo-location/helpers"
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"github.com/lib/pq"
 	uuid "github.com/satori/go.uuid"
@@ -13,47 +14,62 @@ import (
 
 type (
 	WarehouseModel struct {
-		ID        uuid.UUID
-		Name      string
-		Address   string
-		Latitude  decimal.Decimal
-		Longitude decimal.Decimal
-		PhoneNo   string
-		IsDelete  bool
-		CreatedBy uuid.UUID
-		CreatedAt time.Time
-		UpdatedBy uuid.NullUUID
-		UpdatedAt pq.NullTime
+		ID              uuid.UUID
+		Name            string
+		Address         string
+		Latitude        decimal.Decimal
+		Longitude       decimal.Decimal
+		PhoneNo         string
+		AddressMismatch bool
+		ServiceRadius   decimal.Decimal
+		ServiceArea     sql.NullString
+		IsDelete        bool
+		CreatedBy       uuid.UUID
+		CreatedAt       time.Time
+		UpdatedBy       uuid.NullUUID
+		UpdatedAt       pq.NullTime
 	}
 
 	WarehouseResponse struct {
-		ID        uuid.UUID       `json:"id"`
-		Name      string          `json:"name"`
-		Address   string          `json:"address"`
-		Latitude  decimal.Decimal `json:"latitude"`
-		Longitude decimal.Decimal `json:"longitude"`
-		PhoneNo   string          `json:"phone_no"`
-		IsDelete  bool            `json:"is_delete"`
-		CreatedBy uuid.UUID       `json:"created_by"`
-		CreatedAt time.Time       `json:"created_at"`
-		UpdatedBy uuid.UUID       `json:"updated_by"`
-		UpdatedAt time.Time       `json:"updated_at"`
+		ID              uuid.UUID       `json:"id"`
+		Name            string          `json:"name"`
+		Address         string          `json:"address"`
+		Latitude        decimal.Decimal `json:"latitude"`
+		Longitude       decimal.Decimal `json:"longitude"`
+		PhoneNo         string          `json:"phone_no"`
+		AddressMismatch bool            `json:"address_mismatch"`
+		ServiceRadius   decimal.Decimal `json:"service_radius"`
+		ServiceArea     json.RawMessage `json:"service_area"`
+		IsDelete        bool            `json:"is_delete"`
+		CreatedBy       uuid.UUID       `json:"created_by"`
+		CreatedAt       time.Time       `json:"created_at"`
+		UpdatedBy       uuid.UUID       `json:"updated_by"`
+		UpdatedAt       time.Time       `json:"updated_at"`
 	}
 )
 
 func (s WarehouseModel) Response() WarehouseResponse {
+
+	var serviceArea json.RawMessage
+	if s.ServiceArea.Valid {
+		serviceArea = json.RawMessage(s.ServiceArea.String)
+	}
+
 	return WarehouseResponse{
-		ID:        s.ID,
-		Name:      s.Name,
-		Address:   s.Address,
-		Latitude:  s.Latitude,
-		Longitude: s.Longitude,
-		PhoneNo:   s.PhoneNo,
-		IsDelete:  s.IsDelete,
-		CreatedBy: s.CreatedBy,
-		CreatedAt: s.CreatedAt,
-		UpdatedBy: s.UpdatedBy.UUID,
-		UpdatedAt: s.UpdatedAt.Time,
+		ID:              s.ID,
+		Name:            s.Name,
+		Address:         s.Address,
+		Latitude:        s.Latitude,
+		Longitude:       s.Longitude,
+		PhoneNo:         s.PhoneNo,
+		AddressMismatch: s.AddressMismatch,
+		ServiceRadius:   s.ServiceRadius,
+		ServiceArea:     serviceArea,
+		IsDelete:        s.IsDelete,
+		CreatedBy:       s.CreatedBy,
+		CreatedAt:       s.CreatedAt,
+		UpdatedBy:       s.UpdatedBy.UUID,
+		UpdatedAt:       s.UpdatedAt.Time,
 	}
 }
 
@@ -67,6 +83,9 @@ func GetOneWarehouse(ctx context.Context, db *sql.DB, warehouseID uuid.UUID) (Wa
 			latitude,
 			longitude,
 			phone_no,
+			address_mismatch,
+			service_radius,
+			service_area,
 			is_delete,
 			created_by,
 			created_at,
@@ -86,6 +105,9 @@ func GetOneWarehouse(ctx context.Context, db *sql.DB, warehouseID uuid.UUID) (Wa
 		&warehouse.Latitude,
 		&warehouse.Longitude,
 		&warehouse.PhoneNo,
+		&warehouse.AddressMismatch,
+		&warehouse.ServiceRadius,
+		&warehouse.ServiceArea,
 		&warehouse.IsDelete,
 		&warehouse.CreatedBy,
 		&warehouse.CreatedAt,
@@ -101,7 +123,8 @@ func GetOneWarehouse(ctx context.Context, db *sql.DB, warehouseID uuid.UUID) (Wa
 
 }
 
-func GetAllWarehouseWithDistance(ctx context.Context, db *sql.DB, filter helpers.Filter) ([]WarehouseModel, error) {
+func GetAllWarehouseWithDistance(ctx context.Context, db helpers.DBExecutor, filter helpers.Filter) (
+	[]WarehouseModel, error) {
 
 	query := fmt.Sprintf(`
 		SELECT
@@ -111,6 +134,9 @@ func GetAllWarehouseWithDistance(ctx context.Context, db *sql.DB, filter helpers
 			latitude,
 			longitude,
 			phone_no,
+			address_mismatch,
+			service_radius,
+			service_area,
 			is_delete,
 			created_by,
 			created_at,
@@ -121,11 +147,19 @@ func GetAllWarehouseWithDistance(ctx context.Context, db *sql.DB, filter helpers
 				POW(69.1 * ($2 - longitude::FLOAT8) * COS(latitude::FLOAT8 / 57.3), 2)) AS distance  
 		FROM 
 			warehouse
+		WHERE 
+			is_delete = false
 		ORDER BY 
 			distance
 		LIMIT $3 OFFSET $4`)
 
-	rows, err := db.QueryContext(ctx, query, filter.Latitude, filter.Longitude, filter.Limit, filter.Offset)
+	// A zero limit means every warehouse, LIMIT NULL is LIMIT ALL in postgres.
+	limit := sql.NullInt64{
+		Int64: int64(filter.Limit),
+		Valid: filter.Limit > 0,
+	}
+
+	rows, err := db.QueryContext(ctx, query, filter.Latitude, filter.Longitude, limit, filter.Offset)
 
 	if err != nil {
 		return nil, err
@@ -145,6 +179,9 @@ func GetAllWarehouseWithDistance(ctx context.Context, db *sql.DB, filter helpers
 			&warehouse.Latitude,
 			&warehouse.Longitude,
 			&warehouse.PhoneNo,
+			&warehouse.AddressMismatch,
+			&warehouse.ServiceRadius,
+			&warehouse.ServiceArea,
 			&warehouse.IsDelete,
 			&warehouse.CreatedBy,
 			&warehouse.CreatedAt,
@@ -161,6 +198,71 @@ func GetAllWarehouseWithDistance(ctx context.Context, db *sql.DB, filter helpers
 
 }
 
+func GetAllWarehouseByOrderID(ctx context.Context, db helpers.DBExecutor, orderID uuid.UUID) (
+	[]WarehouseModel, error) {
+
+	query := fmt.Sprintf(`
+		SELECT DISTINCT
+			w.id,
+			w.name,
+			w.address,
+			w.latitude,
+			w.longitude,
+			w.phone_no,
+			w.address_mismatch,
+			w.service_radius,
+			w.service_area,
+			w.is_delete,
+			w.created_by,
+			w.created_at,
+			w.updated_by,
+			w.updated_at
+		FROM 
+			warehouse w
+		INNER JOIN
+			order_product op
+		ON
+			op.warehouse_id = w.id
+		WHERE 
+			op.order_id = $1
+	`)
+
+	rows, err := db.QueryContext(ctx, query, orderID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var warehouses []WarehouseModel
+	for rows.Next() {
+		var warehouse WarehouseModel
+
+		rows.Scan(
+			&warehouse.ID,
+			&warehouse.Name,
+			&warehouse.Address,
+			&warehouse.Latitude,
+			&warehouse.Longitude,
+			&warehouse.PhoneNo,
+			&warehouse.AddressMismatch,
+			&warehouse.ServiceRadius,
+			&warehouse.ServiceArea,
+			&warehouse.IsDelete,
+			&warehouse.CreatedBy,
+			&warehouse.CreatedAt,
+			&warehouse.UpdatedBy,
+			&warehouse.UpdatedAt,
+		)
+
+		warehouses = append(warehouses, warehouse)
+	}
+
+	return warehouses, nil
+
+}
+
 func GetAllWarehouse(ctx context.Context, db *sql.DB, filter helpers.Filter) ([]WarehouseModel, error) {
 
 	var searchQuery string
@@ -177,6 +279,9 @@ func GetAllWarehouse(ctx context.Context, db *sql.DB, filter helpers.Filter) ([]
 			latitude,
 			longitude,
 			phone_no,
+			address_mismatch,
+			service_radius,
+			service_area,
 			is_delete,
 			created_by,
 			created_at,
@@ -209,6 +314,9 @@ func GetAllWarehouse(ctx context.Context, db *sql.DB, filter helpers.Filter) ([]
 			&warehouse.Latitude,
 			&warehouse.Longitude,
 			&warehouse.PhoneNo,
+			&warehouse.AddressMismatch,
+			&warehouse.ServiceRadius,
+			&warehouse.ServiceArea,
 			&warehouse.IsDelete,
 			&warehouse.CreatedBy,
 			&warehouse.CreatedAt,
@@ -232,16 +340,20 @@ func (s *WarehouseModel) Insert(ctx context.Context, db *sql.DB) error {
 			latitude,
 			longitude,
 			phone_no,
+			address_mismatch,
+			service_radius,
+			service_area,
 			created_by,
 			created_at)
 		VALUES(
-			$1,$2,$3,$4,$5,$6,now())
+			$1,$2,$3,$4,$5,$6,$7,$8,$9,now())
 		RETURNING 
 			id, created_at
 	`)
 
 	err := db.QueryRowContext(ctx, query,
-		s.Name, s.Address, s.Latitude, s.Longitude, s.PhoneNo, s.CreatedBy).Scan(
+		s.Name, s.Address, s.Latitude, s.Longitude, s.PhoneNo, s.AddressMismatch, s.ServiceRadius,
+		s.ServiceArea, s.CreatedBy).Scan(
 		&s.ID, &s.CreatedAt,
 	)
 
@@ -263,16 +375,20 @@ func (s *WarehouseModel) Update(ctx context.Context, db *sql.DB) error {
 			latitude=$3,
 			longitude=$4,
 			phone_no=$5,
+			address_mismatch=$6,
+			service_radius=$7,
+			service_area=$8,
 			updated_at=NOW(),
-			updated_by=$6
+			updated_by=$9
 		WHERE 
-			id=$7
+			id=$10
 		RETURNING 
 			id,created_at,updated_at,created_by
 	`)
 
 	err := db.QueryRowContext(ctx, query,
-		s.Name, s.Address, s.Latitude, s.Longitude, s.PhoneNo, s.UpdatedBy, s.ID).Scan(
+		s.Name, s.Address, s.Latitude, s.Longitude, s.PhoneNo, s.AddressMismatch, s.ServiceRadius,
+		s.ServiceArea, s.UpdatedBy, s.ID).Scan(
 		&s.ID, &s.CreatedAt, &s.UpdatedAt, &s.CreatedBy,
 	)
 
@@ -0,0 +1,100 @@
+package routers
+
+import (
+	"afiqo-location/api"
+	"afiqo-location/helpers"
+	"github.com/gorilla/mux"
+	uuid "github.com/satori/go.uuid"
+	"net/http"
+)
+
+func HandlerCartDetail(w http.ResponseWriter, r *http.Request) (interface{}, *helpers.Error) {
+
+	ctx := r.Context()
+
+	customerID := uuid.FromStringOrNil(ctx.Value("user_id").(string))
+
+	param := api.CustomerDataParam{ID: customerID}
+
+	return cartService.Detail(ctx, param)
+}
+
+func HandlerCartItemAdd(w http.ResponseWriter, r *http.Request) (interface{}, *helpers.Error) {
+
+	ctx := r.Context()
+
+	var param api.CartItemParam
+
+	err := helpers.ParseBodyRequestData(ctx, r, &param)
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerCartItemAdd/ParseBodyRequestData",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	param.CustomerID = uuid.FromStringOrNil(ctx.Value("user_id").(string))
+
+	return cartService.Add(ctx, param)
+}
+
+func HandlerCartItemUpdate(w http.ResponseWriter, r *http.Request) (interface{}, *helpers.Error) {
+
+	ctx := r.Context()
+
+	params := mux.Vars(r)
+
+	productID, err := uuid.FromString(params["product_id"])
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerCartItemUpdate/parseID",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	param := api.CartItemParam{ProductID: productID}
+
+	err = helpers.ParseBodyRequestData(ctx, r, &param)
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerCartItemUpdate/ParseBodyRequestData",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	param.ProductID = productID
+	param.CustomerID = uuid.FromStringOrNil(ctx.Value("user_id").(string))
+
+	return cartService.Update(ctx, param)
+}
+
+func HandlerCartItemRemove(w http.ResponseWriter, r *http.Request) (interface{}, *helpers.Error) {
+
+	ctx := r.Context()
+
+	params := mux.Vars(r)
+
+	productID, err := uuid.FromString(params["product_id"])
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerCartItemRemove/parseID",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	param := api.CartItemRemoveParam{
+		CustomerID: uuid.FromStringOrNil(ctx.Value("user_id").(string)),
+		ProductID:  productID,
+	}
+
+	return cartService.Remove(ctx, param)
+}
+
+func HandlerCartCheckout(w http.ResponseWriter, r *http.Request) (interface{}, *helpers.Error) {
+
+	ctx := r.Context()
+
+	var param api.CartCheckoutParam
+
+	err := helpers.ParseBodyRequestData(ctx, r, &param)
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerCartCheckout/ParseBodyRequestData",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	param.CustomerID = uuid.FromStringOrNil(ctx.Value("user_id").(string))
+
+	return cartService.Checkout(ctx, param)
+}
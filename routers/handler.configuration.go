@@ -15,7 +15,7 @@ func HandlerConfigurationUpdate(w http.ResponseWriter, r *http.Request) (interfa
 	err := helpers.ParseBodyRequestData(ctx, r, &param)
 	if err != nil {
 
-		return nil, helpers.ErrorWrap(err, "handler", "HandlerSupplierUpdate/ParseBodyRequestData",
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerConfigurationUpdate/ParseBodyRequestData",
 			helpers.BadRequestMessage, http.StatusBadRequest)
 
 	}
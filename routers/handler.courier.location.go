@@ -0,0 +1,43 @@
+package routers
+
+import (
+	"afiqo-location/api"
+	"afiqo-location/helpers"
+	"github.com/gorilla/mux"
+	uuid "github.com/satori/go.uuid"
+	"net/http"
+)
+
+func HandlerCourierLocationAdd(w http.ResponseWriter, r *http.Request) (interface{}, *helpers.Error) {
+
+	ctx := r.Context()
+
+	var param api.CourierLocationAddParam
+
+	err := helpers.ParseBodyRequestData(ctx, r, &param)
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerCourierLocationAdd/ParseBodyRequestData",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	param.CourierID = uuid.FromStringOrNil(ctx.Value("user_id").(string))
+
+	return courierLocationService.Add(ctx, param)
+}
+
+func HandlerCourierLocationByShipment(w http.ResponseWriter, r *http.Request) (interface{}, *helpers.Error) {
+
+	ctx := r.Context()
+
+	params := mux.Vars(r)
+
+	shipmentID, err := uuid.FromString(params["id"])
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerCourierLocationByShipment/parseID",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	param := api.CourierLocationShipmentParam{ShipmentID: shipmentID}
+
+	return courierLocationService.ShipmentCourier(ctx, param)
+}
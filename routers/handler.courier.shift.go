@@ -0,0 +1,97 @@
+package routers
+
+import (
+	"afiqo-location/api"
+	"afiqo-location/helpers"
+	"github.com/gorilla/mux"
+	uuid "github.com/satori/go.uuid"
+	"net/http"
+)
+
+func HandlerCourierShiftList(w http.ResponseWriter, r *http.Request) (interface{}, *helpers.Error) {
+
+	ctx := r.Context()
+
+	filter, err := helpers.ParseFilter(ctx, r)
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerCourierShiftList/parseFilter",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	return courierShiftService.List(ctx, filter)
+}
+
+func HandlerCourierShiftDetail(w http.ResponseWriter, r *http.Request) (interface{}, *helpers.Error) {
+
+	ctx := r.Context()
+
+	params := mux.Vars(r)
+
+	shiftID, err := uuid.FromString(params["id"])
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerCourierShiftDetail/parseID",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	param := api.CourierShiftDetailParam{ID: shiftID}
+
+	return courierShiftService.Detail(ctx, param)
+}
+
+func HandlerCourierShiftAdd(w http.ResponseWriter, r *http.Request) (interface{}, *helpers.Error) {
+
+	ctx := r.Context()
+
+	var param api.CourierShiftParam
+
+	err := helpers.ParseBodyRequestData(ctx, r, &param)
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerCourierShiftAdd/ParseBodyRequestData",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	return courierShiftService.Add(ctx, param)
+}
+
+func HandlerCourierShiftUpdate(w http.ResponseWriter, r *http.Request) (interface{}, *helpers.Error) {
+
+	ctx := r.Context()
+
+	params := mux.Vars(r)
+
+	shiftID, err := uuid.FromString(params["id"])
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerCourierShiftUpdate/parseID",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	var param api.CourierShiftParam
+
+	err = helpers.ParseBodyRequestData(ctx, r, &param)
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerCourierShiftUpdate/ParseBodyRequestData",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	param.ID = shiftID
+
+	return courierShiftService.Update(ctx, param)
+}
+
+func HandlerCourierShiftDelete(w http.ResponseWriter, r *http.Request) (interface{}, *helpers.Error) {
+
+	ctx := r.Context()
+
+	params := mux.Vars(r)
+
+	shiftID, err := uuid.FromString(params["id"])
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerCourierShiftDelete/parseID",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	param := api.CourierShiftDetailParam{ID: shiftID}
+
+	return courierShiftService.Delete(ctx, param)
+}
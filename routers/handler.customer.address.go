@@ -0,0 +1,103 @@
+package routers
+
+import (
+	"afiqo-location/api"
+	"afiqo-location/helpers"
+	"github.com/gorilla/mux"
+	uuid "github.com/satori/go.uuid"
+	"net/http"
+)
+
+func HandlerCustomerAddressList(w http.ResponseWriter, r *http.Request) (interface{}, *helpers.Error) {
+
+	ctx := r.Context()
+
+	customerID := uuid.FromStringOrNil(ctx.Value("user_id").(string))
+
+	param := api.CustomerDataParam{ID: customerID}
+
+	return customerAddressService.List(ctx, param)
+}
+
+func HandlerCustomerAddressDetail(w http.ResponseWriter, r *http.Request) (interface{}, *helpers.Error) {
+
+	ctx := r.Context()
+
+	params := mux.Vars(r)
+
+	addressID, err := uuid.FromString(params["id"])
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerCustomerAddressDetail/parseID",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	param := api.CustomerAddressDetailParam{
+		ID:         addressID,
+		CustomerID: uuid.FromStringOrNil(ctx.Value("user_id").(string)),
+	}
+
+	return customerAddressService.Detail(ctx, param)
+}
+
+func HandlerCustomerAddressAdd(w http.ResponseWriter, r *http.Request) (interface{}, *helpers.Error) {
+
+	ctx := r.Context()
+
+	var param api.CustomerAddressParam
+
+	err := helpers.ParseBodyRequestData(ctx, r, &param)
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerCustomerAddressAdd/ParseBodyRequestData",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	param.CustomerID = uuid.FromStringOrNil(ctx.Value("user_id").(string))
+
+	return customerAddressService.Add(ctx, param)
+}
+
+func HandlerCustomerAddressUpdate(w http.ResponseWriter, r *http.Request) (interface{}, *helpers.Error) {
+
+	ctx := r.Context()
+
+	params := mux.Vars(r)
+
+	addressID, err := uuid.FromString(params["id"])
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerCustomerAddressUpdate/parseID",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	var param api.CustomerAddressParam
+
+	err = helpers.ParseBodyRequestData(ctx, r, &param)
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerCustomerAddressUpdate/ParseBodyRequestData",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	param.ID = addressID
+	param.CustomerID = uuid.FromStringOrNil(ctx.Value("user_id").(string))
+
+	return customerAddressService.Update(ctx, param)
+}
+
+func HandlerCustomerAddressDelete(w http.ResponseWriter, r *http.Request) (interface{}, *helpers.Error) {
+
+	ctx := r.Context()
+
+	params := mux.Vars(r)
+
+	addressID, err := uuid.FromString(params["id"])
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerCustomerAddressDelete/parseID",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	param := api.CustomerAddressDetailParam{
+		ID:         addressID,
+		CustomerID: uuid.FromStringOrNil(ctx.Value("user_id").(string)),
+	}
+
+	return customerAddressService.Delete(ctx, param)
+}
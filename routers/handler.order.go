@@ -3,6 +3,9 @@ package routers
 import (
 	"afiqo-location/api"
 	"afiqo-location/helpers"
+	"afiqo-location/invoice"
+	"bytes"
+	"fmt"
 	"github.com/gorilla/mux"
 	uuid "github.com/satori/go.uuid"
 	"net/http"
@@ -73,6 +76,23 @@ func HandlerOrder(w http.ResponseWriter, r *http.Request) (interface{}, *helpers
 	return orderService.Order(ctx, param)
 }
 
+func HandlerOrderQuote(w http.ResponseWriter, r *http.Request) (interface{}, *helpers.Error) {
+
+	ctx := r.Context()
+
+	var param api.OrderParam
+
+	err := helpers.ParseBodyRequestData(ctx, r, &param)
+	if err != nil {
+
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerOrderQuote/ParseBodyRequestData",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+
+	}
+
+	return orderService.Quote(ctx, param)
+}
+
 func HandlerOrderDelete(w http.ResponseWriter, r *http.Request) (interface{}, *helpers.Error) {
 
 	ctx := r.Context()
@@ -92,3 +112,75 @@ func HandlerOrderDelete(w http.ResponseWriter, r *http.Request) (interface{}, *h
 
 	return orderService.Delete(ctx, param)
 }
+
+func HandlerOrderCancel(w http.ResponseWriter, r *http.Request) (interface{}, *helpers.Error) {
+
+	ctx := r.Context()
+
+	params := mux.Vars(r)
+
+	orderID, err := uuid.FromString(params["id"])
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerOrderCancel/parseID",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	param := api.OrderCancelParam{ID: orderID}
+
+	return orderService.Cancel(ctx, param)
+}
+
+// HandlerOrderInvoice writes the invoice document itself rather than going through HandlerFunc. The format
+// query picks pdf, the default, or html.
+func HandlerOrderInvoice(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	params := mux.Vars(r)
+
+	orderID, err := uuid.FromString(params["id"])
+	if err != nil {
+		helpers.ErrorResponse(w, helpers.BadRequestMessage, http.StatusBadRequest)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = invoice.FormatPDF
+	}
+
+	if format != invoice.FormatPDF && format != invoice.FormatHTML {
+		helpers.ErrorResponse(w, helpers.BadRequestMessage, http.StatusBadRequest)
+		return
+	}
+
+	param := api.OrderInvoiceParam{ID: orderID}
+
+	document, errs := orderService.Invoice(ctx, param)
+	if errs != nil {
+		helpers.ErrorResponse(w, errs.Message, errs.StatusCode)
+		return
+	}
+
+	var body bytes.Buffer
+	contentType := "application/pdf"
+
+	if format == invoice.FormatHTML {
+		contentType = "text/html; charset=utf-8"
+		err = document.RenderHTML(&body)
+	} else {
+		err = document.RenderPDF(&body)
+	}
+
+	if err != nil {
+		logger.Err.Printf(`handler.order.go/HandlerOrderInvoice/Render/%s/%v`, orderID, err)
+		helpers.ErrorResponse(w, helpers.InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s.%s"`, document.Reference(), format))
+	w.WriteHeader(http.StatusOK)
+	body.WriteTo(w)
+}
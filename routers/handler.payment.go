@@ -5,9 +5,12 @@ import (
 	"afiqo-location/helpers"
 	"github.com/gorilla/mux"
 	uuid "github.com/satori/go.uuid"
+	"io/ioutil"
 	"net/http"
 )
 
+const maxWebhookBody = 1 << 20
+
 func HandlerPaymentList(w http.ResponseWriter, r *http.Request) (interface{}, *helpers.Error) {
 
 	ctx := r.Context()
@@ -55,3 +58,47 @@ func HandlerPaymentUpdate(w http.ResponseWriter, r *http.Request) (interface{},
 
 	return paymentService.Update(ctx, param)
 }
+
+// HandlerPaymentWebhook takes notifications from the payment gateway, which signs the raw body.
+func HandlerPaymentWebhook(w http.ResponseWriter, r *http.Request) (interface{}, *helpers.Error) {
+
+	ctx := r.Context()
+
+	payload, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerPaymentWebhook/ReadAll",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	param := api.PaymentWebhookParam{
+		Payload:   payload,
+		Signature: r.Header.Get("X-Signature"),
+	}
+
+	return paymentService.Webhook(ctx, param)
+}
+
+func HandlerPaymentRefund(w http.ResponseWriter, r *http.Request) (interface{}, *helpers.Error) {
+
+	ctx := r.Context()
+
+	params := mux.Vars(r)
+
+	paymentID, err := uuid.FromString(params["id"])
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerPaymentRefund/parseID",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	var param api.PaymentRefundParam
+
+	err = helpers.ParseBodyRequestData(ctx, r, &param)
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerPaymentRefund/ParseBodyRequestData",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	param.ID = paymentID
+
+	return paymentService.Refund(ctx, param)
+}
@@ -3,11 +3,20 @@ package routers
 import (
 	"afiqo-location/api"
 	"afiqo-location/helpers"
+	"fmt"
 	"github.com/gorilla/mux"
 	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	"io"
 	"net/http"
 )
 
+const (
+	// maxProofUpload caps a whole proof of delivery request, photo and signature included.
+	maxProofUpload = 16 << 20
+	maxProofMemory = 4 << 20
+)
+
 func HandlerShipmentList(w http.ResponseWriter, r *http.Request) (interface{}, *helpers.Error) {
 
 	ctx := r.Context()
@@ -39,6 +48,25 @@ func HandlerShipmentListByCourierID(w http.ResponseWriter, r *http.Request) (int
 	return shipmentService.ListByCourierID(ctx, filter, param)
 }
 
+func HandlerCourierRoute(w http.ResponseWriter, r *http.Request) (interface{}, *helpers.Error) {
+
+	ctx := r.Context()
+
+	filter, err := helpers.ParseFilter(ctx, r)
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerCourierRoute/parseFilter",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	param := api.CourierRouteParam{
+		CourierID:   uuid.FromStringOrNil(ctx.Value("user_id").(string)),
+		WarehouseID: filter.WarehouseID,
+	}
+
+	return shipmentService.Route(ctx, param)
+}
+
 func HandlerShipmentListByCustomerID(w http.ResponseWriter, r *http.Request) (interface{}, *helpers.Error) {
 
 	ctx := r.Context()
@@ -88,3 +116,250 @@ func HandlerShipmentAdd(w http.ResponseWriter, r *http.Request) (interface{}, *h
 
 	return shipmentService.Add(ctx, param)
 }
+
+func HandlerShipmentStatusUpdate(w http.ResponseWriter, r *http.Request) (interface{}, *helpers.Error) {
+
+	ctx := r.Context()
+
+	params := mux.Vars(r)
+
+	shipmentID, err := uuid.FromString(params["id"])
+
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerShipmentStatusUpdate/parseID",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	var param api.ShipmentUpdateParam
+
+	err = helpers.ParseBodyRequestData(ctx, r, &param)
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerShipmentStatusUpdate/ParseBodyRequestData",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	param.ID = shipmentID
+
+	return shipmentService.UpdateStatus(ctx, param)
+}
+
+func HandlerShipmentEvents(w http.ResponseWriter, r *http.Request) (interface{}, *helpers.Error) {
+
+	ctx := r.Context()
+
+	params := mux.Vars(r)
+
+	shipmentID, err := uuid.FromString(params["id"])
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerShipmentEvents/parseID",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	param := api.ShipmentDetailParam{ID: shipmentID}
+
+	return shipmentService.Events(ctx, param)
+}
+
+func HandlerShipmentTracking(w http.ResponseWriter, r *http.Request) (interface{}, *helpers.Error) {
+
+	ctx := r.Context()
+
+	params := mux.Vars(r)
+
+	shipmentID, err := uuid.FromString(params["id"])
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerShipmentTracking/parseID",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	param := api.ShipmentDetailParam{ID: shipmentID}
+
+	return shipmentService.Tracking(ctx, param)
+}
+
+// HandlerShipmentStream writes server-sent events itself rather than going through HandlerFunc. The server
+// write timeout still ends the stream, so clients are told to reconnect and receive a fresh snapshot.
+func HandlerShipmentStream(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	params := mux.Vars(r)
+
+	shipmentID, err := uuid.FromString(params["id"])
+	if err != nil {
+		helpers.ErrorResponse(w, helpers.BadRequestMessage, http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		helpers.ErrorResponse(w, helpers.InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	started := false
+	send := func(message api.StreamMessage) error {
+		if !started {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("Connection", "keep-alive")
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, "retry: 3000\n\n")
+			started = true
+		}
+
+		var err error
+		if message.Event == api.StreamEventPing {
+			_, err = fmt.Fprint(w, ": ping\n\n")
+		} else {
+			_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", message.Event, message.Data)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		flusher.Flush()
+		return nil
+	}
+
+	param := api.ShipmentStreamParam{ID: shipmentID}
+
+	errs := shipmentService.Stream(ctx, param, send)
+	if errs != nil && !started {
+		helpers.ErrorResponse(w, errs.Message, errs.StatusCode)
+	}
+}
+
+func HandlerShipmentCourierUpdate(w http.ResponseWriter, r *http.Request) (interface{}, *helpers.Error) {
+
+	ctx := r.Context()
+
+	params := mux.Vars(r)
+
+	shipmentID, err := uuid.FromString(params["id"])
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerShipmentCourierUpdate/parseID",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	var param api.ShipmentCourierParam
+
+	err = helpers.ParseBodyRequestData(ctx, r, &param)
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerShipmentCourierUpdate/ParseBodyRequestData",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	param.ID = shipmentID
+
+	return shipmentService.AssignCourier(ctx, param)
+}
+
+func HandlerShipmentDeliver(w http.ResponseWriter, r *http.Request) (interface{}, *helpers.Error) {
+
+	ctx := r.Context()
+
+	params := mux.Vars(r)
+
+	shipmentID, err := uuid.FromString(params["id"])
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerShipmentDeliver/parseID",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxProofUpload)
+
+	err = r.ParseMultipartForm(maxProofMemory)
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerShipmentDeliver/ParseMultipartForm",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	defer r.MultipartForm.RemoveAll()
+
+	latitude, err := decimal.NewFromString(r.FormValue("latitude"))
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerShipmentDeliver/parseLatitude",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	longitude, err := decimal.NewFromString(r.FormValue("longitude"))
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerShipmentDeliver/parseLongitude",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	photo, _, err := r.FormFile(api.ProofPhoto)
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerShipmentDeliver/FormFile",
+			helpers.ProofRequiredMessage, http.StatusBadRequest)
+	}
+
+	defer photo.Close()
+
+	signature, _, err := r.FormFile(api.ProofSignature)
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerShipmentDeliver/FormFile",
+			helpers.ProofRequiredMessage, http.StatusBadRequest)
+	}
+
+	defer signature.Close()
+
+	param := api.ShipmentProofParam{
+		ID:            shipmentID,
+		RecipientName: r.FormValue("recipient_name"),
+		Latitude:      latitude,
+		Longitude:     longitude,
+		Photo:         photo,
+		Signature:     signature,
+	}
+
+	return shipmentService.Deliver(ctx, param)
+}
+
+func HandlerShipmentProof(w http.ResponseWriter, r *http.Request) (interface{}, *helpers.Error) {
+
+	ctx := r.Context()
+
+	params := mux.Vars(r)
+
+	shipmentID, err := uuid.FromString(params["id"])
+	if err != nil {
+		return nil, helpers.ErrorWrap(err, "handler", "HandlerShipmentProof/parseID",
+			helpers.BadRequestMessage, http.StatusBadRequest)
+	}
+
+	param := api.ShipmentDetailParam{ID: shipmentID}
+
+	return shipmentService.Proof(ctx, param)
+}
+
+// HandlerShipmentProofFile streams the stored image itself rather than going through HandlerFunc.
+func HandlerShipmentProofFile(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	params := mux.Vars(r)
+
+	shipmentID, err := uuid.FromString(params["id"])
+	if err != nil {
+		helpers.ErrorResponse(w, helpers.BadRequestMessage, http.StatusBadRequest)
+		return
+	}
+
+	param := api.ShipmentProofFileParam{ID: shipmentID, Kind: params["kind"]}
+
+	file, contentType, errs := shipmentService.ProofFile(ctx, param)
+	if errs != nil {
+		helpers.ErrorResponse(w, errs.Message, errs.StatusCode)
+		return
+	}
+
+	defer file.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, file)
+}
@@ -43,8 +43,42 @@ func InitHandlers() *mux.Router {
 
 	apiV1.Handle("/courier/shipments", middleware.SessionMiddleware(middleware.RolesMiddleware(
 		HandlerFunc(HandlerShipmentListByCourierID), session.COURIER_ROLE))).Methods(http.MethodGet)
+	apiV1.Handle("/courier/shipments/{id}/status", middleware.SessionMiddleware(middleware.RolesMiddleware(
+		HandlerFunc(HandlerShipmentStatusUpdate), session.COURIER_ROLE))).Methods(http.MethodPut)
+	apiV1.Handle("/courier/shipments/{id}/proof", middleware.SessionMiddleware(middleware.RolesMiddleware(
+		HandlerFunc(HandlerShipmentDeliver), session.COURIER_ROLE))).Methods(http.MethodPost)
+	apiV1.Handle("/courier/route", middleware.SessionMiddleware(middleware.RolesMiddleware(
+		HandlerFunc(HandlerCourierRoute), session.COURIER_ROLE))).Methods(http.MethodGet)
+	apiV1.Handle("/courier/locations", middleware.SessionMiddleware(middleware.RolesMiddleware(
+		HandlerFunc(HandlerCourierLocationAdd), session.COURIER_ROLE))).Methods(http.MethodPost)
 	apiV1.Handle("/customer/shipments", middleware.SessionMiddleware(middleware.RolesMiddleware(
 		HandlerFunc(HandlerShipmentListByCustomerID), session.CUSTOMER_ROLE))).Methods(http.MethodGet)
+	apiV1.Handle("/customer/shipments/{id}/tracking", middleware.SessionMiddleware(middleware.RolesMiddleware(
+		HandlerFunc(HandlerShipmentTracking), session.CUSTOMER_ROLE))).Methods(http.MethodGet)
+	apiV1.Handle("/customer/shipments/{id}/stream", middleware.SessionMiddleware(middleware.RolesMiddleware(
+		http.HandlerFunc(HandlerShipmentStream), session.CUSTOMER_ROLE))).Methods(http.MethodGet)
+	apiV1.Handle("/customer/shipments/{id}/courier-location", middleware.SessionMiddleware(middleware.RolesMiddleware(
+		HandlerFunc(HandlerCourierLocationByShipment), session.CUSTOMER_ROLE))).Methods(http.MethodGet)
+	apiV1.Handle("/customer/cart", middleware.SessionMiddleware(middleware.RolesMiddleware(
+		HandlerFunc(HandlerCartDetail), session.CUSTOMER_ROLE))).Methods(http.MethodGet)
+	apiV1.Handle("/customer/cart/items", middleware.SessionMiddleware(middleware.RolesMiddleware(
+		HandlerFunc(HandlerCartItemAdd), session.CUSTOMER_ROLE))).Methods(http.MethodPost)
+	apiV1.Handle("/customer/cart/items/{product_id}", middleware.SessionMiddleware(middleware.RolesMiddleware(
+		HandlerFunc(HandlerCartItemUpdate), session.CUSTOMER_ROLE))).Methods(http.MethodPut)
+	apiV1.Handle("/customer/cart/items/{product_id}", middleware.SessionMiddleware(middleware.RolesMiddleware(
+		HandlerFunc(HandlerCartItemRemove), session.CUSTOMER_ROLE))).Methods(http.MethodDelete)
+	apiV1.Handle("/customer/cart/checkout", middleware.SessionMiddleware(middleware.RolesMiddleware(
+		HandlerFunc(HandlerCartCheckout), session.CUSTOMER_ROLE))).Methods(http.MethodPost)
+	apiV1.Handle("/customer/addresses", middleware.SessionMiddleware(middleware.RolesMiddleware(
+		HandlerFunc(HandlerCustomerAddressList), session.CUSTOMER_ROLE))).Methods(http.MethodGet)
+	apiV1.Handle("/customer/addresses/{id}", middleware.SessionMiddleware(middleware.RolesMiddleware(
+		HandlerFunc(HandlerCustomerAddressDetail), session.CUSTOMER_ROLE))).Methods(http.MethodGet)
+	apiV1.Handle("/customer/addresses", middleware.SessionMiddleware(middleware.RolesMiddleware(
+		HandlerFunc(HandlerCustomerAddressAdd), session.CUSTOMER_ROLE))).Methods(http.MethodPost)
+	apiV1.Handle("/customer/addresses/{id}", middleware.SessionMiddleware(middleware.RolesMiddleware(
+		HandlerFunc(HandlerCustomerAddressUpdate), session.CUSTOMER_ROLE))).Methods(http.MethodPut)
+	apiV1.Handle("/customer/addresses/{id}", middleware.SessionMiddleware(middleware.RolesMiddleware(
+		HandlerFunc(HandlerCustomerAddressDelete), session.CUSTOMER_ROLE))).Methods(http.MethodDelete)
 	apiV1.Handle("/customer/orders", middleware.SessionMiddleware(middleware.RolesMiddleware(
 		HandlerFunc(HandlerOrderListByCustomerID), session.CUSTOMER_ROLE))).Methods(http.MethodGet)
 	apiV1.Handle("/customer/products", middleware.SessionMiddleware(middleware.RolesMiddleware(
@@ -95,6 +129,16 @@ func InitHandlers() *mux.Router {
 		HandlerFunc(HandlerCourierUpdate), session.COURIER_ROLE))).Methods(http.MethodPut)
 	apiV1.Handle("/couriers/{id}", middleware.SessionMiddleware(middleware.RolesMiddleware(
 		HandlerFunc(HandlerCourierDelete), session.ADMIN_ROLE))).Methods(http.MethodDelete)
+	apiV1.Handle("/courier-shifts", middleware.SessionMiddleware(middleware.RolesMiddleware(
+		HandlerFunc(HandlerCourierShiftList), session.ADMIN_ROLE))).Methods(http.MethodGet)
+	apiV1.Handle("/courier-shifts/{id}", middleware.SessionMiddleware(middleware.RolesMiddleware(
+		HandlerFunc(HandlerCourierShiftDetail), session.ADMIN_ROLE))).Methods(http.MethodGet)
+	apiV1.Handle("/courier-shifts", middleware.SessionMiddleware(middleware.RolesMiddleware(
+		HandlerFunc(HandlerCourierShiftAdd), session.ADMIN_ROLE))).Methods(http.MethodPost)
+	apiV1.Handle("/courier-shifts/{id}", middleware.SessionMiddleware(middleware.RolesMiddleware(
+		HandlerFunc(HandlerCourierShiftUpdate), session.ADMIN_ROLE))).Methods(http.MethodPut)
+	apiV1.Handle("/courier-shifts/{id}", middleware.SessionMiddleware(middleware.RolesMiddleware(
+		HandlerFunc(HandlerCourierShiftDelete), session.ADMIN_ROLE))).Methods(http.MethodDelete)
 	apiV1.Handle("/courier/password-update", middleware.SessionMiddleware(middleware.RolesMiddleware(
 		HandlerFunc(HandlerCourierPasswordUpdate), session.COURIER_ROLE))).Methods(http.MethodPut)
 	apiV1.Handle("/courier/login", HandlerFunc(HandlerCourierLogin)).Methods(http.MethodPost)
@@ -134,19 +178,33 @@ func InitHandlers() *mux.Router {
 	apiV1.Handle("/warehouses/{id}", middleware.SessionMiddleware(middleware.RolesMiddleware(
 		HandlerFunc(HandlerWarehouseDelete), session.ADMIN_ROLE))).Methods(http.MethodDelete)
 
+	apiV1.Handle("/configuration", middleware.SessionMiddleware(middleware.RolesMiddleware(
+		HandlerFunc(HandlerConfigurationUpdate), session.ADMIN_ROLE))).Methods(http.MethodPut)
+
 	apiV1.Handle("/payments", middleware.SessionMiddleware(
 		HandlerFunc(HandlerPaymentList))).Methods(http.MethodGet)
 	apiV1.Handle("/payments/{id}", middleware.SessionMiddleware(
 		HandlerFunc(HandlerPaymentDetail))).Methods(http.MethodGet)
 	apiV1.Handle("/payments/{id}", middleware.SessionMiddleware(middleware.RolesMiddleware(
 		HandlerFunc(HandlerPaymentUpdate), session.ADMIN_ROLE, session.CUSTOMER_ROLE))).Methods(http.MethodPut)
+	apiV1.Handle("/payments/webhook", HandlerFunc(HandlerPaymentWebhook)).Methods(http.MethodPost)
+	apiV1.Handle("/payments/{id}/refunds", middleware.SessionMiddleware(middleware.RolesMiddleware(
+		HandlerFunc(HandlerPaymentRefund), session.ADMIN_ROLE))).Methods(http.MethodPost)
 
 	apiV1.Handle("/shipments", middleware.SessionMiddleware(
 		HandlerFunc(HandlerShipmentList))).Methods(http.MethodGet)
 	apiV1.Handle("/shipments/{id}", middleware.SessionMiddleware(
 		HandlerFunc(HandlerShipmentDetail))).Methods(http.MethodGet)
+	apiV1.Handle("/shipments/{id}/events", middleware.SessionMiddleware(
+		HandlerFunc(HandlerShipmentEvents))).Methods(http.MethodGet)
+	apiV1.Handle("/shipments/{id}/proof", middleware.SessionMiddleware(
+		HandlerFunc(HandlerShipmentProof))).Methods(http.MethodGet)
+	apiV1.Handle("/shipments/{id}/proof/{kind}", middleware.SessionMiddleware(
+		http.HandlerFunc(HandlerShipmentProofFile))).Methods(http.MethodGet)
 	apiV1.Handle("/shipments", middleware.SessionMiddleware(middleware.RolesMiddleware(
 		HandlerFunc(HandlerShipmentAdd), session.ADMIN_ROLE))).Methods(http.MethodPost)
+	apiV1.Handle("/shipments/{id}/courier", middleware.SessionMiddleware(middleware.RolesMiddleware(
+		HandlerFunc(HandlerShipmentCourierUpdate), session.ADMIN_ROLE))).Methods(http.MethodPut)
 	//apiV1.Handle("/shipments/{id}", middleware.SessionMiddleware(middleware.RolesMiddleware(
 	//	HandlerFunc(HandlerShipmentUpdate), session.ADMIN_ROLE))).Methods(http.MethodPut)
 	//apiV1.Handle("/shipments/{id}", middleware.SessionMiddleware(middleware.RolesMiddleware(
@@ -158,8 +216,14 @@ func InitHandlers() *mux.Router {
 		HandlerFunc(HandlerOrderDetail))).Methods(http.MethodGet)
 	apiV1.Handle("/orders", middleware.SessionMiddleware(middleware.RolesMiddleware(
 		HandlerFunc(HandlerOrder), session.CUSTOMER_ROLE))).Methods(http.MethodPost)
+	apiV1.Handle("/orders/quote", middleware.SessionMiddleware(middleware.RolesMiddleware(
+		HandlerFunc(HandlerOrderQuote), session.CUSTOMER_ROLE))).Methods(http.MethodPost)
 	apiV1.Handle("/orders/{id}", middleware.SessionMiddleware(middleware.RolesMiddleware(
 		HandlerFunc(HandlerOrderDelete), session.ADMIN_ROLE))).Methods(http.MethodDelete)
+	apiV1.Handle("/orders/{id}/cancel", middleware.SessionMiddleware(middleware.RolesMiddleware(
+		HandlerFunc(HandlerOrderCancel), session.CUSTOMER_ROLE, session.ADMIN_ROLE))).Methods(http.MethodPost)
+	apiV1.Handle("/orders/{id}/invoice", middleware.SessionMiddleware(middleware.RolesMiddleware(
+		http.HandlerFunc(HandlerOrderInvoice), session.CUSTOMER_ROLE, session.ADMIN_ROLE))).Methods(http.MethodGet)
 
 	apiV1.Handle("/stocks", middleware.SessionMiddleware(
 		HandlerFunc(HandlerStockList))).Methods(http.MethodGet)
@@ -8,22 +8,26 @@ import (
 )
 
 var (
-	dbPool               *sql.DB
-	cachePool            *redis.Pool
-	logger               *helpers.Logger
-	customerService      *api.CustomerModule
-	adminService         *api.AdminModule
-	supplierService      *api.SupplierModule
-	courierService       *api.CourierModule
-	categoryService      *api.CategoryModule
-	productService       *api.ProductModule
-	orderService         *api.OrderModule
-	orderProductService  *api.OrderProductModule
-	paymentService       *api.PaymentModule
-	warehouseService     *api.WarehouseModule
-	stockService         *api.StockModule
-	shipmentService      *api.ShipmentModule
-	configurationService *api.ConfigurationModule
+	dbPool                 *sql.DB
+	cachePool              *redis.Pool
+	logger                 *helpers.Logger
+	customerService        *api.CustomerModule
+	adminService           *api.AdminModule
+	supplierService        *api.SupplierModule
+	courierService         *api.CourierModule
+	courierLocationService *api.CourierLocationModule
+	courierShiftService    *api.CourierShiftModule
+	categoryService        *api.CategoryModule
+	productService         *api.ProductModule
+	orderService           *api.OrderModule
+	orderProductService    *api.OrderProductModule
+	paymentService         *api.PaymentModule
+	warehouseService       *api.WarehouseModule
+	stockService           *api.StockModule
+	shipmentService        *api.ShipmentModule
+	configurationService   *api.ConfigurationModule
+	cartService            *api.CartModule
+	customerAddressService *api.CustomerAddressModule
 )
 
 func Init(db *sql.DB, cache *redis.Pool, log *helpers.Logger) {
@@ -34,6 +38,8 @@ func Init(db *sql.DB, cache *redis.Pool, log *helpers.Logger) {
 	adminService = api.NewAdminModule(dbPool, cachePool, logger)
 	supplierService = api.NewSupplierModule(dbPool, cachePool, logger)
 	courierService = api.NewCourierModule(dbPool, cachePool, logger)
+	courierLocationService = api.NewCourierLocationModule(dbPool, cachePool, logger)
+	courierShiftService = api.NewCourierShiftModule(dbPool, cachePool, logger)
 	categoryService = api.NewCategoryModule(dbPool, cachePool, logger)
 	productService = api.NewProductModule(dbPool, cachePool, logger)
 	orderService = api.NewOrderModule(dbPool, cachePool, logger)
@@ -43,4 +49,6 @@ func Init(db *sql.DB, cache *redis.Pool, log *helpers.Logger) {
 	stockService = api.NewStockModule(dbPool, cachePool, logger)
 	shipmentService = api.NewShipmentModule(dbPool, cachePool, logger)
 	configurationService = api.NewConfigurationModule(dbPool, cachePool, logger)
+	cartService = api.NewCartModule(dbPool, cachePool, logger)
+	customerAddressService = api.NewCustomerAddressModule(dbPool, cachePool, logger)
 }
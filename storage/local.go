@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const defaultLocalPath = "uploads"
+
+// LocalStore keeps blobs as files below a directory on the local filesystem.
+type LocalStore struct {
+	Dir string
+}
+
+func NewLocalStore(dir string) (LocalStore, error) {
+
+	if dir == "" {
+		dir = defaultLocalPath
+	}
+
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return LocalStore{}, err
+	}
+
+	return LocalStore{Dir: dir}, nil
+}
+
+func (s LocalStore) Put(ctx context.Context, key string, r io.Reader) error {
+
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(name), 0755)
+	if err != nil {
+		return err
+	}
+
+	// Write next to the target and rename, so readers never see half a file.
+	file, err := ioutil.TempFile(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(file.Name())
+
+	_, err = io.Copy(file, r)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), name)
+}
+
+func (s LocalStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+
+	name, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil, ErrBlobNotFound
+	}
+
+	return file, err
+}
+
+func (s LocalStore) Delete(ctx context.Context, key string) error {
+
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(name)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+// path maps a key to a file below Dir and refuses keys that would escape it.
+func (s LocalStore) path(key string) (string, error) {
+
+	clean := path.Clean("/" + key)
+	if key == "" || clean == "/" || strings.Contains(key, "..") {
+		return "", fmt.Errorf(`invalid blob key %q`, key)
+	}
+
+	return filepath.Join(s.Dir, filepath.FromSlash(clean)), nil
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLocalStore(t *testing.T) {
+
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "blob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	store, err := NewLocalStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = store.Put(ctx, "shipments/1/photo.jpg", strings.NewReader("photo"))
+	if err != nil {
+		t.Fatalf("Put = %v", err)
+	}
+
+	file, err := store.Open(ctx, "shipments/1/photo.jpg")
+	if err != nil {
+		t.Fatalf("Open = %v", err)
+	}
+
+	body, _ := ioutil.ReadAll(file)
+	file.Close()
+
+	if string(body) != "photo" {
+		t.Fatalf("Open read %q, want the stored photo", body)
+	}
+
+	err = store.Delete(ctx, "shipments/1/photo.jpg")
+	if err != nil {
+		t.Fatalf("Delete = %v", err)
+	}
+
+	_, err = store.Open(ctx, "shipments/1/photo.jpg")
+	if err != ErrBlobNotFound {
+		t.Fatalf("Open after Delete err = %v, want ErrBlobNotFound", err)
+	}
+
+	err = store.Put(ctx, "../outside.jpg", strings.NewReader("photo"))
+	if err == nil {
+		t.Fatal("a key escaping the directory should be refused")
+	}
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	DriverLocal = "local"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+type (
+	Storage struct {
+		Driver string
+		Path   string
+	}
+
+	// BlobStore keeps uploaded files under slash separated keys such as "shipments/<id>/photo.jpg".
+	BlobStore interface {
+		Put(ctx context.Context, key string, r io.Reader) error
+		// Open fails with ErrBlobNotFound when nothing is stored under the key.
+		Open(ctx context.Context, key string) (io.ReadCloser, error)
+		Delete(ctx context.Context, key string) error
+	}
+)
+
+var store BlobStore
+
+func Init(storage Storage) error {
+
+	blobStore, err := NewBlobStore(storage)
+	if err != nil {
+		return err
+	}
+
+	store = blobStore
+
+	return nil
+}
+
+func NewBlobStore(storage Storage) (BlobStore, error) {
+	switch storage.Driver {
+	case DriverLocal, "":
+		return NewLocalStore(storage.Path)
+	}
+
+	return nil, fmt.Errorf(`unknown storage driver %q`, storage.Driver)
+}
+
+// Store returns the blob store chosen in Init.
+func Store() BlobStore {
+	return store
+}
@@ -1,13 +1,20 @@
 package util
 
 import (
-	"afiqo-location/api"
-	"github.com/google/uuid"
+	uuid "github.com/satori/go.uuid"
 	"math"
 	"math/rand"
 	"time"
 )
 
+type (
+	Distance struct {
+		WarehouseID   uuid.UUID
+		DistanceValue int
+		DurationValue int
+	}
+)
+
 func GetGender(gender int) string {
 	switch gender {
 	case 1:
@@ -34,32 +41,124 @@ func count(number int) int {
 	return count
 }
 
+const (
+	OrderStatusOpen = iota
+	OrderStatusConfirmed
+	OrderStatusCompleted
+	OrderStatusCancelled
+)
+
+var orderStatusTransitions = map[int][]int{
+	OrderStatusOpen:      {OrderStatusConfirmed, OrderStatusCancelled},
+	OrderStatusConfirmed: {OrderStatusCompleted, OrderStatusCancelled},
+}
+
 func GetOrderStatus(status int) string {
 	switch status {
-	case 0:
+	case OrderStatusOpen:
 		return "Open"
-	case 1:
+	case OrderStatusConfirmed:
 		return "Confirmed"
-	case 2:
+	case OrderStatusCompleted:
 		return "Completed"
+	case OrderStatusCancelled:
+		return "Cancelled"
 
 	default:
 		return "Open"
 	}
 }
 
+func CanTransitOrderStatus(from, to int) bool {
+	for _, status := range orderStatusTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	PaymentStatusUnpaid = iota
+	PaymentStatusPaid
+	PaymentStatusFailed
+	PaymentStatusPartiallyRefunded
+	PaymentStatusRefunded
+	PaymentStatusExpired
+	PaymentStatusCancelled
+)
+
 func GetPaymentStatus(status int) string {
 	switch status {
-	case 0:
+	case PaymentStatusUnpaid:
 		return "Unpaid"
-	case 1:
+	case PaymentStatusPaid:
 		return "Paid"
+	case PaymentStatusFailed:
+		return "Failed"
+	case PaymentStatusPartiallyRefunded:
+		return "Partially Refunded"
+	case PaymentStatusRefunded:
+		return "Refunded"
+	case PaymentStatusExpired:
+		return "Expired"
+	case PaymentStatusCancelled:
+		return "Cancelled"
 	default:
 		return "Unpaid"
 	}
 }
 
-func GetMinDistance(distances []api.Distance) (float64, uuid.UUID) {
+// IsPaymentPayable tells whether a payment still waits for money from the customer.
+func IsPaymentPayable(status int) bool {
+	return status == PaymentStatusUnpaid || status == PaymentStatusFailed
+}
+
+// IsPaymentClosed tells whether a payment stopped waiting for money because its order was given up, either
+// when the payment window ran out or when the order was cancelled.
+func IsPaymentClosed(status int) bool {
+	return status == PaymentStatusExpired || status == PaymentStatusCancelled
+}
+
+const (
+	RefundStatusPending = iota
+	RefundStatusSucceeded
+	RefundStatusFailed
+)
+
+func GetRefundStatus(status int) string {
+	switch status {
+	case RefundStatusPending:
+		return "Pending"
+	case RefundStatusSucceeded:
+		return "Succeeded"
+	case RefundStatusFailed:
+		return "Failed"
+	default:
+		return "Pending"
+	}
+}
+
+const (
+	EmailStatusPending = iota
+	EmailStatusSent
+	EmailStatusFailed
+)
+
+func GetEmailStatus(status int) string {
+	switch status {
+	case EmailStatusPending:
+		return "Pending"
+	case EmailStatusSent:
+		return "Sent"
+	case EmailStatusFailed:
+		return "Failed"
+	default:
+		return "Pending"
+	}
+}
+
+func GetMinDistance(distances []Distance) (float64, uuid.UUID) {
 	min := distances[0].DistanceValue
 	id := distances[0].WarehouseID
 	for _, distance := range distances {
@@ -71,6 +170,18 @@ func GetMinDistance(distances []api.Distance) (float64, uuid.UUID) {
 	return MeterToKilometer(min), id
 }
 
+func GetMinDuration(distances []Distance) (int, uuid.UUID) {
+	min := distances[0].DurationValue
+	id := distances[0].WarehouseID
+	for _, distance := range distances {
+		if distance.DurationValue < min {
+			min = distance.DurationValue
+			id = distance.WarehouseID
+		}
+	}
+	return min, id
+}
+
 func RandomString(n int) string {
 
 	rand.Seed(time.Now().UnixNano())
@@ -83,18 +194,40 @@ func RandomString(n int) string {
 	return string(s)
 }
 
+const (
+	ShipmentStatusProcessing = iota + 1
+	ShipmentStatusShipped
+	ShipmentStatusOutForDelivery
+	ShipmentStatusDelivered
+)
+
+var shipmentStatusTransitions = map[int][]int{
+	ShipmentStatusProcessing:     {ShipmentStatusShipped},
+	ShipmentStatusShipped:        {ShipmentStatusOutForDelivery},
+	ShipmentStatusOutForDelivery: {ShipmentStatusDelivered},
+}
+
 func GetShipmentStatus(status int) string {
 	switch status {
-	case 1:
+	case ShipmentStatusProcessing:
 		return "Order Processing"
-	case 2:
+	case ShipmentStatusShipped:
 		return "Shipped"
-	case 3:
+	case ShipmentStatusOutForDelivery:
 		return "Out For Delivery"
-	case 4:
+	case ShipmentStatusDelivered:
 		return "Delivered"
 
 	default:
 		return "Order Processing"
 	}
 }
+
+func CanTransitShipmentStatus(from, to int) bool {
+	for _, status := range shipmentStatusTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}